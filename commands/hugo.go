@@ -15,16 +15,14 @@ package commands
 
 import (
 	"fmt"
-	"github.com/howeyc/fsnotify"
 	"github.com/mostafah/fsync"
 	"github.com/spf13/cobra"
 	"github.com/spf13/hugo/hugolib"
+	"github.com/spf13/hugo/transform"
 	"github.com/spf13/hugo/utils"
 	"github.com/spf13/nitro"
 	"os"
 	"path/filepath"
-	"strings"
-	"sync"
 	"time"
 )
 
@@ -84,87 +82,120 @@ func InitializeConfig() {
 	}
 }
 
-func build() {
+func build() *hugolib.Site {
+	utils.StopOnErr(Config.CheckPublishDir())
 	utils.CheckErr(copyStatic(), fmt.Sprintf("Error copying static files to %s", Config.GetAbsPath(Config.PublishDir)))
-	utils.StopOnErr(buildSite())
+	site, err := buildSite()
+	utils.StopOnErr(err)
 
 	if BuildWatch {
 		fmt.Println("Watching for changes in", Config.GetAbsPath(Config.ContentDir))
 		fmt.Println("Press ctrl+c to stop")
-		utils.CheckErr(NewWatcher(0))
+		utils.CheckErr(NewWatcher(site, 0))
 	}
+	return site
 }
 
 func copyStatic() error {
-	// Copy Static to Destination
-	return fsync.Sync(Config.GetAbsPath(Config.PublishDir+"/"), Config.GetAbsPath(Config.StaticDir+"/"))
+	publishDir := Config.GetAbsPath(Config.PublishDir + "/")
+	// Copy Static to Destination, the pages are rendered after so they
+	// replace the static files of the same path unless StaticWins is set.
+	for _, staticDir := range Config.GetStaticDirs() {
+		if staticDir != Config.GetAbsPath(Config.StaticDir) && !isDir(staticDir) {
+			continue // a theme without static files
+		}
+		staticDir += "/"
+		if err := syncStatic(publishDir, staticDir); err != nil {
+			return err
+		}
+		if err := copyModes(publishDir, staticDir); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func getDirList() []string {
-	var a []string
-	walker := func(path string, fi os.FileInfo, err error) error {
+// syncStatic copies the files in src to dst, leaving out those that aren't
+// to be published, see Config.StaticCopied. Directories holding only such
+// files aren't created.
+func syncStatic(dst, src string) error {
+	if len(Config.StaticExclude) == 0 {
+		return fsync.Sync(dst, src)
+	}
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
 		if err != nil {
-			fmt.Println("Walker: ", err)
-			return nil
+			return err
 		}
-
-		if fi.IsDir() {
-			a = append(a, path)
+		if copied, err := Config.StaticCopied(filepath.ToSlash(rel)); !copied || err != nil {
+			return err
 		}
-		return nil
-	}
-
-	filepath.Walk(Config.GetAbsPath(Config.ContentDir), walker)
-	filepath.Walk(Config.GetAbsPath(Config.LayoutDir), walker)
-	filepath.Walk(Config.GetAbsPath(Config.StaticDir), walker)
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return err
+		}
+		return fsync.Sync(target, path)
+	})
+}
 
-	return a
+// copyModes gives the copies in dst of the files in src the same
+// permissions, so executable or private static files stay that way.
+func copyModes(dst, src string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if tfi, err := os.Stat(target); err != nil || tfi.Mode().Perm() == fi.Mode().Perm() {
+			return nil
+		}
+		return os.Chmod(target, fi.Mode().Perm())
+	})
 }
 
-func buildSite() (err error) {
+// liveReloading has the pages built reload themselves when the site is
+// rebuilt, see liveReload.
+var liveReloading bool
+
+func buildSite() (site *hugolib.Site, err error) {
 	startTime := time.Now()
-	site := &hugolib.Site{Config: *Config}
+	site = &hugolib.Site{Config: *Config}
+	if liveReloading {
+		site.AddTransformer(transform.PriorityLiveReload, new(transform.LiveReloadInject))
+	}
 	err = site.Build()
 	if err != nil {
 		return
 	}
 	site.Stats()
 	fmt.Printf("in %v ms\n", int(1000*time.Since(startTime).Seconds()))
-	return nil
+	return
 }
 
-func NewWatcher(port int) error {
-	watcher, err := fsnotify.NewWatcher()
-	var wg sync.WaitGroup
-
-	if err != nil {
-		fmt.Println(err)
-		return err
+// NewWatcher keeps site up to date as its files change, serving it on port
+// when it isn't 0.
+func NewWatcher(site *hugolib.Site, port int) error {
+	site.StaticSync = func() error {
+		if err := copyStatic(); err != nil {
+			return fmt.Errorf("Error copying static files to %s: %s", Config.GetAbsPath(Config.PublishDir), err)
+		}
+		return nil
 	}
-
-	defer watcher.Close()
-
-	wg.Add(1)
-	go func() {
-		for {
-			select {
-			case ev := <-watcher.Event:
-				if Verbose {
-					fmt.Println(ev)
-				}
-				watchChange(ev)
-				// TODO add newly created directories to the watch list
-			case err := <-watcher.Error:
-				if err != nil {
-					fmt.Println("error:", err)
-				}
-			}
+	site.Rebuilt = func(err error) {
+		if err != nil {
+			fmt.Println(err)
+			return
 		}
-	}()
-
-	for _, d := range getDirList() {
-		if d != "" {
-			_ = watcher.Watch(d)
+		fmt.Println("Change detected, site rebuilt")
+		if liveReloading {
+			liveReload()
 		}
 	}
 
@@ -172,16 +203,10 @@ func NewWatcher(port int) error {
 		go serve(port)
 	}
 
-	wg.Wait()
-	return nil
+	return site.Watch(nil)
 }
 
-func watchChange(ev *fsnotify.FileEvent) {
-	if strings.HasPrefix(ev.Name, Config.GetAbsPath(Config.StaticDir)) {
-		fmt.Println("Static file changed, syncing\n")
-		utils.CheckErr(copyStatic(), fmt.Sprintf("Error copying static files to %s", Config.GetAbsPath(Config.PublishDir)))
-	} else {
-		fmt.Println("Change detected, rebuilding site\n")
-		utils.StopOnErr(buildSite())
-	}
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
 }
@@ -0,0 +1,90 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The pages served while watching hold a websocket open to the server (see
+// transform.LiveReloadInject) and reload when told the site was rebuilt.
+// Only what that takes of the websocket protocol is spoken: the handshake
+// and unmasked text frames from the server.
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var liveReloadConns = struct {
+	sync.Mutex
+	m map[net.Conn]bool
+}{m: make(map[net.Conn]bool)}
+
+func liveReloadHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "Expected a websocket", http.StatusBadRequest)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websockets are not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+
+	h := sha1.New()
+	io.WriteString(h, key+websocketGUID)
+	accept := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	if _, err = fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept); err != nil {
+		conn.Close()
+		return
+	}
+
+	liveReloadConns.Lock()
+	liveReloadConns.m[conn] = true
+	liveReloadConns.Unlock()
+
+	// Nothing the browser sends matters, but reading tells when it leaves.
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		liveReloadConns.Lock()
+		delete(liveReloadConns.m, conn)
+		liveReloadConns.Unlock()
+		conn.Close()
+	}()
+}
+
+// liveReload tells every page being watched to reload.
+func liveReload() {
+	frame := append([]byte{0x81, byte(len("reload"))}, "reload"...)
+
+	liveReloadConns.Lock()
+	defer liveReloadConns.Unlock()
+	for conn := range liveReloadConns.m {
+		if _, err := conn.Write(frame); err != nil {
+			delete(liveReloadConns.m, conn)
+			conn.Close()
+		}
+	}
+}
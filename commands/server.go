@@ -16,16 +16,36 @@ package commands
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spf13/hugo/transform"
+	"mime"
+	"net"
 	"net/http"
 	"strconv"
 )
 
 var serverPort int
+var serverInterface string
 var serverWatch bool
 
+// Types the server gets right whatever the system's mime tables say.
+var serverMimeTypes = map[string]string{
+	".css":  "text/css; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".js":   "application/javascript",
+	".json": "application/json",
+	".svg":  "image/svg+xml",
+	".woff": "application/font-woff",
+	".xml":  "application/xml",
+}
+
 func init() {
 	serverCmd.Flags().IntVarP(&serverPort, "port", "p", 1313, "port to run the server on")
-	serverCmd.Flags().BoolVarP(&serverWatch, "watch", "w", false, "watch filesystem for changes and recreate as needed")
+	serverCmd.Flags().StringVar(&serverInterface, "bind", "", "interface to which the server will bind, all of them by default")
+	serverCmd.Flags().BoolVarP(&serverWatch, "watch", "w", true, "watch filesystem for changes and recreate as needed")
+
+	for ext, typ := range serverMimeTypes {
+		mime.AddExtensionType(ext, typ)
+	}
 }
 
 var serverCmd = &cobra.Command{
@@ -40,17 +60,20 @@ Serve them up.`,
 func server(cmd *cobra.Command, args []string) {
 	InitializeConfig()
 
-	// Unless command line overrides, we use localhost for the server
+	// Unless command line overrides, we use the server's address
 	if BaseUrl == "" {
-		Config.BaseUrl = "http://localhost:" + strconv.Itoa(serverPort)
+		Config.BaseUrl = "http://" + serverHost() + "/"
 	}
 
-	build()
+	// Pages reload themselves as the site is rebuilt
+	liveReloading = serverWatch
+
+	site := build()
 
 	// Watch runs its own server as part of the routine
 	if serverWatch {
 		fmt.Println("Watching for changes in", Config.GetAbsPath(Config.ContentDir))
-		err := NewWatcher(serverPort)
+		err := NewWatcher(site, serverPort)
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -64,7 +87,22 @@ func serve(port int) {
 		fmt.Println("Serving pages from " + Config.GetAbsPath(Config.PublishDir))
 	}
 
-	fmt.Printf("Web Server is available at http://localhost:%v\n", port)
+	fmt.Printf("Web Server is available at http://%s/\n", serverHost())
 	fmt.Println("Press ctrl+c to stop")
-	panic(http.ListenAndServe(":"+strconv.Itoa(port), http.FileServer(http.Dir(Config.GetAbsPath(Config.PublishDir)))))
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(Config.GetAbsPath(Config.PublishDir))))
+	if liveReloading {
+		mux.HandleFunc(transform.LiveReloadPath, liveReloadHandler)
+	}
+	addr := net.JoinHostPort(serverInterface, strconv.Itoa(port))
+	panic(http.ListenAndServe(addr, mux))
+}
+
+// serverHost is the host and port to browse the server at.
+func serverHost() string {
+	host := serverInterface
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(serverPort))
 }
@@ -0,0 +1,79 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"time"
+)
+
+// Archive is a month or a year of content, for archive lists like
+// "June 2013 (4)".
+type Archive struct {
+	Date   time.Time // the first day of the month or year
+	Pages  Pages
+	yearly bool
+}
+
+func (a *Archive) Count() int { return len(a.Pages) }
+
+// Name is the month of the archive, e.g. "June 2013", or its year.
+func (a *Archive) Name() string {
+	if a.yearly {
+		return a.Date.Format("2006")
+	}
+	return a.Date.Format("January 2006")
+}
+
+// Archives is a list of archives, newest first.
+type Archives []*Archive
+
+// Year returns the archives of year y.
+func (a Archives) Year(y int) (archives Archives) {
+	for _, archive := range a {
+		if archive.Date.Year() == y {
+			archives = append(archives, archive)
+		}
+	}
+	return
+}
+
+// years groups the monthly archives a by year, newest first.
+func (a Archives) years() (years Archives) {
+	for _, archive := range a {
+		if len(years) == 0 || years[len(years)-1].Date.Year() != archive.Date.Year() {
+			date := time.Date(archive.Date.Year(), time.January, 1, 0, 0, 0, 0, archive.Date.Location())
+			years = append(years, &Archive{Date: date, yearly: true})
+		}
+		year := years[len(years)-1]
+		year.Pages = append(year.Pages, archive.Pages...)
+	}
+	return
+}
+
+// buildArchives groups the dated pages of s.Pages, which must already be
+// sorted, by month.
+func (s *Site) buildArchives() (archives Archives) {
+	for _, p := range s.Pages {
+		if p.Date.IsZero() {
+			continue
+		}
+		y, m, _ := p.Date.Date()
+		if n := len(archives); n == 0 || archives[n-1].Date.Year() != y || archives[n-1].Date.Month() != m {
+			archives = append(archives, &Archive{Date: time.Date(y, m, 1, 0, 0, 0, 0, p.Date.Location())})
+		}
+		archive := archives[len(archives)-1]
+		archive.Pages = append(archive.Pages, p)
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"testing"
+)
+
+var archivesFakeSource = []source.ByteSource{
+	{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-06-02\n---\n"), Section: "post"},
+	{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-06-20\n---\n"), Section: "post"},
+	{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-04-01\n---\n"), Section: "post"},
+	{Name: "post/d.md", Content: []byte("---\ntitle: d\ndate: 2012-12-24\n---\n"), Section: "post"},
+}
+
+func TestSiteArchives(t *testing.T) {
+	s := &Site{
+		Config: Config{BaseUrl: "http://auth/"},
+		Source: &source.InMemorySource{ByteSource: archivesFakeSource},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	var names []string
+	for _, a := range s.Info.Archives {
+		names = append(names, a.Name())
+	}
+	if !compareStringSlice(names, []string{"June 2013", "April 2013", "December 2012"}) {
+		t.Fatalf("Unexpected archives: %v", names)
+	}
+	if june := s.Info.Archives[0]; june.Count() != 2 || june.Pages[0].Title != "b" {
+		t.Errorf("Unexpected June archive: %v", june.Pages)
+	}
+	if len(s.Info.Archives.Year(2013)) != 2 || len(s.Info.Archives.Year(2011)) != 0 {
+		t.Errorf("Unexpected archives of 2013: %v", s.Info.Archives.Year(2013))
+	}
+
+	years := s.Info.ArchiveYears
+	if len(years) != 2 || years[0].Name() != "2013" || years[0].Count() != 3 || years[1].Count() != 1 {
+		t.Errorf("Unexpected years: %v", years)
+	}
+}
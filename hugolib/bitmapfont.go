@@ -0,0 +1,286 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// bitmapFont is a font of glyphs drawn with pixels, all of the same height,
+// for the text of social cards.
+type bitmapFont struct {
+	height int
+	glyphs map[rune]*bitmapGlyph
+}
+
+// bitmapGlyph is a character of a bitmapFont. Pixel x of row y is set when
+// bit width-1-x of rows[y] is.
+type bitmapGlyph struct {
+	advance, width int
+	rows           []uint64
+}
+
+func (g *bitmapGlyph) set(x, y int) bool {
+	return g.rows[y]>>uint(g.width-1-x)&1 == 1
+}
+
+// glyph is the glyph of r, that of "?" when the font doesn't have it.
+func (f *bitmapFont) glyph(r rune) *bitmapGlyph {
+	if g, ok := f.glyphs[r]; ok {
+		return g
+	}
+	return f.glyphs['?']
+}
+
+// missing are the characters of s, spaces aside, the font doesn't have.
+func (f *bitmapFont) missing(s string) (runes []rune) {
+	for _, r := range s {
+		if _, ok := f.glyphs[r]; !ok && !unicode.IsSpace(r) {
+			runes = append(runes, r)
+		}
+	}
+	return
+}
+
+// measure is the width of s in pixels of the font.
+func (f *bitmapFont) measure(s string) (width int) {
+	for _, r := range s {
+		if g := f.glyph(r); g != nil {
+			width += g.advance
+		}
+	}
+	return
+}
+
+// defaultFont is the font of social cards without OGImageFont, 5 by 7
+// pixels for the printable ASCII characters.
+func defaultFont() *bitmapFont {
+	f := &bitmapFont{height: 7, glyphs: make(map[rune]*bitmapGlyph)}
+	for i := range defaultGlyphs {
+		f.glyphs[rune(' '+i)] = &bitmapGlyph{advance: 6, width: 5, rows: defaultGlyphs[i][:]}
+	}
+	return f
+}
+
+// parseBDF reads a font in the Glyph Bitmap Distribution Format of X11, the
+// format most bitmap fonts are found in. The encodings of the glyphs are
+// taken to be unicode.
+func parseBDF(r io.Reader) (*bitmapFont, error) {
+	f := &bitmapFont{glyphs: make(map[rune]*bitmapGlyph)}
+	var (
+		baseline int
+		encoding = -1
+		g        *bitmapGlyph
+		bbx      [4]int
+		inBitmap bool
+		bitrow   int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		ints := func(n int) ([]int, error) {
+			if len(fields) < n+1 {
+				return nil, fmt.Errorf("Line %d: expected %d numbers after %s", line, n, fields[0])
+			}
+			v := make([]int, n)
+			for i := range v {
+				var err error
+				if v[i], err = strconv.Atoi(fields[i+1]); err != nil {
+					return nil, fmt.Errorf("Line %d: %s", line, err)
+				}
+			}
+			return v, nil
+		}
+
+		if inBitmap {
+			if fields[0] == "ENDCHAR" {
+				inBitmap = false
+			} else if bitrow < bbx[1] {
+				bits, err := strconv.ParseUint(fields[0], 16, 64)
+				if err != nil {
+					return nil, fmt.Errorf("Line %d: %s", line, err)
+				}
+				y := baseline - bbx[1] - bbx[3] + bitrow
+				if y >= 0 && y < f.height {
+					g.rows[y] = bits >> uint(len(fields[0])*4-bbx[0])
+				}
+				bitrow++
+			}
+			if !inBitmap && encoding >= 0 {
+				f.glyphs[rune(encoding)] = g
+			}
+			continue
+		}
+
+		switch fields[0] {
+		case "FONTBOUNDINGBOX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			f.height, baseline = v[1], v[1]+v[3]
+		case "STARTCHAR":
+			g, encoding, bbx = &bitmapGlyph{}, -1, [4]int{}
+		case "ENCODING":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			encoding = v[0]
+		case "DWIDTH":
+			if g == nil {
+				return nil, fmt.Errorf("Line %d: unexpected DWIDTH", line)
+			}
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			g.advance = v[0]
+		case "BBX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			copy(bbx[:], v)
+		case "BITMAP":
+			if g == nil || f.height == 0 {
+				return nil, fmt.Errorf("Line %d: unexpected BITMAP", line)
+			}
+			g.width = bbx[0]
+			if bbx[2] > 0 {
+				g.width += bbx[2]
+			}
+			if g.width > 64 {
+				return nil, fmt.Errorf("Line %d: glyph wider than 64 pixels", line)
+			}
+			g.rows = make([]uint64, f.height)
+			inBitmap, bitrow = true, 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(f.glyphs) == 0 {
+		return nil, fmt.Errorf("No glyphs found")
+	}
+	return f, nil
+}
+
+// defaultGlyphs are the rows of the glyphs of defaultFont, from " " to "~".
+var defaultGlyphs = [][7]uint64{
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // space
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04}, // !
+	{0x0a, 0x0a, 0x0a, 0x00, 0x00, 0x00, 0x00}, // "
+	{0x0a, 0x0a, 0x1f, 0x0a, 0x1f, 0x0a, 0x0a}, // #
+	{0x04, 0x0f, 0x14, 0x0e, 0x05, 0x1e, 0x04}, // $
+	{0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03}, // %
+	{0x0c, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0d}, // &
+	{0x04, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00}, // '
+	{0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02}, // (
+	{0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08}, // )
+	{0x00, 0x04, 0x15, 0x0e, 0x15, 0x04, 0x00}, // *
+	{0x00, 0x04, 0x04, 0x1f, 0x04, 0x04, 0x00}, // +
+	{0x00, 0x00, 0x00, 0x00, 0x0c, 0x04, 0x08}, // ,
+	{0x00, 0x00, 0x00, 0x1f, 0x00, 0x00, 0x00}, // -
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c}, // .
+	{0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00}, // /
+	{0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e}, // 0
+	{0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e}, // 1
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f}, // 2
+	{0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e}, // 3
+	{0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02}, // 4
+	{0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e}, // 5
+	{0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e}, // 6
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08}, // 7
+	{0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e}, // 8
+	{0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c}, // 9
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00}, // :
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x04, 0x08}, // ;
+	{0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02}, // <
+	{0x00, 0x00, 0x1f, 0x00, 0x1f, 0x00, 0x00}, // =
+	{0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08}, // >
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04}, // ?
+	{0x0e, 0x11, 0x01, 0x0d, 0x15, 0x15, 0x0e}, // @
+	{0x0e, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11}, // A
+	{0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e}, // B
+	{0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e}, // C
+	{0x1c, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1c}, // D
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f}, // E
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10}, // F
+	{0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f}, // G
+	{0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11}, // H
+	{0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e}, // I
+	{0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c}, // J
+	{0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11}, // K
+	{0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f}, // L
+	{0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11}, // M
+	{0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11}, // N
+	{0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e}, // O
+	{0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10}, // P
+	{0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d}, // Q
+	{0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11}, // R
+	{0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e}, // S
+	{0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // T
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e}, // U
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04}, // V
+	{0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a}, // W
+	{0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11}, // X
+	{0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04}, // Y
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f}, // Z
+	{0x0e, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0e}, // [
+	{0x00, 0x10, 0x08, 0x04, 0x02, 0x01, 0x00}, // \
+	{0x0e, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0e}, // ]
+	{0x04, 0x0a, 0x11, 0x00, 0x00, 0x00, 0x00}, // ^
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1f}, // _
+	{0x08, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00}, // `
+	{0x00, 0x00, 0x0e, 0x01, 0x0f, 0x11, 0x0f}, // a
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1e}, // b
+	{0x00, 0x00, 0x0e, 0x10, 0x10, 0x11, 0x0e}, // c
+	{0x01, 0x01, 0x0d, 0x13, 0x11, 0x11, 0x0f}, // d
+	{0x00, 0x00, 0x0e, 0x11, 0x1f, 0x10, 0x0e}, // e
+	{0x06, 0x09, 0x08, 0x1c, 0x08, 0x08, 0x08}, // f
+	{0x00, 0x0f, 0x11, 0x11, 0x0f, 0x01, 0x0e}, // g
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11}, // h
+	{0x04, 0x00, 0x0c, 0x04, 0x04, 0x04, 0x0e}, // i
+	{0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0c}, // j
+	{0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12}, // k
+	{0x0c, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e}, // l
+	{0x00, 0x00, 0x1a, 0x15, 0x15, 0x11, 0x11}, // m
+	{0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11}, // n
+	{0x00, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x0e}, // o
+	{0x00, 0x00, 0x1e, 0x11, 0x1e, 0x10, 0x10}, // p
+	{0x00, 0x00, 0x0d, 0x13, 0x0f, 0x01, 0x01}, // q
+	{0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10}, // r
+	{0x00, 0x00, 0x0e, 0x10, 0x0e, 0x01, 0x1e}, // s
+	{0x08, 0x08, 0x1c, 0x08, 0x08, 0x09, 0x06}, // t
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0d}, // u
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x0a, 0x04}, // v
+	{0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0a}, // w
+	{0x00, 0x00, 0x11, 0x0a, 0x04, 0x0a, 0x11}, // x
+	{0x00, 0x00, 0x11, 0x11, 0x0f, 0x01, 0x0e}, // y
+	{0x00, 0x00, 0x1f, 0x02, 0x04, 0x08, 0x1f}, // z
+	{0x02, 0x04, 0x04, 0x08, 0x04, 0x04, 0x02}, // {
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}, // |
+	{0x08, 0x04, 0x04, 0x02, 0x04, 0x04, 0x08}, // }
+	{0x00, 0x00, 0x08, 0x15, 0x02, 0x00, 0x00}, // ~
+}
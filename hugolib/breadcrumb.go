@@ -0,0 +1,90 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"html/template"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Crumb is one step of a breadcrumb trail. Permalink is empty for steps
+// that have no page of their own.
+type Crumb struct {
+	Title     string
+	Permalink template.HTML
+}
+
+// Breadcrumbs returns the trail from the home page down to the page:
+// home, then each directory the content lives in, then the page itself.
+func (p *Page) Breadcrumbs() []*Crumb {
+	link, _ := p.Permalink()
+	return p.Site.breadcrumbs(p.Dir, &Crumb{Title: p.LinkTitle(), Permalink: template.HTML(link)})
+}
+
+// Breadcrumbs returns the trail from the home page down to the node. It is
+// derived from the node's url, so the index of a term reads home, then the
+// index, then the term.
+func (n *Node) Breadcrumbs() []*Crumb {
+	self := &Crumb{Title: n.LinkTitle(), Permalink: n.Permalink}
+	if strings.TrimRight(string(n.Permalink), "/") == strings.TrimRight(string(n.Site.BaseUrl), "/") {
+		return []*Crumb{self}
+	}
+
+	dir := strings.TrimSuffix(n.Url, "index.html")
+	return n.Site.breadcrumbs(path.Dir(strings.Trim(dir, "/")), self)
+}
+
+// breadcrumbs builds home, then a crumb for every directory of dir, then
+// last.
+func (s *SiteInfo) breadcrumbs(dir string, last *Crumb) []*Crumb {
+	crumbs := []*Crumb{{Title: s.Title, Permalink: s.link("")}}
+	if dir = strings.Trim(path.Clean("/"+dir), "/"); dir != "" {
+		parts := strings.Split(dir, "/")
+		for i, part := range parts {
+			crumbs = append(crumbs, s.dirCrumb(path.Join(parts[:i+1]...), part))
+		}
+	}
+	return append(crumbs, last)
+}
+
+// dirCrumb describes a directory, linking it when a section list or an
+// index page is rendered for it.
+func (s *SiteInfo) dirCrumb(dir, name string) *Crumb {
+	if section := s.Sections.Get(dir); section != nil {
+		return &Crumb{Title: section.Title, Permalink: section.Permalink}
+	}
+	crumb := &Crumb{Title: s.title(name)}
+	if s.Config != nil {
+		for _, plural := range s.Config.Indexes {
+			if plural == dir {
+				crumb.Permalink = s.link(dir + "/")
+			}
+		}
+	}
+	return crumb
+}
+
+func (s *SiteInfo) link(p string) template.HTML {
+	base, err := url.Parse(string(s.BaseUrl))
+	if err != nil {
+		return template.HTML(p)
+	}
+	rel, err := url.Parse(p)
+	if err != nil {
+		return template.HTML(p)
+	}
+	return template.HTML(MakePermalink(base, rel).String())
+}
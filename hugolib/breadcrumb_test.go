@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"testing"
+)
+
+var breadcrumbFakeSource = []source.ByteSource{
+	{Name: "blog/first.md", Content: []byte("---\ntitle: First\n---\n"), Section: "blog"},
+	{Name: "blog/go/deep.md", Content: []byte("---\ntitle: Deep\nslug: deep\n---\n"), Section: "go"},
+	{Name: "about.md", Content: []byte("---\ntitle: About\n---\n"), Section: ""},
+}
+
+func checkCrumbs(t *testing.T, what string, crumbs []*Crumb, expected ...string) {
+	if len(crumbs) != len(expected)/2 {
+		t.Errorf("%s: expected %d crumbs, got: %d", what, len(expected)/2, len(crumbs))
+		return
+	}
+	for i, c := range crumbs {
+		if c.Title != expected[2*i] || string(c.Permalink) != expected[2*i+1] {
+			t.Errorf("%s: crumb %d expected %s %s, got: %s %s", what, i, expected[2*i], expected[2*i+1], c.Title, c.Permalink)
+		}
+	}
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	s := &Site{
+		Config: Config{
+			BaseUrl: "http://auth/bub/",
+			Title:   "Home",
+			Indexes: map[string]string{"tag": "tags"},
+		},
+		Source: &source.InMemorySource{ByteSource: breadcrumbFakeSource},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	for _, p := range s.Pages {
+		switch p.Title {
+		case "First":
+			checkCrumbs(t, p.Title, p.Breadcrumbs(),
+				"Home", "http://auth/bub/",
+				"Blogs", "http://auth/bub/blog/index.html",
+				"First", "http://auth/bub/blog/first")
+		case "Deep":
+			checkCrumbs(t, p.Title, p.Breadcrumbs(),
+				"Home", "http://auth/bub/",
+				"Blogs", "http://auth/bub/blog/index.html",
+				"Go", "",
+				"Deep", "http://auth/bub/blog/go/deep/")
+		case "About":
+			checkCrumbs(t, p.Title, p.Breadcrumbs(),
+				"Home", "http://auth/bub/",
+				"About", "http://auth/bub/about")
+		}
+	}
+
+	n := s.NewNode()
+	n.Title = "Go"
+	n.Url = "tags/go/index.html"
+	n.Permalink = permalink(s, n.Url)
+	checkCrumbs(t, "term", n.Breadcrumbs(),
+		"Home", "http://auth/bub/",
+		"Tags", "http://auth/bub/tags/",
+		"Go", "http://auth/bub/tags/go/index.html")
+
+	n = s.NewNode()
+	n.Title = "Home"
+	n.Permalink = permalink(s, "")
+	checkCrumbs(t, "home", n.Breadcrumbs(), "Home", "http://auth/bub/")
+}
@@ -0,0 +1,287 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/hugo/source"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A directory holding an index.md is a bundle: the index is a single page
+// named after the directory and every other file below it is a resource of
+// that page, published next to it.
+const bundleIndex = "index.md"
+
+// Resource is a file belonging to a bundled page.
+type Resource struct {
+	// Name is the path of the file relative to the bundle directory.
+	Name      string
+	Title     string
+	Params    map[string]interface{}
+	Permalink template.HTML
+	// Width and Height are the dimensions of an image, 0 for other files.
+	Width, Height int
+	// Exif is the metadata of a jpeg, nil when it has none.
+	Exif    *Exif
+	content []byte
+	target  string
+	order   int
+}
+
+// Resources are ordered as listed in the resources frontmatter, then by name.
+type Resources []*Resource
+
+func (r Resources) Len() int { return len(r) }
+func (r Resources) Less(i, j int) bool {
+	if r[i].order != r[j].order {
+		return r[i].order < r[j].order
+	}
+	return r[i].Name < r[j].Name
+}
+func (r Resources) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+
+// Get returns the resource with the given name or nil.
+func (r Resources) Get(name string) *Resource {
+	for _, res := range r {
+		if res.Name == name {
+			return res
+		}
+	}
+	return nil
+}
+
+// cleanDir returns dir without a trailing slash, "" for the content root.
+func cleanDir(dir string) string {
+	if dir = path.Clean("/" + dir); dir == "/" {
+		return ""
+	}
+	return dir[1:]
+}
+
+func filePath(file *source.File) string {
+	return path.Join(cleanDir(file.Dir), path.Base(file.LogicalName))
+}
+
+// sourcePath is the path of the content file p was read from, relative to the
+// content directory.
+func (p *Page) sourcePath() string {
+	return path.Join(cleanDir(p.Dir), path.Base(p.FileName))
+}
+
+func isBundleIndex(file *source.File) bool {
+	return path.Base(file.LogicalName) == bundleIndex
+}
+
+// bundleDirs finds the directories that are bundles.
+func bundleDirs(files []*source.File) (dirs []string) {
+	for _, file := range files {
+		if isBundleIndex(file) {
+			dirs = append(dirs, cleanDir(file.Dir))
+		}
+	}
+	return
+}
+
+// bundleOf returns the innermost bundle holding a file in dir, if any.
+func bundleOf(bundles []string, dir string) (bundle string, ok bool) {
+	for _, b := range bundles {
+		if (dir == b || b == "" || strings.HasPrefix(dir, b+"/")) && (!ok || len(b) > len(bundle)) {
+			bundle, ok = b, true
+		}
+	}
+	return
+}
+
+// readBundle creates the page for the bundle in dir from its index file.
+// The page takes the place of a file named after the directory.
+func (s *Site) readBundle(file *source.File, dir string) (*Page, error) {
+	name := path.Base(dir) + path.Ext(bundleIndex)
+	logical := path.Join(path.Dir(path.Dir(file.LogicalName)), name)
+	page, err := readFrom(file.Contents, logical, s.Info)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := cleanDir(path.Dir(dir))
+	page.Dir, page.Section = "", ""
+	if parent != "" {
+		page.Dir = parent + "/"
+		page.Section = path.Base(parent)
+	}
+	return page, nil
+}
+
+// addResource attaches file to the page of its bundle.
+func addResource(page *Page, bundle string, file *source.File) error {
+	content, err := ioutil.ReadAll(file.Contents)
+	if err != nil {
+		return err
+	}
+	name := filePath(file)
+	if bundle != "" {
+		name = strings.TrimPrefix(name, bundle+"/")
+	}
+	r := &Resource{Name: name, content: content}
+	if err = r.decodeImage(page.Site.Config.location()); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", page.FileName, err)
+	}
+	page.Resources = append(page.Resources, r)
+	return nil
+}
+
+// resourceMeta is an entry of the resources frontmatter of a bundle. It
+// applies to the resources whose name matches Src, a path.Match pattern.
+type resourceMeta struct {
+	Src    string
+	Title  string
+	Params map[string]interface{}
+}
+
+func parseResourceMeta(v interface{}) ([]resourceMeta, error) {
+	entries, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of resources, got %v", v)
+	}
+
+	meta := make([]resourceMeta, len(entries))
+	for i, entry := range entries {
+		m, ok := lowerMap(entry)
+		if !ok {
+			return nil, fmt.Errorf("expected a map for resource %d, got %v", i+1, entry)
+		}
+		for k, u := range m {
+			switch k {
+			case "src":
+				meta[i].Src = interfaceToString(u)
+			case "title":
+				meta[i].Title = interfaceToString(u)
+			case "params":
+				params, ok := paramValue(u).(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("params of resource %d must be a map", i+1)
+				}
+				meta[i].Params = params
+			default:
+				return nil, fmt.Errorf("unknown key %s for resource %d", k, i+1)
+			}
+		}
+		if meta[i].Src == "" {
+			return nil, fmt.Errorf("resource %d has no src", i+1)
+		}
+		if _, err := path.Match(meta[i].Src, ""); err != nil {
+			return nil, fmt.Errorf("bad src %q: %s", meta[i].Src, err)
+		}
+	}
+	return meta, nil
+}
+
+// applyResourceMeta sets the title, params and order of the resources from
+// the resources frontmatter. The first entry matching a resource sets its
+// order; for titles and params the first entry providing one wins. A
+// ":counter" in a title is replaced by the position of the resource among
+// those matched by the entry, starting at 1. Resources matched by no entry
+// come last.
+func (p *Page) applyResourceMeta() {
+	sort.Sort(p.Resources)
+	counters := make([]int, len(p.resources))
+	for _, r := range p.Resources {
+		r.order = len(p.resources) + 1
+		for i, meta := range p.resources {
+			if ok, _ := path.Match(meta.Src, r.Name); !ok {
+				continue
+			}
+			counters[i]++
+			if r.order > i {
+				r.order = i
+			}
+			if r.Title == "" && meta.Title != "" {
+				r.Title = strings.Replace(meta.Title, ":counter", strconv.Itoa(counters[i]), -1)
+			}
+			if r.Params == nil && meta.Params != nil {
+				r.Params = meta.Params
+			}
+		}
+		if r.Title == "" {
+			r.Title = r.Name
+		}
+		if r.Params == nil {
+			r.Params = make(map[string]interface{})
+		}
+	}
+	sort.Sort(p.Resources)
+}
+
+// setupResources orders the resources of p and places them below its url.
+func (p *Page) setupResources() {
+	if len(p.Resources) == 0 {
+		return
+	}
+	p.applyResourceMeta()
+
+	target, link := p.resourceBase()
+	for _, r := range p.Resources {
+		r.target = path.Join(target, r.Name)
+		r.Permalink = template.HTML(link + r.Name)
+	}
+	p.setupGallery()
+}
+
+// resourceBase is the directory the files of p are published in, and its
+// permalink with a trailing slash.
+func (p *Page) resourceBase() (target, link string) {
+	target = p.TargetPath()
+	if path.Base(target) == "index.html" {
+		target = path.Dir(target)
+	} else {
+		target = strings.TrimSuffix(target, path.Ext(target))
+	}
+
+	link, _ = p.Permalink()
+	if !strings.HasSuffix(link, "/") {
+		link = strings.TrimSuffix(link, path.Ext(link)) + "/"
+	}
+	return
+}
+
+// publishResources writes the resources of p and the thumbnails of its
+// images, see publishFile.
+func (s *Site) publishResources(p *Page) (err error) {
+	for _, r := range p.Resources {
+		if err = s.publishFile(r.target, bytes.NewReader(r.content)); err != nil {
+			return
+		}
+	}
+	for _, img := range p.Gallery {
+		for _, name := range thumbnailNames(img.Thumbnails) {
+			t := img.Thumbnails[name]
+			if err = s.publishFile(t.target, bytes.NewReader(t.content)); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+type filePublisher interface {
+	PublishFile(string, io.Reader) error
+}
@@ -0,0 +1,130 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"strings"
+	"testing"
+)
+
+var bundleFakeSource = []source.ByteSource{
+	{Name: "post/first.md", Content: []byte("---\ntitle: first\n---\n"), Section: "post"},
+	{Name: "post/trip/index.md", Content: []byte("---\ntitle: trip\n---\n![a](photo.jpg)"), Section: "trip"},
+	{Name: "post/trip/photo.jpg", Content: []byte("jpg"), Section: "trip"},
+	{Name: "post/trip/maps/route.gpx", Content: []byte("gpx"), Section: "maps"},
+}
+
+func TestLeafBundle(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: bundleFakeSource},
+		Config: Config{BaseUrl: "http://auth/bub/"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}{{ range .Resources }} {{ .Permalink }}{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	if len(s.Pages) != 2 {
+		t.Fatalf("Expected the bundle to be a single page, got %d pages", len(s.Pages))
+	}
+	if len(s.Sections) != 1 || len(s.Sections["post"]) != 2 {
+		t.Errorf("Expected the bundle to be in the post section, got: %v", s.Sections)
+	}
+
+	must(s.RenderPages())
+
+	for file, expected := range map[string]string{
+		"post/trip.html":           HTML("trip http://auth/bub/post/trip/maps/route.gpx http://auth/bub/post/trip/photo.jpg"),
+		"post/trip/photo.jpg":      "jpg",
+		"post/trip/maps/route.gpx": "gpx",
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s expected: %q, got: %q", file, expected, files[file])
+		}
+	}
+}
+
+func TestBundleOf(t *testing.T) {
+	bundles := []string{"post/trip", "post/trip/day1"}
+	for dir, expected := range map[string]string{
+		"post/trip":           "post/trip",
+		"post/trip/maps":      "post/trip",
+		"post/trip/day1/pics": "post/trip/day1",
+	} {
+		if bundle, ok := bundleOf(bundles, dir); !ok || bundle != expected {
+			t.Errorf("%s expected to be in bundle %s, got: %s", dir, expected, bundle)
+		}
+	}
+	if _, ok := bundleOf(bundles, "post/tripping"); ok {
+		t.Errorf("post/tripping should not be in a bundle")
+	}
+}
+
+func TestResourceMetadata(t *testing.T) {
+	for _, frontmatter := range []string{`---
+title: trip
+resources:
+  - src: "*.pdf"
+    title: Notes
+    params:
+      icon: pdf
+  - src: "*.jpg"
+    title: "Photo :counter"
+  - src: "a.jpg"
+    title: ignored
+---
+`, `+++
+title = "trip"
+[[resources]]
+src = "*.pdf"
+title = "Notes"
+[resources.params]
+icon = "pdf"
+[[resources]]
+src = "*.jpg"
+title = "Photo :counter"
+[[resources]]
+src = "a.jpg"
+title = "ignored"
++++
+`} {
+		files := make(map[string][]byte)
+		s := &Site{
+			Target: &target.InMemoryTarget{Files: files},
+			Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+				{Name: "post/trip/index.md", Content: []byte(frontmatter), Section: "trip"},
+				{Name: "post/trip/b.jpg", Content: []byte("jpg"), Section: "trip"},
+				{Name: "post/trip/a.jpg", Content: []byte("jpg"), Section: "trip"},
+				{Name: "post/trip/notes.pdf", Content: []byte("pdf"), Section: "trip"},
+				{Name: "post/trip/route.gpx", Content: []byte("gpx"), Section: "trip"},
+			}},
+		}
+		s.initializeSiteInfo()
+		s.prepTemplates()
+		must(s.addTemplate("_default/single.html", `{{ range .Resources }}[{{ .Name }} {{ .Title }}{{ with .Params.icon }} {{ . }}{{ end }}]{{ end }}`))
+		must(s.CreatePages())
+		must(s.RenderPages())
+
+		expected := HTML("[notes.pdf Notes pdf][a.jpg Photo 1][b.jpg Photo 2][route.gpx route.gpx]")
+		if got := string(files["post/trip.html"]); got != expected {
+			t.Errorf("Expected: %q, got: %q", expected, got)
+		}
+	}
+}
+
+func TestInvalidResourceMetadata(t *testing.T) {
+	for _, meta := range []string{
+		"resources: photo.jpg",
+		"resources:\n  - title: no src",
+		"resources:\n  - src: \"[\"",
+		"resources:\n  - src: a.jpg\n    name: b.jpg",
+	} {
+		_, err := ReadFrom(strings.NewReader("---\n"+meta+"\n---\n"), "trip/index.md")
+		if err == nil {
+			t.Errorf("Expected an error for %q", meta)
+		}
+	}
+}
@@ -14,6 +14,7 @@
 package hugolib
 
 import (
+	"bitbucket.org/pkg/inflect"
 	"encoding/json"
 	"fmt"
 	"github.com/BurntSushi/toml"
@@ -28,12 +29,67 @@ import (
 // config file items
 type Config struct {
 	ContentDir, PublishDir, BaseUrl, StaticDir string
+	ContentEncoding                            string // of content files without a byte order mark, see parser.ToUTF8
+	DataDir                                    string // files read into .Site.Data
+	I18nDir                                    string // translations for the T template func
+	PublishDirMode, PublishFileMode            string // octal, e.g. "0775"
 	Path, CacheDir, LayoutDir, DefaultLayout   string
+	CacheTTL                                   string   // how long data fetched by getJSON and getCSV is cached, e.g. "1h"
+	Theme, ThemesDir                           string   // layouts and static files used where the site has none, see GetThemeDirs
+	Themes                                     []string // theme components used after Theme
 	ConfigFile                                 string
 	Title                                      string
-	Indexes                                    map[string]string // singular, plural
+	TitleCase                                  string            // of section and index titles: "title", "ap" or "none"
+	Description                                string            // of the site, and of the homepage unless content/_index.md has one
+	Descriptions                               map[string]string // list directory, e.g. "post" or "tags/go", description
+	Author, Copyright                          string            // for the feeds
+	RSSLimit                                   int               // items in a feed, 0 for all
+	RSSFullContent                             bool              // feeds have the content instead of the summary
+	LanguageCode, LanguageDirection            string
+	Language, DefaultLanguage                  string                 // of the translations, see Site.translate
+	Hreflang                                   bool                   // link translated pages to each other, see Page.Translations
+	Indexes                                    map[string]string      // singular, plural, see Plurals when empty
+	Plurals                                    map[string]string      // singular, plural, used instead of inflect
+	Paginate                                   int                    // pages per list page, 0 for no pagination
+	IndexPaginate                              map[string]int         // plural, pages per term page
+	SectionOptions                             map[string]ListOptions // section, see Site.sectionOptions
+	ContentTypes                               map[string]ContentType // type, defaults of its content
+	IndexSources                               map[string]string      // plural, dotted frontmatter path
+	HierarchicalIndexes, SplitIndexStrings     bool
+	Menu                                       map[string][]MenuEntry // menu name, entries
 	ProcessFilters                             map[string][]string
 	BuildDrafts, UglyUrls, Verbose             bool
+	HideFuture                                 bool   // leave out content published later
+	Deterministic                              bool   // the same output for the same content, see SiteInfo.BuildDate
+	ExpirySoon, StaleDrafts                    string // durations, e.g. "168h", for hugo check
+	MarkDrafts                                 bool   // flag pages built from drafts
+	DraftBanner                                string // html shown on them, see transform.DraftMark
+	NavElement, NavAttrName                    string
+	NavMatchPrefix, Typography, LintHtml       bool
+	ExternalAssetHosts                         []string
+	Sanitize                                   bool
+	SanitizeElements, SanitizeAttributes       []string
+	Math                                       bool
+	MathDelimiters                             [][]string
+	Footnotes, DefinitionLists                 bool
+	FootnoteAnchorPrefix                       string
+	FootnoteReturnLinkContents                 string
+	HasCJKLanguage                             bool
+	RenderWorkers                              int               // pages rendered at once, defaults to the number of CPUs
+	Manifest                                   string            // build manifest to compare permalinks with, off when empty
+	AliasMoved                                 bool              // alias the old url of pages that moved
+	DateFormat                                 string            // Go layout of FormattedDate, "January 2, 2006" by default
+	Timezone                                   string            // of the dates of the front matter without one and of FormattedDate, e.g. "Europe/Paris", UTC by default
+	PygmentsCodeFences                         bool              // highlight the fenced code blocks of markdown naming their language
+	PygmentsStyle                              string            // of highlighted code, "monokai" by default
+	PygmentsUseClasses                         bool              // highlight with css classes instead of inline styles
+	StaticWins                                 bool              // a static file replaces the page rendered to the same path
+	StaticExclude, StaticPassthrough           []string          // patterns of static files not copied, and of those copied anyway, see StaticCopied
+	Thumbnails                                 map[string]string // size name, box the thumbnails of bundle images fit in, e.g. "300x200", see parseThumbnailSize
+	OGImages                                   bool              // make a social card for the pages without an image param, see Page.OGImage
+	OGImageFont                                string            // BDF font of the social cards, a built in one when empty
+	OGImageBackground, OGImageColor            string            // of the social cards, "#222222" and "#ffffff" by default
+	LenientShortcodes                          bool              // warn about the shortcodes failing instead of stopping the build
 }
 
 var c Config
@@ -53,8 +109,12 @@ func SetupConfig(cfgfile *string, path *string) *Config {
 	// set defaults
 	c.ContentDir = "content"
 	c.LayoutDir = "layouts"
+	c.ThemesDir = "themes"
 	c.PublishDir = "public"
 	c.StaticDir = "static"
+	c.DataDir = "data"
+	c.I18nDir = "i18n"
+	c.DefaultLanguage = "en"
 	c.DefaultLayout = "post"
 	c.BuildDrafts = false
 	c.UglyUrls = false
@@ -68,6 +128,11 @@ func SetupConfig(cfgfile *string, path *string) *Config {
 		c.Indexes["tag"] = "tags"
 		c.Indexes["category"] = "categories"
 	}
+	for singular, plural := range c.Indexes {
+		if plural == "" {
+			c.Indexes[singular] = c.pluralize(singular)
+		}
+	}
 
 	if !strings.HasSuffix(c.BaseUrl, "/") {
 		c.BaseUrl = c.BaseUrl + "/"
@@ -147,6 +212,50 @@ func findPath() (string, error) {
 	return path, nil
 }
 
+// GetThemeDirs are the absolute paths of Theme and Themes, the first taking
+// precedence over the following ones when they have files of the same name.
+func (c *Config) GetThemeDirs() (dirs []string) {
+	for _, theme := range append([]string{c.Theme}, c.Themes...) {
+		if theme != "" {
+			dirs = append(dirs, c.GetAbsPath(filepath.Join(c.ThemesDir, theme)))
+		}
+	}
+	return
+}
+
+// GetStaticDirs are the absolute paths of the static dirs in the order their
+// files are copied: the themes, the last one first, then the site, so the
+// files of the site and of the themes taking precedence replace theirs.
+func (c *Config) GetStaticDirs() (dirs []string) {
+	for _, theme := range c.GetThemeDirs() {
+		dirs = append([]string{filepath.Join(theme, "static")}, dirs...)
+	}
+	return append(dirs, c.GetAbsPath(c.StaticDir))
+}
+
+// pluralize is the plural of name in Plurals, e.g. "documentation" for
+// "documentation", else the one inflect guesses.
+func (c *Config) pluralize(name string) string {
+	if plural, ok := c.Plurals[name]; ok {
+		return plural
+	}
+	if plural, ok := c.Plurals[strings.ToLower(name)]; ok {
+		return plural
+	}
+	return inflect.Pluralize(name)
+}
+
+// singularize is the singular of name in Plurals, else the one inflect
+// guesses.
+func (c *Config) singularize(name string) string {
+	for singular, plural := range c.Plurals {
+		if plural == name || plural == strings.ToLower(name) {
+			return singular
+		}
+	}
+	return inflect.Singularize(name)
+}
+
 // GetAbsPath return the absolute path for a given path with the internal slashes
 // properly converted.
 func (c *Config) GetAbsPath(name string) string {
@@ -0,0 +1,100 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	helper "github.com/spf13/hugo/template"
+	"path"
+	"strings"
+)
+
+// ContentType holds the defaults of the content of a type, set in
+// Config.ContentTypes. The type of a page is the one of its front matter,
+// else its section.
+type ContentType struct {
+	Layout    string                 // used instead of "single" unless the page sets one
+	Permalink string                 // pattern of the urls of pages without one, see expandPermalink
+	Archetype map[string]interface{} // params of pages whose front matter doesn't set them
+	NoFeed    bool                   // keeps its pages out of the feeds, and its section without one
+	List      ListOptions            // of the section named after the type, see Site.sectionOptions
+}
+
+// contentType is the configuration of the type t, the zero ContentType when
+// there is none.
+func (s *Site) contentType(t string) ContentType {
+	for name, ct := range s.Config.ContentTypes {
+		if strings.ToLower(name) == strings.ToLower(t) {
+			return ct
+		}
+	}
+	return ContentType{}
+}
+
+// applyContentType gives p the defaults of its type.
+func (s *Site) applyContentType(p *Page) {
+	ct := s.contentType(p.Type())
+	if p.layout == "" {
+		p.layout = ct.Layout
+	}
+	for k, v := range ct.Archetype {
+		k = strings.ToLower(k)
+		if _, ok := p.Params[k]; ok {
+			continue
+		}
+		if pv := paramValue(v); pv != nil {
+			p.Params[k] = pv
+		}
+	}
+	if ct.Permalink != "" && len(strings.TrimSpace(p.Url)) <= 2 {
+		p.Url = helper.Urlize(expandPermalink(p, ct.Permalink))
+	}
+}
+
+// expandPermalink replaces the placeholders of pattern with the values of p:
+// :year, :month and :day of its date, :section, :sections, all the
+// directories of its file, :title, :filename, the name of its file without
+// extension, and :slug, its slug or else its filename.
+// "/:section/:year/:slug/" turns post/hello.md into /post/2013/hello/, and
+// "/:sections/:slug/" docs/guide/install.md into /docs/guide/install/.
+func expandPermalink(p *Page, pattern string) string {
+	filename := strings.TrimSuffix(path.Base(p.FileName), path.Ext(p.FileName))
+	slug := strings.TrimSpace(p.Slug)
+	if slug == "" {
+		slug = filename
+	}
+	return strings.NewReplacer(
+		":year", p.Date.Format("2006"),
+		":month", p.Date.Format("01"),
+		":day", p.Date.Format("02"),
+		":sections", strings.Trim(path.Clean("/"+p.Dir), "/"),
+		":section", p.Section,
+		":title", helper.Urlize(p.Title),
+		":filename", filename,
+		":slug", slug,
+	).Replace(pattern)
+}
+
+// feedPages leaves the pages of types without feeds out of pages.
+func (s *Site) feedPages(pages Pages) Pages {
+	if len(s.Config.ContentTypes) == 0 {
+		return pages
+	}
+	var kept Pages
+	for _, p := range pages {
+		if !s.contentType(p.Type()).NoFeed {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
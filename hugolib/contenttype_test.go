@@ -0,0 +1,131 @@
+package hugolib
+
+import (
+	"fmt"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"testing"
+)
+
+func TestContentTypes(t *testing.T) {
+	var sources []source.ByteSource
+	for i := 1; i <= 3; i++ {
+		sources = append(sources,
+			source.ByteSource{
+				Name:    fmt.Sprintf("post/post%d.md", i),
+				Content: []byte(fmt.Sprintf("---\ntitle: Post %d\ndate: 2013-0%d-01\n---\n", i, i)),
+				Section: "post",
+			},
+			source.ByteSource{
+				Name:    fmt.Sprintf("note/note%d.md", i),
+				Content: []byte(fmt.Sprintf("---\ntitle: Note %d\ndate: 2013-0%d-02\n---\n", i, i)),
+				Section: "note",
+			})
+	}
+	sources = append(sources,
+		source.ByteSource{Name: "post/own.md", Content: []byte("---\ntitle: Own\ndate: 2012-01-01\nurl: /own/\nauthor: me\nlayout: plain\n---\n"), Section: "post"},
+		source.ByteSource{Name: "note/post.md", Content: []byte("---\ntitle: Typed\ndate: 2012-01-01\ntype: post\n---\n"), Section: "note"},
+	)
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: sources},
+		Config: Config{
+			BaseUrl: "http://auth/",
+			ContentTypes: map[string]ContentType{
+				"post": {
+					Layout:    "article",
+					Permalink: "/blog/:year/:month/:slug/",
+					Archetype: map[string]interface{}{"author": "steve", "toc": "yes"},
+					List:      ListOptions{Paginate: 2, Sort: "title"},
+				},
+				"note": {NoFeed: true},
+			},
+			SectionOptions: map[string]ListOptions{"post": {Order: "desc"}},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/article.html", "article {{ .Title }} by {{ .Params.author }}, toc {{ .Params.toc }}"))
+	must(s.addTemplate("_default/plain.html", "plain {{ .Title }} by {{ .Params.author }}"))
+	must(s.addTemplate("_default/single.html", "single {{ .Title }}"))
+	must(s.addTemplate("_default/list.html", "{{ range .Data.Pages }}{{ .Title }},{{ end }}"))
+	must(s.addTemplate("rss.xml", "{{ range .Data.Pages }}{{ .Title }},{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+	must(s.RenderLists())
+	must(s.RenderHomePage())
+
+	for file, expected := range map[string]string{
+		"/blog/2013/02/post2/index.html": HTML("article Post 2 by steve, toc yes"),
+		"/own/index.html":                HTML("plain Own by me"),
+		"/blog/2012/01/post/index.html":  HTML("article Typed by steve, toc yes"),
+		"note/note1.html":                HTML("single Note 1"),
+		"post":                           HTML("Post 3,Post 2,"),
+		"post/page/2":                    HTML("Post 1,Own,"),
+		"note":                           HTML("Note 3,Note 2,Note 1,Typed,"),
+		".xml":                           "Post 3,Post 2,Post 1,Own,Typed,",
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", file, expected, files[file])
+		}
+	}
+	if _, ok := files["note.xml"]; ok {
+		t.Errorf("Expected no feed for the notes")
+	}
+	if s.Info.Sections.Get("note").RSSLink != "" {
+		t.Errorf("Expected the notes to link no feed")
+	}
+}
+
+func TestNestedPermalinks(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "docs/guide/install/linux.md", Content: []byte("---\ntitle: Linux\n---\n"), Section: "install"},
+			{Name: "docs/guide/install/mac.md", Content: []byte("---\ntitle: Mac\nslug: macos\n---\n"), Section: "install"},
+			{Name: "docs/guide/faq/why.md", Content: []byte("---\ntitle: Why\n---\n"), Section: "faq"},
+		}},
+		Config: Config{
+			BaseUrl:      "http://auth/",
+			ContentTypes: map[string]ContentType{"faq": {Permalink: "/:sections/:slug/"}},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	for file, expected := range map[string]string{
+		"docs/guide/install/linux.html":  HTML("Linux"),
+		"docs/guide/install/macos.html":  HTML("Mac"),
+		"/docs/guide/faq/why/index.html": HTML("Why"),
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q, rendered: %v", file, expected, files[file], renderedFiles(files))
+		}
+	}
+
+	for _, p := range s.Pages {
+		expected := map[string]string{
+			"Linux": "http://auth/docs/guide/install/linux",
+			"Mac":   "http://auth/docs/guide/install/macos/",
+			"Why":   "http://auth/docs/guide/faq/why/",
+		}[p.Title]
+		if plink, _ := p.Permalink(); plink != expected {
+			t.Errorf("Expected the permalink of %s to be %s, got: %s", p.Title, expected, plink)
+		}
+	}
+
+	// The dirs of content read from the filesystem end in a slash.
+	p := s.Pages[0]
+	p.Dir, p.Slug = "docs/guide/install/", "slugged"
+	if plink, _ := p.Permalink(); plink != "http://auth/docs/guide/install/slugged/" {
+		t.Errorf("Expected no empty path segment in the permalink, got: %s", plink)
+	}
+}
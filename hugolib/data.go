@@ -0,0 +1,146 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io/ioutil"
+	"launchpad.net/goyaml"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (s *Site) absDataDir() string {
+	return s.Config.GetAbsPath(s.Config.DataDir)
+}
+
+// loadData reads the yaml, json and toml files of the data directory into
+// SiteInfo.Data, keyed by their names without the extension. Directories
+// become nested maps, so data/authors/steve.yaml is .Site.Data.authors.steve.
+// Two files or a file and a directory with the same key are an error.
+func (s *Site) loadData() error {
+	s.Info.Data = make(map[string]interface{})
+	if s.Config.DataDir == "" {
+		return nil
+	}
+	base := s.absDataDir()
+	if b, _ := dirExists(base); !b {
+		return nil
+	}
+
+	// the file each key was read from
+	from := make(map[string]string)
+	return filepath.Walk(base, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(fi.Name(), ".") && name != base {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, name)
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(rel)
+		switch ext {
+		case ".yaml", ".yml", ".json", ".toml":
+		default:
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		v, err := readData(ext, content)
+		if err != nil {
+			return fmt.Errorf("Error reading data file %s: %s", rel, err)
+		}
+
+		keys := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ext)), "/")
+		m := s.Info.Data
+		for i, key := range keys[:len(keys)-1] {
+			if f, ok := from[strings.Join(keys[:i+1], "/")]; ok {
+				return fmt.Errorf("Data file %s and directory %s have the same key %s", f, filepath.Dir(rel), key)
+			}
+			sub, ok := m[key].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				m[key] = sub
+			}
+			m = sub
+		}
+		key := keys[len(keys)-1]
+		if f, ok := from[strings.Join(keys, "/")]; ok {
+			return fmt.Errorf("Data files %s and %s have the same key %s", f, rel, key)
+		}
+		if _, ok := m[key]; ok {
+			return fmt.Errorf("Data file %s and directory %s have the same key %s", rel, strings.TrimSuffix(rel, ext), key)
+		}
+		from[strings.Join(keys, "/")] = rel
+		m[key] = v
+		return nil
+	})
+}
+
+func readData(ext string, content []byte) (v interface{}, err error) {
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(content, &v)
+	case ".toml":
+		m := make(map[string]interface{})
+		_, err = toml.Decode(string(content), &m)
+		v = m
+	default:
+		err = goyaml.Unmarshal(content, &v)
+	}
+	return dataValue(v), err
+}
+
+// dataValue turns the maps of v into maps with string keys, which templates
+// can walk with .key.
+func dataValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, u := range vv {
+			m[fmt.Sprint(k)] = dataValue(u)
+		}
+		return m
+	case map[string]interface{}:
+		for k, u := range vv {
+			vv[k] = dataValue(u)
+		}
+	case []interface{}:
+		for i, u := range vv {
+			vv[i] = dataValue(u)
+		}
+	case []map[string]interface{}:
+		a := make([]interface{}, len(vv))
+		for i, u := range vv {
+			a[i] = dataValue(u)
+		}
+		return a
+	}
+	return v
+}
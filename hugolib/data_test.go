@@ -0,0 +1,73 @@
+package hugolib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func dataSite(t *testing.T, files map[string]string) (*Site, func()) {
+	dir, err := ioutil.TempDir("", "hugo-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, "data", filepath.FromSlash(name))
+		must(os.MkdirAll(filepath.Dir(name), 0755))
+		must(ioutil.WriteFile(name, []byte(content), 0644))
+	}
+	s := &Site{Config: Config{Path: dir, DataDir: "data"}}
+	s.initializeSiteInfo()
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadData(t *testing.T) {
+	s, cleanup := dataSite(t, map[string]string{
+		"authors/steve.yaml": "name: Steve\nlinks:\n  - site: github\n    url: http://github.com/spf13\n",
+		"event.json":         `{"speakers": [{"name": "Bjørn"}, {"name": "Ann"}]}`,
+		"shop.toml":          "[[products]]\nname = \"hat\"\nprice = 10\n",
+		".draft.yaml":        "not: read",
+		"notes.txt":          "ignored",
+	})
+	defer cleanup()
+	must(s.loadData())
+
+	if len(s.Info.Data) != 3 {
+		t.Errorf("Expected authors, event and shop, got: %v", s.Info.Data)
+	}
+	s.prepTemplates()
+	must(s.addTemplate("data.html", "{{ with .Site.Data }}{{ .authors.steve.name }} "+
+		"{{ range .authors.steve.links }}{{ .site }}{{ end }} "+
+		"{{ range .event.speakers }}{{ .name }},{{ end }} "+
+		"{{ range .shop.products }}{{ .name }} {{ .price }}{{ end }}{{ end }}"))
+	out := new(bytes.Buffer)
+	must(s.Tmpl.ExecuteTemplate(out, "data.html", s.NewNode()))
+	if expected := "Steve github Bjørn,Ann, hat 10"; out.String() != expected {
+		t.Errorf("Expected %q, got: %q", expected, out.String())
+	}
+}
+
+func TestLoadInvalidData(t *testing.T) {
+	s, cleanup := dataSite(t, map[string]string{"broken.json": "{"})
+	defer cleanup()
+	if err := s.loadData(); err == nil {
+		t.Errorf("Expected an error reading broken.json")
+	}
+}
+
+func TestLoadDataSameKey(t *testing.T) {
+	for _, files := range []map[string]string{
+		{"authors.yaml": "steve: {name: Steve}", "authors/ann.yaml": "name: Ann"},
+		{"authors.yaml": "steve: {name: Steve}", "authors.json": `{"ann": {"name": "Ann"}}`},
+	} {
+		s, cleanup := dataSite(t, files)
+		err := s.loadData()
+		cleanup()
+		if err == nil || !strings.Contains(err.Error(), "same key authors") {
+			t.Errorf("Expected an error about the key authors of %v, got: %v", files, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"github.com/spf13/hugo/target"
+	"io"
+)
+
+// Internal templates are used when the layouts don't have their own, e.g.
+// "rss.xml" falls back to "_internal/rss.xml", so a site renders something
+// before it has any layout.
+const (
+	internalPrefix = "_internal/"
+
+	defaultSingle = `<!DOCTYPE html>
+<html{{ with .LanguageCode }} lang="{{ . }}"{{ end }} dir="{{ .LanguageDirection }}">
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Title }} | {{ .Site.Title }}</title>
+</head>
+<body>
+  <h1>{{ .Title }}</h1>
+  {{ .Content }}
+</body>
+</html>
+`
+
+	defaultList = `<!DOCTYPE html>
+<html{{ with .LanguageCode }} lang="{{ . }}"{{ end }} dir="{{ .LanguageDirection }}">
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Title }}</title>{{ with .RSSLink }}
+  <link rel="alternate" type="application/rss+xml" href="{{ . }}">{{ end }}
+</head>
+<body>
+  <h1>{{ .Title }}</h1>
+  <ul>{{ range .Data.Pages }}
+    <li><a href="{{ .Permalink }}">{{ .LinkTitle }}</a></li>{{ end }}
+  </ul>
+</body>
+</html>
+`
+
+	defaultRss = `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>{{ .Title }} on {{ .Site.Title }}</title>
+    <link>{{ .Permalink }}</link>
+    <description>{{ .Title }}</description>{{ with .Site.LanguageCode }}
+    <language>{{ . }}</language>{{ end }}{{ with .Site.Author }}
+    <managingEditor>{{ . }}</managingEditor>{{ end }}{{ with .Site.Copyright }}
+    <copyright>{{ . }}</copyright>{{ end }}{{ if not .Site.LastChange.IsZero }}
+    <lastBuildDate>{{ .Site.LastChange | rfc1123 }}</lastBuildDate>{{ end }}
+    <atom:link href="{{ .Permalink }}" rel="self" type="application/rss+xml" />{{ range .Data.Pages }}
+    <item>
+      <title>{{ .Title }}</title>
+      <link>{{ .Permalink }}</link>{{ if not .Date.IsZero }}
+      <pubDate>{{ .Date | rfc1123 }}</pubDate>{{ end }}
+      <guid>{{ .Permalink }}</guid>
+      <description>{{ if $.Site.Config.RSSFullContent }}{{ .Content | xmlEscape }}{{ else }}{{ .Summary | xmlEscape }}{{ end }}</description>
+    </item>{{ end }}
+  </channel>
+</rss>
+`
+
+	defaultSitemap = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">{{ range .Data.Pages }}
+  <url>
+    <loc>{{ .Permalink }}</loc>{{ if not .Date.IsZero }}
+    <lastmod>{{ .FormattedDate "2006-01-02" }}</lastmod>{{ end }}
+  </url>{{ end }}
+</urlset>
+`
+
+	defaultRobots = `User-agent: *
+{{ with .Data.Sitemap }}Sitemap: {{ . }}
+{{ end }}`
+
+	defaultOpenGraph = `<meta property="og:title" content="{{ .Title }}" />
+<meta property="og:url" content="{{ .Permalink }}" />{{ with .Description }}
+<meta property="og:description" content="{{ . }}" />{{ end }}{{ with .Site.Title }}
+<meta property="og:site_name" content="{{ . }}" />{{ end }}{{ with .OGImage }}
+<meta property="og:image" content="{{ . }}" />{{ end }}
+`
+
+	// The built in shortcodes. Their lines aren't indented, so markdown
+	// leaves their output alone when they are used as {{% %}}.
+	shortcodeFigure = `{{ $res := .Page.Resources.Get (.Get "src") }}<figure{{ with .Get "class" }} class="{{ . }}"{{ end }}>
+{{ with .Get "link" }}<a href="{{ . }}">{{ end }}<img src="{{ with $res }}{{ .Permalink }}{{ else }}{{ .Get "src" }}{{ end }}"{{ with .Get "alt" }} alt="{{ . }}"{{ end }}{{ if or (.Get "width") (.Get "height") }}{{ with .Get "width" }} width="{{ . }}"{{ end }}{{ with .Get "height" }} height="{{ . }}"{{ end }}{{ else }}{{ with $res }}{{ if .Width }} width="{{ .Width }}" height="{{ .Height }}"{{ end }}{{ end }}{{ end }} />{{ if .Get "link" }}</a>{{ end }}{{ if or (.Get "title") (.Get "caption") }}
+<figcaption>{{ with .Get "title" }}<h4>{{ . }}</h4>{{ end }}{{ with .Get "caption" }}<p>{{ . }}</p>{{ end }}</figcaption>{{ end }}
+</figure>`
+
+	shortcodeYoutube = `<div class="embed video-player">
+<iframe class="youtube-player" type="text/html" width="640" height="385" src="//www.youtube.com/embed/{{ .Get 0 }}" allowfullscreen frameborder="0"></iframe>
+</div>`
+
+	shortcodeVimeo = `<div class="embed video-player">
+<iframe src="//player.vimeo.com/video/{{ .Get 0 }}" width="640" height="360" frameborder="0" allowfullscreen></iframe>
+</div>`
+
+	shortcodeGist = `<script src="https://gist.github.com/{{ .Get 0 }}/{{ .Get 1 }}.js{{ with .Get 2 }}?file={{ . }}{{ end }}"></script>`
+
+	shortcodeTweet = `<blockquote class="twitter-tweet"><a href="https://twitter.com/twitter/status/{{ .Get 0 }}"></a></blockquote>
+<script async src="//platform.twitter.com/widgets.js" charset="utf-8"></script>`
+)
+
+var internalTemplates = []struct{ name, tpl string }{
+	{"_internal/_default/single.html", defaultSingle},
+	{"_internal/_default/list.html", defaultList},
+	{"_internal/rss.xml", defaultRss},
+	{"_internal/sitemap.xml", defaultSitemap},
+	{"_internal/robots.txt", defaultRobots},
+	{"_internal/alias.html", target.ALIAS},
+	{"_internal/alias.xhtml", target.ALIAS_XHTML},
+	{"_internal/partials/opengraph.html", defaultOpenGraph},
+	{"_internal/shortcodes/figure.html", shortcodeFigure},
+	{"_internal/shortcodes/youtube.html", shortcodeYoutube},
+	{"_internal/shortcodes/vimeo.html", shortcodeVimeo},
+	{"_internal/shortcodes/gist.html", shortcodeGist},
+	{"_internal/shortcodes/tweet.html", shortcodeTweet},
+}
+
+func (s *Site) addInternalTemplates() error {
+	for _, t := range internalTemplates {
+		if err := s.Tmpl.AddTemplate(t.name, t.tpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aliasLayouts are the layouts tried for the templates of
+// target.HTMLRedirectAlias.
+var aliasLayouts = map[string][]string{
+	"alias":       {"alias.html"},
+	"alias-xhtml": {"alias.xhtml"},
+}
+
+// aliasTemplates lets the layouts replace the pages written for aliases.
+type aliasTemplates struct {
+	s *Site
+}
+
+func (t aliasTemplates) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	layout := t.s.findFirstLayout(aliasLayouts[name]...)
+	if layout == "" {
+		return fmt.Errorf("Layout not found: %s", name)
+	}
+	return t.s.Tmpl.ExecuteTemplate(w, layout, data)
+}
@@ -0,0 +1,238 @@
+package hugolib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultFeed(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\n---\n*a*"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", Title: "Site"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/list.html", "{{ range .Alternates }}{{ .Permalink }}{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderHomePage())
+
+	if string(files["/"]) != HTML("http://auth/index.xml") {
+		t.Errorf("Expected the homepage to link its feed, got: %q", files["/"])
+	}
+	feed := string(files[".xml"])
+	for _, expected := range []string{
+		"<title>Recent Content on Site</title>",
+		"<atom:link href=\"http://auth/index.xml\" rel=\"self\" type=\"application/rss+xml\" />",
+		"<title>a</title>",
+		"<guid>http://auth/post/a</guid>",
+		"<description>a</description>",
+	} {
+		if !strings.Contains(feed, expected) {
+			t.Errorf("Expected the default feed to contain %q, got:\n%s", expected, feed)
+		}
+	}
+}
+
+func TestAliasLayout(t *testing.T) {
+	s := new(Site)
+	s.prepTemplates()
+	tmpl := aliasTemplates{s}
+	node := &target.AliasNode{Permalink: template.HTML("http://auth/post/a")}
+
+	out := new(bytes.Buffer)
+	must(tmpl.ExecuteTemplate(out, "alias", node))
+	if !strings.Contains(out.String(), `content="0;url=http://auth/post/a"`) {
+		t.Errorf("Expected the default alias page, got: %q", out.String())
+	}
+
+	s.prepTemplates()
+	must(s.addTemplate("alias.html", "moved to {{ .Permalink }}"))
+	out.Reset()
+	must(tmpl.ExecuteTemplate(out, "alias", node))
+	if out.String() != "moved to http://auth/post/a" {
+		t.Errorf("Expected the alias layout to be used, got: %q", out.String())
+	}
+}
+
+func TestFeedOptions(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\n---\n*a*"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-02\n---\n*b*"), Section: "post"},
+		}},
+		Config: Config{
+			BaseUrl:        "http://auth/",
+			Title:          "Site",
+			Author:         "me@example.com (Me)",
+			Copyright:      "All rights reserved",
+			RSSLimit:       1,
+			RSSFullContent: true,
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderHomePage())
+	must(s.RenderLists())
+
+	for _, name := range []string{".xml", "post.xml"} {
+		feed := string(files[name])
+		for _, expected := range []string{
+			"<managingEditor>me@example.com (Me)</managingEditor>",
+			"<copyright>All rights reserved</copyright>",
+			"<description>&lt;p&gt;&lt;em&gt;a&lt;/em&gt;&lt;/p&gt;\n</description>",
+		} {
+			if !strings.Contains(feed, expected) {
+				t.Errorf("Expected %s to contain %q, got:\n%s", name, expected, feed)
+			}
+		}
+		if strings.Contains(feed, "<title>b</title>") {
+			t.Errorf("Expected %s to be limited to one item, got:\n%s", name, feed)
+		}
+	}
+}
+
+func TestFeedAbsURL(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\n---\n[b](/post/b) ![c](c.png)"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/blog/", Title: "Site", RSSFullContent: true},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderHomePage())
+
+	feed := string(files[".xml"])
+	for _, expected := range []string{
+		`&lt;a href="http://auth/post/b"&gt;b&lt;/a&gt;`,
+		`&lt;img src="http://auth/blog/c.png"`,
+	} {
+		if !strings.Contains(feed, expected) {
+			t.Errorf("Expected the feed to contain %q, got:\n%s", expected, feed)
+		}
+	}
+}
+
+func TestDefaultLayouts(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\ntags: [go]\n---\n*a*"), Section: "post"},
+			{Name: "doc/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-02\n---\n*b*"), Section: "doc"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-01\nlanguagecode: he\n---\n*c*"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", Title: "Site", LanguageCode: "en-us", Indexes: map[string]string{"tag": "tags"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("doc/single.html", "doc {{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+	must(s.RenderLists())
+	must(s.RenderIndexes())
+
+	for name, expected := range map[string][]string{
+		"post/a.html":  {`<html lang="en-us" dir="ltr">`, "<title>a | Site</title>", "<h1>a</h1>", "<em>a</em>"},
+		"post/c.html":  {`<html lang="he" dir="rtl">`},
+		"doc/b.html":   {"doc b"},
+		"post":         {`<a href="http://auth/post/a">a</a>`},
+		"tags/go.html": {`<a href="http://auth/post/a">a</a>`},
+	} {
+		for _, e := range expected {
+			if !strings.Contains(string(files[name]), e) {
+				t.Errorf("Expected %s to contain %q, got: %q", name, e, files[name])
+			}
+		}
+	}
+	if strings.Contains(string(files["doc/b.html"]), "<h1>") {
+		t.Errorf("Expected the layout of the site to win over the default one, got: %q", files["doc/b.html"])
+	}
+}
+
+func TestFeedValidity(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: Q&A <b>\ndate: 2013-01-03T10:00:00+02:00\n---\n\"Quoted\" & \x0bbroken"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", Title: "Site", Timezone: "UTC"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderHomePage())
+
+	var feed struct {
+		LastBuildDate string `xml:"channel>lastBuildDate"`
+		Items         []struct {
+			Title       string `xml:"title"`
+			PubDate     string `xml:"pubDate"`
+			Guid        string `xml:"guid"`
+			Description string `xml:"description"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(files[".xml"], &feed); err != nil {
+		t.Fatalf("Expected the feed to be valid xml, got %s:\n%s", err, files[".xml"])
+	}
+	if len(feed.Items) != 1 {
+		t.Fatalf("Expected one item, got:\n%s", files[".xml"])
+	}
+	item := feed.Items[0]
+	if feed.LastBuildDate != "Thu, 03 Jan 2013 08:00:00 +0000" || item.PubDate != feed.LastBuildDate {
+		t.Errorf("Expected RFC 1123 dates, got %q and %q", feed.LastBuildDate, item.PubDate)
+	}
+	if item.Title != "Q&A <b>" {
+		t.Errorf("Expected the title escaped, got %q", item.Title)
+	}
+	if item.Guid != "http://auth/post/a" {
+		t.Errorf("Expected an absolute guid, got %q", item.Guid)
+	}
+	if expected := "&ldquo;Quoted&rdquo; &amp; broken"; item.Description != expected {
+		t.Errorf("Expected the description %q, got %q", expected, item.Description)
+	}
+}
+
+func TestFeedFuncs(t *testing.T) {
+	c := &Config{Timezone: "America/New_York"}
+	date := time.Date(2013, 7, 1, 12, 0, 0, 0, time.UTC)
+	if got, _ := c.rfc1123(date); got != "Mon, 01 Jul 2013 08:00:00 -0400" {
+		t.Errorf("rfc1123 got %q", got)
+	}
+	if got, _ := c.rfc3339("2013-07-01T12:00:00Z"); got != "2013-07-01T08:00:00-04:00" {
+		t.Errorf("rfc3339 got %q", got)
+	}
+	if got, _ := c.rfc3339("2013-07-01"); got != "2013-07-01T00:00:00-04:00" {
+		t.Errorf("rfc3339 of a date without a zone got %q", got)
+	}
+	if got := xmlEscape(template.HTML("<p>a&b\x00</p>")); got != "&lt;p&gt;a&amp;b&lt;/p&gt;" {
+		t.Errorf("xmlEscape got %q", got)
+	}
+	for base, expected := range map[string]bool{"http://auth/": true, "/": false, "": false, "auth.com": false} {
+		if got := (&Config{BaseUrl: base}).absoluteBaseUrl(); got != expected {
+			t.Errorf("absoluteBaseUrl of %q expected %t, got %t", base, expected, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// rfc1123 is the rfc1123 template func, date in the Timezone of the site
+// the way RSS wants it, e.g. {{ .Date | rfc1123 }}.
+func (c *Config) rfc1123(date interface{}) (string, error) {
+	return c.feedDate(time.RFC1123Z, date)
+}
+
+// rfc3339 is the rfc3339 template func, date in the Timezone of the site
+// the way Atom wants it, e.g. {{ .Date | rfc3339 }}.
+func (c *Config) rfc3339(date interface{}) (string, error) {
+	return c.feedDate(time.RFC3339, date)
+}
+
+func (c *Config) feedDate(layout string, date interface{}) (string, error) {
+	t, err := toDate(date, c.location())
+	if err != nil {
+		return "", err
+	}
+	return t.In(c.location()).Format(layout), nil
+}
+
+var xmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// xmlEscape is the xmlEscape template func, v as the text of an XML element,
+// e.g. <description>{{ .Summary | xmlEscape }}</description>. Unlike the
+// escaping of html/template it also escapes HTML, and it drops the
+// characters XML doesn't allow.
+func xmlEscape(v interface{}) template.HTML {
+	s := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r <= 0xd7ff ||
+			r >= 0xe000 && r <= 0xfffd || r >= 0x10000 && r <= 0x10ffff {
+			return r
+		}
+		return -1
+	}, fmt.Sprint(v))
+	return template.HTML(xmlReplacer.Replace(s))
+}
+
+// absoluteBaseUrl is whether BaseUrl makes absolute permalinks, as feed
+// readers need.
+func (c *Config) absoluteBaseUrl() bool {
+	u, err := url.Parse(c.BaseUrl)
+	return err == nil && u.IsAbs() && u.Host != ""
+}
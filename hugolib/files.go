@@ -0,0 +1,139 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sitePath is the absolute path of name, relative to the site, refusing
+// names which lead outside of it, e.g. "../secret" or a symlink to /etc.
+func (s *Site) sitePath(name string) (string, error) {
+	root := filepath.Clean(s.Config.GetPath())
+	abs := filepath.Join(root, filepath.FromSlash(name))
+	if !within(root, abs) {
+		return "", fmt.Errorf("%s is outside of the site", name)
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return "", err
+		}
+		if !within(realRoot, real) {
+			return "", fmt.Errorf("%s is outside of the site", name)
+		}
+	}
+	return abs, nil
+}
+
+func within(root, name string) bool {
+	rel, err := filepath.Rel(root, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readFile is the readFile template func, the content of a file of the
+// site, e.g. {{ readFile "static/logo.svg" | safeHtml }}.
+func (s *Site) readFile(name string) (string, error) {
+	abs, err := s.sitePath(name)
+	if err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// readDir is the readDir template func, the files of a directory of the
+// site sorted by name, e.g. {{ range readDir "static/images" }}{{ .Name }}.
+func (s *Site) readDir(name string) ([]os.FileInfo, error) {
+	abs, err := s.sitePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadDir(abs)
+}
+
+// CheckPublishDir makes sure the publish dir, followed through symlinks, is
+// somewhere the site may be written to and cleaned: neither the root of the
+// filesystem nor a directory holding the site, nor a directory holding or
+// inside its content, static files, layouts or themes, and not a symlink
+// from inside the site to somewhere outside of it.
+func (c *Config) CheckPublishDir() error {
+	publish := filepath.Clean(filepath.FromSlash(c.GetAbsPath(c.PublishDir)))
+	root := filepath.Clean(c.GetPath())
+	realPublish, err := evalExisting(publish)
+	if err != nil {
+		return err
+	}
+	realRoot, err := evalExisting(root)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case realPublish == filepath.Dir(realPublish):
+		return fmt.Errorf("Refusing to publish to %s: it is the root of the filesystem", publish)
+	case within(realPublish, realRoot):
+		return fmt.Errorf("Refusing to publish to %s: it holds the site at %s", publish, root)
+	case within(root, publish) && !within(realRoot, realPublish):
+		return fmt.Errorf("Refusing to publish to %s: it is a symlink to %s, outside of the site", publish, realPublish)
+	}
+
+	for _, source := range []struct{ what, dir string }{
+		{"content", c.ContentDir},
+		{"static files", c.StaticDir},
+		{"layouts", c.LayoutDir},
+		{"themes", c.ThemesDir},
+	} {
+		if source.dir == "" {
+			continue
+		}
+		dir := filepath.Clean(filepath.FromSlash(c.GetAbsPath(source.dir)))
+		realDir, err := evalExisting(dir)
+		if err != nil {
+			return err
+		}
+		if within(realPublish, realDir) || within(realDir, realPublish) {
+			return fmt.Errorf("Refusing to publish to %s: it overlaps the %s at %s", publish, source.what, dir)
+		}
+	}
+	return nil
+}
+
+// evalExisting is name with the symlinks of the part of it that exists
+// resolved.
+func evalExisting(name string) (string, error) {
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(name)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(name)
+		if parent == name {
+			return filepath.Join(append([]string{name}, rest...)...), nil
+		}
+		rest = append([]string{filepath.Base(name)}, rest...)
+		name = parent
+	}
+}
@@ -0,0 +1,99 @@
+package hugolib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFileAndDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	site := filepath.Join(dir, "site")
+	for name, content := range map[string]string{
+		"site/static/images/b.png": "b",
+		"site/static/images/a.svg": "<svg/>",
+		"secret.txt":               "secret",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		must(os.MkdirAll(filepath.Dir(name), 0755))
+		must(ioutil.WriteFile(name, []byte(content), 0644))
+	}
+	linked := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(site, "link.txt")) == nil
+
+	s := &Site{Config: Config{Path: site}}
+	s.prepTemplates()
+	must(s.addTemplate("svg.html", `{{ readFile "static/images/a.svg" | safeHtml }}`))
+	must(s.addTemplate("dir.html", `{{ range readDir "static/images" }}{{ .Name }},{{ end }}`))
+	must(s.addTemplate("read.html", `{{ readFile . }}`))
+
+	for name, expected := range map[string]string{"svg.html": "<svg/>", "dir.html": "a.svg,b.png,"} {
+		out := new(bytes.Buffer)
+		must(s.Tmpl.ExecuteTemplate(out, name, nil))
+		if out.String() != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, out.String())
+		}
+	}
+
+	outside := []string{"../secret.txt", "static/../../secret.txt", filepath.Join(dir, "secret.txt")}
+	if linked {
+		outside = append(outside, "link.txt")
+	}
+	for _, name := range outside {
+		out := new(bytes.Buffer)
+		if err := s.Tmpl.ExecuteTemplate(out, "read.html", name); err == nil && out.String() == "secret" {
+			t.Errorf("Expected %s to be refused, got: %q", name, out.String())
+		}
+	}
+	if _, err := s.readDir(".."); err == nil {
+		t.Errorf("Expected the parent of the site to be refused")
+	}
+}
+
+func TestCheckPublishDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	site := filepath.Join(dir, "site")
+	must(os.MkdirAll(filepath.Join(site, "content"), 0755))
+	must(os.MkdirAll(filepath.Join(dir, "elsewhere"), 0755))
+	linked := os.Symlink(filepath.Join(dir, "elsewhere"), filepath.Join(site, "linked")) == nil &&
+		os.Symlink(filepath.Join(site, "content"), filepath.Join(site, "tocontent")) == nil
+
+	for publishDir, ok := range map[string]bool{
+		"public":                              true,
+		"public/nested/dir":                   true,
+		filepath.Join(dir, "www"):             true,
+		".":                                   false,
+		"..":                                  false,
+		"content":                             false,
+		"content/post":                        false,
+		"static":                              false,
+		"static/public":                       false,
+		"layouts":                             false,
+		"themes":                              false,
+		"themes/mine/public":                  false,
+		string(filepath.Separator):            false,
+		filepath.Join(site, "..", "..", ".."): false,
+	} {
+		c := &Config{Path: site, ContentDir: "content", StaticDir: "static", LayoutDir: "layouts", ThemesDir: "themes", PublishDir: publishDir}
+		if err := c.CheckPublishDir(); (err == nil) != ok {
+			t.Errorf("Expected publishing to %s to be allowed: %v, got: %v", publishDir, ok, err)
+		}
+	}
+	if linked {
+		for _, publishDir := range []string{"linked", "linked/public", "tocontent"} {
+			c := &Config{Path: site, ContentDir: "content", PublishDir: publishDir}
+			if err := c.CheckPublishDir(); err == nil {
+				t.Errorf("Expected publishing to the symlink %s to be refused", publishDir)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// dateFormat is the dateFormat template func, date formatted with the Go
+// layout, e.g. {{ dateFormat "Jan 2, 2006" .Params.updated }}.  The date is
+// a time or a string in one of the formats of the front matter, taken to be
+// in the Timezone of the site when it has no zone.
+func (c *Config) dateFormat(layout string, date interface{}) (string, error) {
+	t, err := toDate(date, c.location())
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// toDate is date, a time or a string in one of the formats of the front
+// matter, as a time. Strings without a zone are in loc.
+func toDate(date interface{}, loc *time.Location) (time.Time, error) {
+	switch d := date.(type) {
+	case time.Time:
+		return d, nil
+	case *time.Time:
+		if d != nil {
+			return *d, nil
+		}
+	case string:
+		return parseDateWith(d, dateLayouts, loc)
+	}
+	return time.Time{}, fmt.Errorf("Unable to format %v as a date", date)
+}
+
+// humanize is the humanize template func, name as words starting with a
+// capital, e.g. "My first post" for "my-first-post".
+func humanize(name string) string {
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
+
+// defaultDateFormat is the layout of FormattedDate when the site has no
+// DateFormat.
+const defaultDateFormat = "January 2, 2006"
+
+// FormattedDate is the date of the node in the Timezone of the site, in
+// layout or else the DateFormat of the site, e.g. {{ .FormattedDate }} or
+// {{ .FormattedDate "2006-01-02" }}.
+func (n *Node) FormattedDate(layout ...string) string {
+	format := defaultDateFormat
+	if len(layout) > 0 && layout[0] != "" {
+		format = layout[0]
+	} else if n.Site.Config != nil && n.Site.Config.DateFormat != "" {
+		format = n.Site.Config.DateFormat
+	}
+	return n.Date.In(n.Site.Config.location()).Format(format)
+}
+
+// locations caches the time zones loaded for Timezone.
+var locations = struct {
+	sync.Mutex
+	m map[string]*time.Location
+}{m: make(map[string]*time.Location)}
+
+// location is the time zone of Timezone, UTC when it is empty or unknown.
+func (c *Config) location() *time.Location {
+	if c == nil || c.Timezone == "" {
+		return time.UTC
+	}
+	locations.Lock()
+	defer locations.Unlock()
+	if loc, ok := locations.m[c.Timezone]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unknown timezone %q, using UTC\n", c.Timezone)
+		loc = time.UTC
+	}
+	locations.m[c.Timezone] = loc
+	return loc
+}
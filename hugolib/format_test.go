@@ -0,0 +1,96 @@
+package hugolib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatFuncs(t *testing.T) {
+	s := &Site{Config: Config{Plurals: map[string]string{"person": "people", "documentation": "documentation"}}}
+	s.prepTemplates()
+	must(s.addTemplate("a", `{{ dateFormat "Jan 2, 2006" .Date }}|{{ dateFormat "2006" "2013-07-01" }}|{{ humanize "my-first_post" }}|{{ pluralize "tag" }} {{ pluralize "person" }}|{{ singularize "people" }} {{ singularize "categories" }} {{ singularize "documentation" }}`))
+
+	out := new(bytes.Buffer)
+	must(s.Tmpl.ExecuteTemplate(out, "a", map[string]interface{}{"Date": time.Date(2013, 9, 3, 0, 0, 0, 0, time.UTC)}))
+	expected := "Sep 3, 2013|2013|My first post|tags people|person category documentation"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got: %q", expected, out.String())
+	}
+
+	if _, err := s.Config.dateFormat("2006", "yesterday"); err == nil {
+		t.Errorf("Expected a date that can't be parsed to fail")
+	}
+	if humanize("") != "" {
+		t.Errorf("Expected nothing to humanize to nothing")
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Paris"); err != nil {
+		t.Skip("No time zone database:", err)
+	}
+	s := &Site{Config: Config{Timezone: "Europe/Paris", DateFormat: "2006-01-02 15:04"}}
+	s.initializeSiteInfo()
+	local, err := readFrom(strings.NewReader("---\ntitle: local\ndate: 2013-07-01\n---\n"), "local.md", s.Info)
+	must(err)
+	utc, err := readFrom(strings.NewReader("---\ntitle: utc\ndate: 2013-07-01T01:00:00Z\n---\n"), "utc.md", s.Info)
+	must(err)
+
+	if !local.Date.Before(utc.Date) {
+		t.Errorf("Expected midnight in Paris to come before 1am UTC, got: %s and %s", local.Date, utc.Date)
+	}
+	for _, test := range []struct {
+		page     *Page
+		layout   []string
+		expected string
+	}{
+		{local, nil, "2013-07-01 00:00"},
+		{utc, nil, "2013-07-01 03:00"},
+		{utc, []string{"Jan 2 15:04 MST"}, "Jul 1 03:00 CEST"},
+	} {
+		if got := test.page.FormattedDate(test.layout...); got != test.expected {
+			t.Errorf("Expected %s to be formatted %q, got: %q", test.page.Title, test.expected, got)
+		}
+	}
+
+	if got, _ := s.Config.dateFormat("15:04 MST", "2013-07-01 12:00"); got != "12:00 CEST" {
+		t.Errorf("Expected a date without a zone to be in the time zone of the site, got: %q", got)
+	}
+
+	if got := (&Node{Date: utc.Date}).FormattedDate(); got != "July 1, 2013" {
+		t.Errorf("Expected the default format without a site, got: %q", got)
+	}
+}
+
+func TestFrontMatterDates(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("No time zone database:", err)
+	}
+	for _, test := range []struct {
+		date     interface{}
+		expected time.Time
+	}{
+		{"2013-07-01", time.Date(2013, 6, 30, 22, 0, 0, 0, time.UTC)},
+		{"2013-07-01T10:30:00", time.Date(2013, 7, 1, 8, 30, 0, 0, time.UTC)},
+		{"2013-07-01 10:30", time.Date(2013, 7, 1, 8, 30, 0, 0, time.UTC)},
+		{"2013-07-01T10:30:00Z", time.Date(2013, 7, 1, 10, 30, 0, 0, time.UTC)},
+		{"2013-07-01T10:30:00-05:00", time.Date(2013, 7, 1, 15, 30, 0, 0, time.UTC)},
+		{"2013-07-01 10:30:00 +0900", time.Date(2013, 7, 1, 1, 30, 0, 0, time.UTC)},
+		{time.Date(2013, 7, 1, 10, 30, 0, 0, time.UTC), time.Date(2013, 7, 1, 10, 30, 0, 0, time.UTC)},
+	} {
+		d, err := interfaceToDate(test.date, paris)
+		if err != nil {
+			t.Errorf("Unable to read %v: %s", test.date, err)
+			continue
+		}
+		if !d.Equal(test.expected) {
+			t.Errorf("Expected %v to be %s, got: %s", test.date, test.expected, d.UTC())
+		}
+	}
+	if _, err := interfaceToDate("yesterday", paris); err == nil {
+		t.Errorf("Expected a date that can't be read to fail")
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Gallery is the images of a bundle, in the order of its resources.
+type Gallery []*GalleryImage
+
+// GalleryImage is an image of a bundle with its thumbnails, by the name of
+// their size in Thumbnails of the configuration.
+type GalleryImage struct {
+	*Resource
+	Thumbnails map[string]*Thumbnail
+}
+
+// Thumbnail is a smaller copy of an image, published next to it with the
+// name of its size appended, e.g. photo-small.jpg.
+type Thumbnail struct {
+	Permalink     template.HTML
+	Width, Height int
+	content       []byte
+	target        string
+}
+
+// setupGallery lists the images of p, making their thumbnails. It follows
+// setupResources.
+func (p *Page) setupGallery() {
+	p.Gallery = nil
+	var sizes map[string]string
+	if p.Site.Config != nil {
+		sizes = p.Site.Config.Thumbnails
+	}
+
+	for _, r := range p.Resources {
+		if r.Width == 0 {
+			continue
+		}
+		thumbs, err := r.thumbnails(sizes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: %s: %s\n", p.FileName, err)
+		}
+		p.Gallery = append(p.Gallery, &GalleryImage{Resource: r, Thumbnails: thumbs})
+	}
+}
+
+// thumbnails makes the thumbnails of the image r for sizes, leaving out
+// those with an invalid size. The error is that of the first left out.
+func (r *Resource) thumbnails(sizes map[string]string) (thumbs map[string]*Thumbnail, err error) {
+	thumbs = make(map[string]*Thumbnail)
+	if len(sizes) == 0 {
+		return
+	}
+	src, format, err := image.Decode(bytes.NewReader(r.content))
+	if err != nil {
+		return thumbs, fmt.Errorf("Unable to read the image %s: %s", r.Name, err)
+	}
+
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		width, height, e := parseThumbnailSize(sizes[name])
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		width, height = fitThumbnail(r.Width, r.Height, width, height)
+
+		t := &Thumbnail{Width: width, Height: height, content: r.content}
+		if width != r.Width || height != r.Height {
+			if t.content, e = encodeImage(resize(src, width, height), format); e != nil {
+				if err == nil {
+					err = fmt.Errorf("Unable to make the %s thumbnail of %s: %s", name, r.Name, e)
+				}
+				continue
+			}
+		}
+		ext := path.Ext(r.Name)
+		t.target = strings.TrimSuffix(r.target, ext) + "-" + name + ext
+		t.Permalink = template.HTML(strings.TrimSuffix(string(r.Permalink), ext) + "-" + name + ext)
+		thumbs[name] = t
+	}
+	return
+}
+
+// thumbnailNames are the sizes of thumbs, sorted.
+func thumbnailNames(thumbs map[string]*Thumbnail) []string {
+	names := make([]string, 0, len(thumbs))
+	for name := range thumbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseThumbnailSize reads a size of Thumbnails, "WIDTHxHEIGHT" with either
+// of them left out to only bound the other, e.g. "300x200", "300x" or
+// "x200". A single number is a width. 0 is returned for a missing bound.
+func parseThumbnailSize(size string) (width, height int, err error) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(size)), "x", 2)
+	bounds := []*int{&width, &height}
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if *bounds[i], err = strconv.Atoi(part); err != nil || *bounds[i] <= 0 {
+			return 0, 0, fmt.Errorf("Invalid thumbnail size %q", size)
+		}
+	}
+	if width == 0 && height == 0 {
+		return 0, 0, fmt.Errorf("Invalid thumbnail size %q", size)
+	}
+	return
+}
+
+// fitThumbnail is the size of an image of width by height scaled down to fit
+// within the bounds, keeping its proportions. Images are never enlarged.
+func fitThumbnail(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && float64(maxWidth)/float64(width) < scale {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && float64(maxHeight)/float64(height) < scale {
+		scale = float64(maxHeight) / float64(height)
+	}
+	if scale == 1 {
+		return width, height
+	}
+	w, h := int(float64(width)*scale+0.5), int(float64(height)*scale+0.5)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// resize scales src down to width by height, each pixel the average of
+// those of src it covers.
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 == x0 {
+				x1++
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := rgba.PixOffset(sx, sy)
+					for c := 0; c < 4; c++ {
+						sum[c] += int(rgba.Pix[i+c])
+					}
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			i := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// encodeImage encodes img in format, as named by image.Decode.
+func encodeImage(img image.Image, format string) ([]byte, error) {
+	out := new(bytes.Buffer)
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(out, img, &jpeg.Options{Quality: 85})
+	case "gif":
+		err = gif.Encode(out, img, nil)
+	default:
+		err = png.Encode(out, img)
+	}
+	return out.Bytes(), err
+}
@@ -0,0 +1,93 @@
+package hugolib
+
+import (
+	"bytes"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestParseThumbnailSize(t *testing.T) {
+	for size, expected := range map[string][2]int{"300x200": {300, 200}, "300x": {300, 0}, "x200": {0, 200}, "300": {300, 0}, " 30X20 ": {30, 20}} {
+		if w, h, err := parseThumbnailSize(size); err != nil || w != expected[0] || h != expected[1] {
+			t.Errorf("parseThumbnailSize(%q) expected %v, got %d, %d, %v", size, expected, w, h, err)
+		}
+	}
+	for _, size := range []string{"", "x", "axb", "0x0", "-1x2"} {
+		if _, _, err := parseThumbnailSize(size); err == nil {
+			t.Errorf("parseThumbnailSize(%q) expected an error", size)
+		}
+	}
+}
+
+func TestFitThumbnail(t *testing.T) {
+	for _, test := range []struct{ w, h, maxW, maxH, expectedW, expectedH int }{
+		{800, 600, 400, 0, 400, 300},
+		{800, 600, 400, 100, 133, 100},
+		{800, 600, 0, 300, 400, 300},
+		{800, 600, 1000, 1000, 800, 600},
+		{1000, 1, 10, 0, 10, 1},
+	} {
+		if w, h := fitThumbnail(test.w, test.h, test.maxW, test.maxH); w != test.expectedW || h != test.expectedH {
+			t.Errorf("fitThumbnail(%d, %d, %d, %d) expected %dx%d, got %dx%d", test.w, test.h, test.maxW, test.maxH, test.expectedW, test.expectedH, w, h)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			src.SetGray(x, y, color.Gray{uint8(100 * (x % 2))})
+		}
+	}
+	dst := resize(src, 2, 1)
+	for x := 0; x < 2; x++ {
+		if r, _, _, _ := dst.At(x, 0).RGBA(); r>>8 != 50 {
+			t.Errorf("Expected pixel %d to be the average of those it covers, got %d", x, r>>8)
+		}
+	}
+}
+
+func TestGallery(t *testing.T) {
+	var a, b bytes.Buffer
+	must(png.Encode(&a, image.NewGray(image.Rect(0, 0, 8, 4))))
+	must(png.Encode(&b, image.NewGray(image.Rect(0, 0, 2, 6))))
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/trip/index.md", Content: []byte("---\ntitle: trip\nresources:\n  - src: b.png\n---\n"), Section: "trip"},
+			{Name: "post/trip/a.png", Content: a.Bytes(), Section: "trip"},
+			{Name: "post/trip/b.png", Content: b.Bytes(), Section: "trip"},
+			{Name: "post/trip/notes.txt", Content: []byte("notes"), Section: "trip"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", Thumbnails: map[string]string{"small": "4x4", "big": "100x", "bad": "huge"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ range .Gallery }}{{ .Name }} {{ with .Thumbnails.small }}{{ .Permalink }} {{ .Width }}x{{ .Height }}{{ end }};{{ end }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	if expected := HTML("b.png http://auth/post/trip/b-small.png 1x4;a.png http://auth/post/trip/a-small.png 4x2;"); string(files["post/trip.html"]) != expected {
+		t.Errorf("Expected the gallery %q, got %q", expected, files["post/trip.html"])
+	}
+	for name, size := range map[string][2]int{"post/trip/a-small.png": {4, 2}, "post/trip/b-small.png": {1, 4}, "post/trip/a-big.png": {8, 4}} {
+		config, err := png.DecodeConfig(bytes.NewReader(files[name]))
+		if err != nil || config.Width != size[0] || config.Height != size[1] {
+			t.Errorf("Expected %s to be %dx%d, got %dx%d, %v", name, size[0], size[1], config.Width, config.Height, err)
+		}
+	}
+	if !bytes.Equal(files["post/trip/a-big.png"], a.Bytes()) {
+		t.Errorf("Expected a thumbnail larger than its image to be the image")
+	}
+	if _, ok := files["post/trip/a-bad.png"]; ok {
+		t.Errorf("Expected no thumbnail for an invalid size")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/theplant/blackfriday"
+	"html"
+	"html/template"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// pygmentize is the command run to highlight code, see Config.highlight.
+var pygmentize = "pygmentize"
+
+// defaultPygmentsStyle is the style of highlighted code when the site has no
+// PygmentsStyle.
+const defaultPygmentsStyle = "monokai"
+
+// highlight is code highlighted as lang by pygmentize, with the style of
+// the site inline unless PygmentsUseClasses is set, in which case the css of
+// the style is left to the site (pygmentize -S monokai -f html prints it).
+// An empty lang has pygmentize guess it.  Options are more options of the
+// html formatter of pygmentize, e.g. "linenos=table".
+func (c *Config) highlight(code, lang string, options ...string) (string, error) {
+	style := defaultPygmentsStyle
+	if c.PygmentsStyle != "" {
+		style = c.PygmentsStyle
+	}
+	opts := []string{"style=" + style, fmt.Sprintf("noclasses=%t", !c.PygmentsUseClasses), "encoding=utf8"}
+	opts = append(opts, options...)
+
+	args := []string{"-f", "html", "-O", strings.Join(opts, ",")}
+	if lang == "" {
+		args = append(args, "-g")
+	} else {
+		args = append(args, "-l", lang)
+	}
+	cmd := exec.Command(pygmentize, args...)
+	cmd.Stdin = strings.NewReader(code)
+	out, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = out, stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%s", msg)
+		}
+		return "", fmt.Errorf("Error highlighting %s code: %s", lang, err)
+	}
+	return out.String(), nil
+}
+
+// highlightFunc is the highlight template func, e.g.
+// {{ highlight .Params.snippet "go" "linenos=table" }}.  The code is shown
+// as is, escaped, when it can't be highlighted.
+func (s *Site) highlightFunc(code, lang string, options ...string) template.HTML {
+	highlighted, err := s.Config.highlight(code, lang, options...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
+		return template.HTML("<pre><code>" + html.EscapeString(code) + "</code></pre>")
+	}
+	return template.HTML(highlighted)
+}
+
+// highlightRenderer highlights the fenced code blocks of markdown naming
+// their language, leaving the rest to the renderer it wraps.
+type highlightRenderer struct {
+	blackfriday.Renderer
+	config *Config
+}
+
+func (r *highlightRenderer) BlockCode(out *bytes.Buffer, text []byte, lang string) {
+	if lang == "" {
+		r.Renderer.BlockCode(out, text, lang)
+		return
+	}
+	highlighted, err := r.config.highlight(string(text), lang)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
+		r.Renderer.BlockCode(out, text, lang)
+		return
+	}
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+	out.WriteString(highlighted)
+}
@@ -0,0 +1,68 @@
+package hugolib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakePygmentize has highlight run a script echoing its arguments and the
+// code, and returns a func putting pygmentize back.
+func fakePygmentize(t *testing.T) func() {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake pygmentize is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "hugo-pygments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "pygmentize")
+	must(ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"<div>$*\"\ncat\necho \"</div>\"\n"), 0755))
+	old := pygmentize
+	pygmentize = script
+	return func() {
+		pygmentize = old
+		os.RemoveAll(dir)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	defer fakePygmentize(t)()
+
+	c := &Config{}
+	out, err := c.highlight("x := 1", "go", "linenos=table")
+	must(err)
+	if out != "<div>-f html -O style=monokai,noclasses=true,encoding=utf8,linenos=table -l go\nx := 1</div>\n" {
+		t.Errorf("Expected the code highlighted with the default style, got: %q", out)
+	}
+
+	c = &Config{PygmentsStyle: "friendly", PygmentsUseClasses: true}
+	if out, _ = c.highlight("x", ""); !strings.HasPrefix(out, "<div>-f html -O style=friendly,noclasses=false,encoding=utf8 -g\n") {
+		t.Errorf("Expected the style and classes of the site and a guessed language, got: %q", out)
+	}
+
+	pygmentize = "no-such-pygmentize"
+	s := &Site{}
+	if out := s.highlightFunc("a < b", "go"); out != "<pre><code>a &lt; b</code></pre>" {
+		t.Errorf("Expected the code escaped without pygmentize, got: %q", out)
+	}
+}
+
+func TestHighlightCodeFences(t *testing.T) {
+	defer fakePygmentize(t)()
+
+	content := "---\ntitle: code\n---\nSome code:\n\n```go\nx := 1\n```\n\n```\nplain\n```\n"
+	s := &Site{Config: Config{PygmentsCodeFences: true}}
+	s.initializeSiteInfo()
+	p, err := readFrom(strings.NewReader(content), "code.md", s.Info)
+	must(err)
+	if !strings.Contains(string(p.Content), "<div>-f html -O style=monokai,noclasses=true,encoding=utf8 -l go\nx := 1\n</div>") {
+		t.Errorf("Expected the go block highlighted, got: %q", p.Content)
+	}
+	if !strings.Contains(string(p.Content), "<pre><code>plain\n</code></pre>") {
+		t.Errorf("Expected the block without a language left alone, got: %q", p.Content)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"io"
+)
+
+// OutputHook is called with the target path and the fully rendered content of
+// every file right before it is published.  The returned bytes are what gets
+// written, so a hook can inspect the output (link auditing, search indexing)
+// or rewrite it (analytics injection).  Returning an error aborts the publish.
+type OutputHook func(path string, content []byte) ([]byte, error)
+
+// AddOutputHook registers a hook to run on every published file.  Hooks run
+// in the order they were added.
+func (s *Site) AddOutputHook(hook OutputHook) {
+	s.OutputHooks = append(s.OutputHooks, hook)
+}
+
+func (s *Site) applyOutputHooks(path string, r io.Reader) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	content := buf.Bytes()
+	for _, hook := range s.OutputHooks {
+		var err error
+		if content, err = hook(path, content); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.NewReader(content), nil
+}
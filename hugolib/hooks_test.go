@@ -0,0 +1,55 @@
+package hugolib
+
+import (
+	"errors"
+	"github.com/spf13/hugo/target"
+	"strings"
+	"testing"
+)
+
+func TestOutputHooksRewriteContent(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+	}
+
+	var seen []string
+	s.AddOutputHook(func(path string, content []byte) ([]byte, error) {
+		seen = append(seen, path)
+		return content, nil
+	})
+	s.AddOutputHook(func(path string, content []byte) ([]byte, error) {
+		return append(content, []byte("<!-- analytics -->")...), nil
+	})
+
+	if err := s.WritePublic("foo.html", strings.NewReader("<p>foo</p>")); err != nil {
+		t.Fatalf("Unable to write file: %s", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "foo.html" {
+		t.Errorf("Expected hook to be called with foo.html, got: %v", seen)
+	}
+
+	expected := "<p>foo</p><!-- analytics -->"
+	if string(files["foo.html"]) != expected {
+		t.Errorf("Content expected: %q, got: %q", expected, files["foo.html"])
+	}
+}
+
+func TestOutputHookError(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+	}
+	s.AddOutputHook(func(path string, content []byte) ([]byte, error) {
+		return nil, errors.New("broken link")
+	})
+
+	if err := s.WritePublic("foo.html", strings.NewReader("<p>foo</p>")); err == nil {
+		t.Errorf("Expected the hook error to be returned")
+	}
+
+	if _, ok := files["foo.html"]; ok {
+		t.Errorf("File should not be published when a hook fails")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Translations are the strings of a language, keyed by id. A string is
+// either text or a map of plural forms: "zero", "one" and "other".
+type Translations map[string]interface{}
+
+func (s *Site) absI18nDir() string {
+	return s.Config.GetAbsPath(s.Config.I18nDir)
+}
+
+// loadI18n reads the translation files of the i18n directory, one per
+// language and named after it, e.g. i18n/fr.yaml. They are yaml, json or
+// toml like the data files.
+func (s *Site) loadI18n() error {
+	s.i18n = make(map[string]Translations)
+	if s.Config.I18nDir == "" {
+		return nil
+	}
+	base := s.absI18nDir()
+	if b, _ := dirExists(base); !b {
+		return nil
+	}
+	files, err := ioutil.ReadDir(base)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		ext := filepath.Ext(fi.Name())
+		switch ext {
+		case ".yaml", ".yml", ".json", ".toml":
+		default:
+			continue
+		}
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(base, fi.Name()))
+		if err != nil {
+			return err
+		}
+		v, err := readData(ext, content)
+		if err != nil {
+			return fmt.Errorf("Error reading translations %s: %s", fi.Name(), err)
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Error reading translations %s: not a map of strings", fi.Name())
+		}
+		s.i18n[strings.ToLower(strings.TrimSuffix(fi.Name(), ext))] = Translations(m)
+	}
+	return nil
+}
+
+// language is the language of the translations of the site, its
+// LanguageCode unless Language is set.
+func (c *Config) language() string {
+	if c.Language != "" {
+		return c.Language
+	}
+	return c.LanguageCode
+}
+
+// languages are the languages translations are looked up in: lang, the
+// language of the site, each followed by itself without its region ("pt"
+// for "pt-br"), and the default language.
+func (s *Site) languages(lang string) (langs []string) {
+	seen := make(map[string]bool)
+	add := func(lang string) {
+		if lang = strings.ToLower(lang); lang != "" && !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	for _, lang := range []string{lang, s.Config.language()} {
+		add(lang)
+		if i := strings.Index(lang, "-"); i > 0 {
+			add(lang[:i])
+		}
+	}
+	add(s.Config.DefaultLanguage)
+	return
+}
+
+// translate is the T template func, in the language of the site. With a
+// count it picks the plural form of the string and replaces %d with the
+// count, e.g. {{ T "comments" (len .Params.comments) }}. Strings missing in
+// every language are returned as their id.
+func (s *Site) translate(id string, count ...int) string {
+	return s.translateIn("", id, count...)
+}
+
+// translateIn is translate looking in lang first, see Node.T.
+func (s *Site) translateIn(lang, id string, count ...int) string {
+	languages := s.languages(lang)
+	for _, lang := range languages {
+		v, ok := s.i18n[lang][id]
+		if !ok {
+			continue
+		}
+		if len(count) == 0 {
+			return pluralForm(v, 1)
+		}
+		return strings.Replace(pluralForm(v, count[0]), "%d", strconv.Itoa(count[0]), -1)
+	}
+	if s.Config.Verbose {
+		fmt.Printf("No translation of %q for %s\n", id, strings.Join(languages, ", "))
+	}
+	return id
+}
+
+// T is the T template func in the language of the node, e.g.
+// {{ .T "readMore" }} on a page whose front matter sets its languagecode,
+// falling back to the language of the site.
+func (n *Node) T(id string, count ...int) string {
+	if n.Site.translate == nil {
+		return id
+	}
+	return n.Site.translate(n.languageCode, id, count...)
+}
+
+func pluralForm(v interface{}, n int) string {
+	forms, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	form := "other"
+	switch n {
+	case 0:
+		if _, ok := forms["zero"]; ok {
+			form = "zero"
+		}
+	case 1:
+		form = "one"
+	}
+	if text, ok := forms[form]; ok {
+		return fmt.Sprint(text)
+	}
+	return fmt.Sprint(forms["other"])
+}
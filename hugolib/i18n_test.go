@@ -0,0 +1,82 @@
+package hugolib
+
+import (
+	"bytes"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"en.yaml": "readMore: Read more\nhome: Home\ncomments:\n  zero: No comments\n  one: One comment\n  other: \"%d comments\"\n",
+		"fr.toml": "readMore = \"Lire la suite\"\n[comments]\none = \"%d commentaire\"\nother = \"%d commentaires\"\n",
+	} {
+		must(os.MkdirAll(filepath.Join(dir, "i18n"), 0755))
+		must(ioutil.WriteFile(filepath.Join(dir, "i18n", name), []byte(content), 0644))
+	}
+
+	for _, test := range []struct {
+		lang, expected string
+	}{
+		{"fr-CA", "Lire la suite, Home, 0 commentaires, 1 commentaire, 3 commentaires, missing"},
+		{"en", "Read more, Home, No comments, One comment, 3 comments, missing"},
+		{"", "Read more, Home, No comments, One comment, 3 comments, missing"},
+	} {
+		s := &Site{Config: Config{Path: dir, I18nDir: "i18n", Language: test.lang, DefaultLanguage: "en"}}
+		s.initializeSiteInfo()
+		must(s.loadI18n())
+		must(s.prepTemplates())
+		must(s.addTemplate("t.html", `{{ T "readMore" }}, {{ T "home" }}, {{ T "comments" 0 }}, {{ T "comments" 1 }}, {{ T "comments" 3 }}, {{ T "missing" }}`))
+		out := new(bytes.Buffer)
+		must(s.Tmpl.ExecuteTemplate(out, "t.html", s.NewNode()))
+		if out.String() != test.expected {
+			t.Errorf("Expected %q in %q, got: %q", test.expected, test.lang, out.String())
+		}
+	}
+}
+
+func TestTranslatePageLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	must(os.MkdirAll(filepath.Join(dir, "i18n"), 0755))
+	must(ioutil.WriteFile(filepath.Join(dir, "i18n", "en.yaml"), []byte("readMore: Read more\nhome: Home\n"), 0644))
+	must(ioutil.WriteFile(filepath.Join(dir, "i18n", "fr.yaml"), []byte("readMore: Lire la suite\n"), 0644))
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\nlanguagecode: fr-CA\n---\n"), Section: "post"},
+		}},
+		Config: Config{Path: dir, I18nDir: "i18n", LanguageCode: "en-us"},
+	}
+	s.initializeSiteInfo()
+	must(s.loadI18n())
+	must(s.prepTemplates())
+	must(s.addTemplate("_default/single.html", `{{ .T "readMore" }}, {{ .T "home" }}, {{ T "readMore" }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	for file, expected := range map[string]string{
+		"post/a.html": "Read more, Home, Read more",
+		"post/b.html": "Lire la suite, Home, Read more",
+	} {
+		if string(files[file]) != HTML(expected) {
+			t.Errorf("Expected %s to be %q, got: %q", file, HTML(expected), files[file])
+		}
+	}
+}
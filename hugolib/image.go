@@ -0,0 +1,212 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path"
+	"strings"
+	"time"
+)
+
+// imageExtensions are the resources whose dimensions are read.
+var imageExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+
+// Exif is the metadata a camera stores in a jpeg.
+type Exif struct {
+	// Date is when the picture was taken, zero when unknown.
+	Date time.Time
+	// Lat and Long are in degrees, south and west being negative. They are
+	// only set when HasGPS.
+	Lat, Long float64
+	HasGPS    bool
+}
+
+// decodeImage sets the dimensions and the exif metadata of r when it is an
+// image. Naive exif dates are read in loc.
+func (r *Resource) decodeImage(loc *time.Location) error {
+	if !imageExtensions[strings.ToLower(path.Ext(r.Name))] {
+		return nil
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(r.content))
+	if err != nil {
+		return fmt.Errorf("Unable to read the image %s: %s", r.Name, err)
+	}
+	r.Width, r.Height = config.Width, config.Height
+	r.Exif = readExif(r.content, loc)
+	return nil
+}
+
+// Markers of the jpeg segments read by readExif.
+const (
+	jpegSOI  = 0xd8
+	jpegAPP1 = 0xe1
+	jpegSOS  = 0xda
+)
+
+// readExif finds the exif metadata of a jpeg, nil when it has none.
+func readExif(b []byte, loc *time.Location) *Exif {
+	if len(b) < 4 || b[0] != 0xff || b[1] != jpegSOI {
+		return nil
+	}
+	for i := 2; i+4 <= len(b) && b[i] == 0xff; {
+		marker := b[i+1]
+		size := int(binary.BigEndian.Uint16(b[i+2:]))
+		if marker == jpegSOS || size < 2 || i+2+size > len(b) {
+			return nil
+		}
+		segment := b[i+4 : i+2+size]
+		if marker == jpegAPP1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseExif(segment[6:], loc)
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+// Tags of the exif metadata.
+const (
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTime         = 0x0132
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+// tiff reads the tiff structure exif metadata is stored in.
+type tiff struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+type tiffValue struct {
+	typ  uint16
+	data []byte
+}
+
+// tiffTypeSizes are the sizes of the types of tiff values, by type.
+var tiffTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func parseExif(b []byte, loc *time.Location) *Exif {
+	if len(b) < 8 {
+		return nil
+	}
+	t := tiff{b: b}
+	switch {
+	case bytes.HasPrefix(b, []byte("II*\x00")):
+		t.order = binary.LittleEndian
+	case bytes.HasPrefix(b, []byte("MM\x00*")):
+		t.order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd0 := t.ifd(t.order.Uint32(b[4:]))
+	exif := new(Exif)
+	date := t.string(ifd0[tagDateTime])
+	if sub, ok := ifd0[tagExifIFD]; ok {
+		if original := t.string(t.ifd(t.uint32(sub))[tagDateTimeOriginal]); original != "" {
+			date = original
+		}
+	}
+	if d, err := time.ParseInLocation("2006:01:02 15:04:05", date, loc); err == nil {
+		exif.Date = d
+	}
+
+	if sub, ok := ifd0[tagGPSIFD]; ok {
+		gps := t.ifd(t.uint32(sub))
+		lat, latOk := t.degrees(gps[tagGPSLatitude])
+		long, longOk := t.degrees(gps[tagGPSLongitude])
+		if latOk && longOk {
+			if t.string(gps[tagGPSLatitudeRef]) == "S" {
+				lat = -lat
+			}
+			if t.string(gps[tagGPSLongitudeRef]) == "W" {
+				long = -long
+			}
+			exif.Lat, exif.Long, exif.HasGPS = lat, long, true
+		}
+	}
+	return exif
+}
+
+// ifd reads the entries of the image file directory at offset, skipping
+// those that don't fit in the data.
+func (t tiff) ifd(offset uint32) map[uint16]tiffValue {
+	entries := make(map[uint16]tiffValue)
+	if uint64(offset)+2 > uint64(len(t.b)) {
+		return entries
+	}
+	n := uint32(t.order.Uint16(t.b[offset:]))
+	for i := uint32(0); i < n; i++ {
+		e := uint64(offset) + 2 + uint64(i)*12
+		if e+12 > uint64(len(t.b)) {
+			break
+		}
+		typ := t.order.Uint16(t.b[e+2:])
+		size := uint64(tiffTypeSizes[typ]) * uint64(t.order.Uint32(t.b[e+4:]))
+		start := e + 8
+		if size > 4 {
+			start = uint64(t.order.Uint32(t.b[e+8:]))
+		}
+		if size == 0 || start+size > uint64(len(t.b)) {
+			continue
+		}
+		entries[t.order.Uint16(t.b[e:])] = tiffValue{typ: typ, data: t.b[start : start+size]}
+	}
+	return entries
+}
+
+func (t tiff) uint32(v tiffValue) uint32 {
+	switch {
+	case v.typ == 4 && len(v.data) >= 4:
+		return t.order.Uint32(v.data)
+	case v.typ == 3 && len(v.data) >= 2:
+		return uint32(t.order.Uint16(v.data))
+	}
+	return 0
+}
+
+func (t tiff) string(v tiffValue) string {
+	if v.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(v.data), "\x00 ")
+}
+
+// degrees reads the degrees, minutes and seconds rationals of a coordinate.
+func (t tiff) degrees(v tiffValue) (float64, bool) {
+	if v.typ != 5 || len(v.data) < 24 {
+		return 0, false
+	}
+	var deg float64
+	for i, unit := range []float64{1, 60, 3600} {
+		num := t.order.Uint32(v.data[i*8:])
+		den := t.order.Uint32(v.data[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		deg += float64(num) / float64(den) / unit
+	}
+	return deg, true
+}
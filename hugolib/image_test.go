@@ -0,0 +1,139 @@
+package hugolib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+// appendIFD appends an image file directory holding entries to the big
+// endian tiff b, followed by the values that don't fit in the entries.
+func appendIFD(b []byte, entries []tiffEntry) []byte {
+	data := uint32(len(b) + 2 + 12*len(entries) + 4)
+	var values []byte
+	b = append(b, 0, 0)
+	binary.BigEndian.PutUint16(b[len(b)-2:], uint16(len(entries)))
+	for _, e := range entries {
+		entry := make([]byte, 12)
+		binary.BigEndian.PutUint16(entry, e.tag)
+		binary.BigEndian.PutUint16(entry[2:], e.typ)
+		binary.BigEndian.PutUint32(entry[4:], e.count)
+		if len(e.data) <= 4 {
+			copy(entry[8:], e.data)
+		} else {
+			binary.BigEndian.PutUint32(entry[8:], data+uint32(len(values)))
+			values = append(values, e.data...)
+		}
+		b = append(b, entry...)
+	}
+	return append(append(b, 0, 0, 0, 0), values...)
+}
+
+func be32(v ...uint32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, u := range v {
+		binary.BigEndian.PutUint32(b[4*i:], u)
+	}
+	return b
+}
+
+func jpegWithExif(t *testing.T) []byte {
+	tiff := []byte("MM\x00*\x00\x00\x00\x00")
+	exifIFD := len(tiff)
+	tiff = appendIFD(tiff, []tiffEntry{{tagDateTimeOriginal, 2, 20, []byte("2013:07:01 12:30:00\x00")}})
+	gpsIFD := len(tiff)
+	tiff = appendIFD(tiff, []tiffEntry{
+		{tagGPSLatitudeRef, 2, 2, []byte("S\x00")},
+		{tagGPSLatitude, 5, 3, be32(33, 1, 30, 1, 0, 1)},
+		{tagGPSLongitudeRef, 2, 2, []byte("W\x00")},
+		{tagGPSLongitude, 5, 3, be32(70, 1, 45, 1, 36, 1)},
+	})
+	binary.BigEndian.PutUint32(tiff[4:], uint32(len(tiff)))
+	tiff = appendIFD(tiff, []tiffEntry{
+		{tagExifIFD, 4, 1, be32(uint32(exifIFD))},
+		{tagGPSIFD, 4, 1, be32(uint32(gpsIFD))},
+	})
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 3)), nil); err != nil {
+		t.Fatal(err)
+	}
+	app1 := []byte{0xff, jpegAPP1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+6+len(tiff)))
+	app1 = append(append(app1, "Exif\x00\x00"...), tiff...)
+	return append(append(img.Bytes()[:2:2], app1...), img.Bytes()[2:]...)
+}
+
+func TestReadExif(t *testing.T) {
+	exif := readExif(jpegWithExif(t), time.UTC)
+	if exif == nil {
+		t.Fatalf("Expected exif metadata")
+	}
+	if expected := time.Date(2013, 7, 1, 12, 30, 0, 0, time.UTC); !exif.Date.Equal(expected) {
+		t.Errorf("Expected the date %s, got %s", expected, exif.Date)
+	}
+	if !exif.HasGPS || math.Abs(exif.Lat+33.5) > 1e-9 || math.Abs(exif.Long+70.76) > 1e-9 {
+		t.Errorf("Expected the location -33.5, -70.76, got %v, %v (%t)", exif.Lat, exif.Long, exif.HasGPS)
+	}
+
+	for _, b := range [][]byte{nil, []byte("jpg"), {0xff, jpegSOI, 0xff, jpegAPP1, 0xff, 0xff}} {
+		if exif := readExif(b, time.UTC); exif != nil {
+			t.Errorf("Expected no exif metadata in %q, got %v", b, exif)
+		}
+	}
+}
+
+func TestReadTruncatedExif(t *testing.T) {
+	for _, segment := range []string{
+		"Exif\x00\x00II*\x00",
+		"Exif\x00\x00MM\x00*\x00\x00",
+		"Exif\x00\x00II*\x00\xff\xff\xff\xff",
+		"Exif\x00\x00II*\x00\x08\x00\x00\x00\xff\xff",
+	} {
+		b := append([]byte{0xff, jpegSOI, 0xff, jpegAPP1}, byte((len(segment)+2)>>8), byte(len(segment)+2))
+		b = append(b, segment...)
+		if exif := readExif(b, time.UTC); exif != nil && (exif.HasGPS || !exif.Date.IsZero()) {
+			t.Errorf("Expected no exif metadata in %q, got %v", segment, exif)
+		}
+	}
+}
+
+func TestImageResources(t *testing.T) {
+	var pngBytes bytes.Buffer
+	must(png.Encode(&pngBytes, image.NewGray(image.Rect(0, 0, 5, 2))))
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/trip/index.md", Content: []byte("---\ntitle: trip\n---\n"), Section: "trip"},
+			{Name: "post/trip/a.png", Content: pngBytes.Bytes(), Section: "trip"},
+			{Name: "post/trip/b.JPG", Content: jpegWithExif(t), Section: "trip"},
+			{Name: "post/trip/notes.txt", Content: []byte("notes"), Section: "trip"},
+		}},
+		Config: Config{Timezone: "America/Santiago"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ range .Resources }}{{ .Name }} {{ .Width }}x{{ .Height }}{{ with .Exif }} {{ .Date.Format "2006-01-02 15:04 -0700" }}{{ end }};{{ end }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	if expected := HTML("a.png 5x2;b.JPG 4x3 2013-07-01 12:30 -0400;notes.txt 0x0;"); string(files["post/trip.html"]) != expected {
+		t.Errorf("Expected %q, got %q", expected, files["post/trip.html"])
+	}
+}
@@ -15,12 +15,15 @@ package hugolib
 
 import (
 	"github.com/spf13/hugo/template"
+	"path"
 	"sort"
+	"strings"
 )
 
 type IndexCount struct {
 	Name  string
 	Count int
+	Pages Pages
 }
 
 type Index map[string]Pages
@@ -41,21 +44,271 @@ func (i Index) Add(key string, p *Page) {
 	i[key] = append(i[key], p)
 }
 
+// addOnce adds p to key unless it is already listed there.
+func (i Index) addOnce(key string, p *Page) {
+	key = kp(key)
+	for _, existing := range i[key] {
+		if existing == p {
+			return
+		}
+	}
+	i[key] = append(i[key], p)
+}
+
+// indexSingulars returns the singular names of the configured indexes in
+// alphabetical order.
+func (s *Site) indexSingulars() []string {
+	singulars := make([]string, 0, len(s.Config.Indexes))
+	for singular := range s.Config.Indexes {
+		singulars = append(singulars, singular)
+	}
+	sort.Strings(singulars)
+	return singulars
+}
+
+// keys returns the terms of i in alphabetical order.
+func (i Index) keys() []string {
+	keys := make([]string, 0, len(i))
+	for key := range i {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pages returns every page listed under any term of i, newest first.
+func (i Index) pages() Pages {
+	seen := make(map[*Page]bool)
+	var pages Pages
+	for _, key := range i.keys() {
+		for _, p := range i[key] {
+			if !seen[p] {
+				seen[p] = true
+				pages = append(pages, p)
+			}
+		}
+	}
+	pages.Sort()
+	return pages
+}
+
+// addTerm records that p is filed under term in the index plural.
+func (p *Page) addTerm(plural, term string) {
+	if p.terms == nil {
+		p.terms = make(map[string][]string)
+	}
+	term = kp(term)
+	for _, t := range p.terms[plural] {
+		if t == term {
+			return
+		}
+	}
+	p.terms[plural] = append(p.terms[plural], term)
+}
+
+// GetTerms returns the nodes of the terms p is filed under in the index
+// plural, in the order of its front matter. Parents of hierarchical terms
+// are left out.
+func (p *Page) GetTerms(plural string) []*Node {
+	var nodes []*Node
+	for _, term := range p.terms[plural] {
+		if n, ok := p.Site.termNodes[plural][term]; ok {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// parentTerms returns the ancestors of a hierarchical term, closest first,
+// e.g. "programming/go/web" has "programming/go" and "programming".
+func parentTerms(term string) (parents []string) {
+	term = strings.Trim(term, "/")
+	for i := strings.LastIndex(term, "/"); i > 0; i = strings.LastIndex(term, "/") {
+		term = term[:i]
+		parents = append(parents, term)
+	}
+	return
+}
+
+// addTermName remembers how term, and the parents it names, were written
+// the first time, see termName.
+func (s *Site) addTermName(plural, term string) {
+	if s.termNames[plural] == nil {
+		s.termNames[plural] = make(map[string]string)
+	}
+	for _, name := range append([]string{strings.Trim(term, "/")}, parentTerms(term)...) {
+		if _, ok := s.termNames[plural][kp(name)]; !ok {
+			s.termNames[plural][kp(name)] = name
+		}
+	}
+}
+
+// termName is the term of key as the front matter first wrote it, e.g.
+// "macOS" for the key "macos", and key itself when it wasn't written.
+func (s *Site) termName(plural, key string) string {
+	if name, ok := s.termNames[plural][key]; ok {
+		return name
+	}
+	return key
+}
+
+// IndexTerm is a term in the tree formed by hierarchical terms, i.e. terms
+// using "/" to name their parent like "programming/go".
+type IndexTerm struct {
+	Name     string
+	Key      string
+	Pages    Pages
+	Parent   *IndexTerm
+	Children []*IndexTerm
+}
+
+type IndexTree []*IndexTerm
+
+// Tree arranges the terms of the index by their parents.  It returns the
+// terms at the top of the hierarchy; terms only present as the parent of
+// another term are included with the pages rolled up into them, if any.
+func (i Index) Tree() IndexTree {
+	var (
+		keys  = i.keys()
+		terms = make(map[string]*IndexTerm)
+		roots IndexTree
+	)
+
+	var get func(key string) *IndexTerm
+	get = func(key string) *IndexTerm {
+		if t, ok := terms[key]; ok {
+			return t
+		}
+		t := &IndexTerm{Name: path.Base(key), Key: key, Pages: i[key]}
+		terms[key] = t
+		if parents := parentTerms(key); len(parents) > 0 {
+			t.Parent = get(parents[0])
+			t.Parent.Children = append(t.Parent.Children, t)
+		} else {
+			roots = append(roots, t)
+		}
+		return t
+	}
+
+	for _, key := range keys {
+		get(key)
+	}
+	return roots
+}
+
+// Get finds the term with the given key anywhere in the tree.
+func (t IndexTree) Get(key string) *IndexTerm {
+	key = kp(key)
+	for _, term := range t {
+		if term.Key == key {
+			return term
+		}
+		if found := IndexTree(term.Children).Get(key); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
 func (l IndexList) BuildOrderedIndexList() OrderedIndexList {
 	oil := make(OrderedIndexList, len(l))
 	for idx_name, index := range l {
-		i := 0
-		oi := make(OrderedIndex, len(index))
-		for name, pages := range index {
-			oi[i] = IndexCount{name, len(pages)}
-			i++
-		}
-		sort.Sort(oi)
-		oil[idx_name] = oi
+		oil[idx_name] = index.ByCount()
 	}
 	return oil
 }
 
-func (idx OrderedIndex) Len() int           { return len(idx) }
-func (idx OrderedIndex) Less(i, j int) bool { return idx[i].Count > idx[j].Count }
-func (idx OrderedIndex) Swap(i, j int)      { idx[i], idx[j] = idx[j], idx[i] }
+// Alphabetical lists the terms of i by name.
+func (i Index) Alphabetical() OrderedIndex {
+	oi := make(OrderedIndex, 0, len(i))
+	for _, name := range i.keys() {
+		oi = append(oi, IndexCount{name, len(i[name]), i[name]})
+	}
+	return oi
+}
+
+// ByCount lists the terms of i most used first, terms used as often by
+// name.
+func (i Index) ByCount() OrderedIndex {
+	return i.Alphabetical().ByCount()
+}
+
+// Alphabetical returns the terms of idx ordered by name.
+func (idx OrderedIndex) Alphabetical() OrderedIndex {
+	oi := append(OrderedIndex(nil), idx...)
+	sort.Sort(indexByName(oi))
+	return oi
+}
+
+// ByCount returns the terms of idx most used first, terms used as often by
+// name.
+func (idx OrderedIndex) ByCount() OrderedIndex {
+	oi := append(OrderedIndex(nil), idx...)
+	sort.Sort(oi)
+	return oi
+}
+
+// Count is the number of pages of the term name, 0 if it isn't used.
+func (idx OrderedIndex) Count(name string) int {
+	name = kp(name)
+	for _, ic := range idx {
+		if ic.Name == name {
+			return ic.Count
+		}
+	}
+	return 0
+}
+
+// MaxCount is the number of pages of the most used term.
+func (idx OrderedIndex) MaxCount() (max int) {
+	for _, ic := range idx {
+		if ic.Count > max {
+			max = ic.Count
+		}
+	}
+	return
+}
+
+// Top returns the n most used terms, e.g. for a "top categories" list.
+func (idx OrderedIndex) Top(n int) OrderedIndex {
+	oi := idx.ByCount()
+	if n >= 0 && n < len(oi) {
+		oi = oi[:n]
+	}
+	return oi
+}
+
+// Levels spreads the terms over n levels by their count, for the sizes of
+// a tag cloud: the most used terms are at level n, the least used at 1.
+func (idx OrderedIndex) Levels(n int) map[string]int {
+	levels := make(map[string]int, len(idx))
+	min, max := 0, idx.MaxCount()
+	for _, ic := range idx {
+		if min == 0 || ic.Count < min {
+			min = ic.Count
+		}
+	}
+	for _, ic := range idx {
+		levels[ic.Name] = 1
+		if max > min && n > 1 {
+			levels[ic.Name] += (ic.Count - min) * (n - 1) / (max - min)
+		}
+	}
+	return levels
+}
+
+// OrderedIndex is ordered by count, terms of the same count by name.
+func (idx OrderedIndex) Len() int { return len(idx) }
+func (idx OrderedIndex) Less(i, j int) bool {
+	if idx[i].Count != idx[j].Count {
+		return idx[i].Count > idx[j].Count
+	}
+	return idx[i].Name < idx[j].Name
+}
+func (idx OrderedIndex) Swap(i, j int) { idx[i], idx[j] = idx[j], idx[i] }
+
+type indexByName OrderedIndex
+
+func (idx indexByName) Len() int           { return len(idx) }
+func (idx indexByName) Less(i, j int) bool { return idx[i].Name < idx[j].Name }
+func (idx indexByName) Swap(i, j int)      { idx[i], idx[j] = idx[j], idx[i] }
@@ -1,6 +1,10 @@
 package hugolib
 
 import (
+	"github.com/spf13/hugo/source"
+	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 )
@@ -14,3 +18,241 @@ func TestSitePossibleIndexes(t *testing.T) {
 		t.Fatalf("possible indexes do not match [tags categories].  Got: %s", indexes)
 	}
 }
+
+func TestParentTerms(t *testing.T) {
+	if !compareStringSlice(parentTerms("programming/go/web"), []string{"programming/go", "programming"}) {
+		t.Errorf("Unexpected parents: %v", parentTerms("programming/go/web"))
+	}
+	if len(parentTerms("programming")) != 0 {
+		t.Errorf("A top level term has no parents")
+	}
+}
+
+func TestHierarchicalIndexes(t *testing.T) {
+	site := &Site{Config: Config{
+		Indexes:             map[string]string{"category": "categories"},
+		HierarchicalIndexes: true,
+	}}
+	for _, content := range []string{
+		"---\ntitle: a\ncategories: [programming/go/web]\n---\n",
+		"---\ntitle: b\ncategories: [programming/go, programming]\n---\n",
+		"---\ntitle: c\ncategories: [programming/rust, cooking]\n---\n",
+	} {
+		site.Pages = append(site.Pages, pageMust(ReadFrom(strings.NewReader(content), "path/to/page")))
+	}
+	must(site.BuildSiteMeta())
+
+	index := site.Indexes["categories"]
+	for key, count := range map[string]int{
+		"programming":        3,
+		"programming/go":     2,
+		"programming/go/web": 1,
+		"programming/rust":   1,
+		"cooking":            1,
+	} {
+		if index.Count(key) != count {
+			t.Errorf("%s expected %d pages, got: %d", key, count, index.Count(key))
+		}
+	}
+
+	tree := site.Info.IndexTrees["categories"]
+	if len(tree) != 2 || tree[0].Key != "cooking" || tree[1].Key != "programming" {
+		t.Fatalf("Unexpected roots: %v", tree)
+	}
+
+	golang := tree.Get("programming/go")
+	if golang == nil || golang.Name != "go" || golang.Parent != tree[1] {
+		t.Fatalf("Unexpected term for programming/go: %v", golang)
+	}
+	if len(golang.Children) != 1 || golang.Children[0].Key != "programming/go/web" {
+		t.Errorf("Unexpected children of programming/go: %v", golang.Children)
+	}
+	if len(tree[1].Children) != 2 || tree[1].Children[1].Key != "programming/rust" {
+		t.Errorf("Unexpected children of programming: %v", tree[1].Children)
+	}
+}
+
+func TestIndexSources(t *testing.T) {
+	site := &Site{Config: Config{
+		Indexes:      map[string]string{"topic": "topics", "tag": "tags"},
+		IndexSources: map[string]string{"topics": "meta.topics"},
+	}}
+	for _, content := range []string{
+		"---\ntitle: a\ntags: go\nmeta:\n  topics: [web, Databases]\n---\n",
+		"---\ntitle: b\ntags: [go, web]\nMeta:\n  Topics: web\n---\n",
+		"+++\ntitle = \"c\"\n[meta]\ntopics = [\"cooking\"]\n+++\n",
+	} {
+		site.Pages = append(site.Pages, pageMust(ReadFrom(strings.NewReader(content), "path/to/page")))
+	}
+	must(site.BuildSiteMeta())
+
+	for plural, counts := range map[string]map[string]int{
+		"topics": {"web": 2, "databases": 1, "cooking": 1},
+		"tags":   {"go": 2, "web": 1},
+	} {
+		for key, count := range counts {
+			if site.Indexes[plural].Count(key) != count {
+				t.Errorf("%s/%s expected %d pages, got: %d", plural, key, count, site.Indexes[plural].Count(key))
+			}
+		}
+	}
+}
+
+func TestSplitIndexStrings(t *testing.T) {
+	for _, split := range []bool{true, false} {
+		site := &Site{Config: Config{
+			Indexes:           map[string]string{"tag": "tags"},
+			SplitIndexStrings: split,
+		}}
+		site.Pages = append(site.Pages, pageMust(ReadFrom(strings.NewReader("---\ntitle: a\ntags: \"go, web,, tutorial \"\n---\n"), "path/to/page")))
+		must(site.BuildSiteMeta())
+
+		tags := site.Indexes["tags"]
+		if split {
+			if len(tags) != 3 || tags.Count("go") != 1 || tags.Count("web") != 1 || tags.Count("tutorial") != 1 {
+				t.Errorf("Expected three split tags, got: %v", tags)
+			}
+		} else if len(tags) != 1 || tags.Count("go, web,, tutorial ") != 1 {
+			t.Errorf("Expected a single tag when not splitting, got: %v", tags)
+		}
+	}
+}
+
+func TestInvalidIndexWarning(t *testing.T) {
+	site := &Site{Config: Config{Indexes: map[string]string{"tag": "tags"}, Verbose: true}}
+	site.Pages = append(site.Pages,
+		pageMust(ReadFrom(strings.NewReader("---\ntitle: a\ntags:\n  go: true\n---\n"), "path/to/a")),
+		pageMust(ReadFrom(strings.NewReader("---\ntitle: b\n---\n"), "path/to/b")))
+
+	stderr := os.Stderr
+	log, err := ioutil.TempFile("", "hugo-indexes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(log.Name())
+	os.Stderr = log
+	err = site.BuildSiteMeta()
+	os.Stderr = stderr
+	must(err)
+
+	warning, _ := ioutil.ReadFile(log.Name())
+	if string(warning) != "Invalid tags in path/to/a\n" {
+		t.Errorf("Expected a warning about the tags of a only, got: %q", warning)
+	}
+}
+
+func TestGetTerms(t *testing.T) {
+	site := &Site{Config: Config{
+		BaseUrl:             "http://auth/bub/",
+		Indexes:             map[string]string{"tag": "tags"},
+		HierarchicalIndexes: true,
+	}}
+	site.initializeSiteInfo()
+	site.Pages = append(site.Pages, pageMust(ReadFrom(strings.NewReader("---\ntitle: a\ntags: [Web, programming/go, web]\n---\n"), "path/to/page")))
+	must(site.BuildSiteMeta())
+
+	var got []string
+	for _, n := range site.Pages[0].GetTerms("tags") {
+		got = append(got, string(n.Permalink))
+	}
+	expected := []string{"http://auth/bub/tags/web.html", "http://auth/bub/tags/programming/go.html"}
+	if !compareStringSlice(got, expected) {
+		t.Errorf("Expected terms %v, got: %v", expected, got)
+	}
+	if terms := site.Pages[0].GetTerms("categories"); len(terms) != 0 {
+		t.Errorf("Expected no categories, got: %v", terms)
+	}
+}
+
+// orderTarget records the order files are published in.
+type orderTarget struct {
+	published []string
+}
+
+func (t *orderTarget) Publish(label string, r io.Reader) error {
+	t.published = append(t.published, label)
+	_, err := ioutil.ReadAll(r)
+	return err
+}
+
+func (t *orderTarget) Translate(label string) (string, error) {
+	return label, nil
+}
+
+func TestIndexOrdering(t *testing.T) {
+	tgt := new(orderTarget)
+	s := &Site{
+		Target: tgt,
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "a.md", Content: []byte("---\ntitle: a\ntags: [go, web, css]\ncategories: [b]\n---\n")},
+			{Name: "b.md", Content: []byte("---\ntitle: b\ntags: [web, css]\ncategories: [a]\n---\n")},
+		}},
+		Config: Config{Indexes: map[string]string{"tag": "tags", "category": "categories"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("indexes/tag.html", "{{ .Title }}"))
+	must(s.addTemplate("indexes/category.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	for i := 0; i < 3; i++ {
+		tgt.published = nil
+		must(s.RenderIndexes())
+		var pages []string
+		for _, name := range tgt.published {
+			if strings.HasSuffix(name, ".html") {
+				pages = append(pages, name)
+			}
+		}
+		expected := []string{"categories/a.html", "categories/b.html", "tags/css.html", "tags/go.html", "tags/web.html"}
+		if !compareStringSlice(pages, expected) {
+			t.Fatalf("Expected the terms rendered in the order %v, got: %v", expected, pages)
+		}
+	}
+
+	names := func(oi OrderedIndex) (names []string) {
+		for _, ic := range oi {
+			names = append(names, ic.Name)
+		}
+		return
+	}
+	tags := s.Info.Indexes["tags"]
+	if got := names(tags); !compareStringSlice(got, []string{"css", "web", "go"}) {
+		t.Errorf("Expected the tags by count, then name, got: %v", got)
+	}
+	if got := names(tags.Alphabetical()); !compareStringSlice(got, []string{"css", "go", "web"}) {
+		t.Errorf("Expected the tags by name, got: %v", got)
+	}
+	if got := names(s.Indexes["tags"].ByCount()); !compareStringSlice(got, names(tags)) {
+		t.Errorf("Expected the same order from the index, got: %v", got)
+	}
+	if tags[0].Count != 2 || len(tags[0].Pages) != 2 {
+		t.Errorf("Expected css to list both pages, got: %v", tags[0])
+	}
+}
+
+func TestOrderedIndexCounts(t *testing.T) {
+	idx := OrderedIndex{{Name: "go", Count: 5}, {Name: "web", Count: 3}, {Name: "css", Count: 1}, {Name: "vim", Count: 1}}.ByCount()
+
+	if idx.Count("Go") != 5 || idx.Count("html") != 0 || idx.MaxCount() != 5 {
+		t.Errorf("Unexpected counts: %d %d %d", idx.Count("Go"), idx.Count("html"), idx.MaxCount())
+	}
+	var top []string
+	for _, ic := range idx.Top(3) {
+		top = append(top, ic.Name)
+	}
+	if !compareStringSlice(top, []string{"go", "web", "css"}) {
+		t.Errorf("Unexpected top terms: %v", top)
+	}
+	if len(idx.Top(10)) != 4 {
+		t.Errorf("Expected every term when asking for more than there are")
+	}
+	levels := idx.Levels(5)
+	if levels["go"] != 5 || levels["web"] != 3 || levels["css"] != 1 || levels["vim"] != 1 {
+		t.Errorf("Unexpected levels: %v", levels)
+	}
+	if levels := (OrderedIndex{{Name: "go", Count: 2}}).Levels(5); levels["go"] != 1 {
+		t.Errorf("Expected a single term at level 1, got: %v", levels)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+)
+
+// The functions below give the layouts tried, in order, for each kind of
+// page. The first one found in the layout directory is used.  Specific
+// layouts come first, then the ones in _default shared by every type.
+
+// pageLayouts are the layouts for a piece of content:
+//
+//	<type>/<layout>.html, <layout>.html, _default/<layout>.html,
+//	_default/single.html
+//
+// where the layout is "single" unless the page sets one.  A layout set to a
+// template name such as "landing/now.html" is tried first of all.
+func pageLayouts(p *Page) []string {
+	l := p.Layout()
+	if p.layout != "" && !strings.HasSuffix(p.layout, ".html") {
+		l = append(l, "_default/"+p.layout+".html")
+	}
+	return append(l, "_default/single.html")
+}
+
+// sectionLayouts are the layouts for the list of content in a section:
+//
+//	<section>/list.html, indexes/<section>.html, _default/section.html,
+//	_default/list.html, _default/indexes.html
+func sectionLayouts(section string) []string {
+	return []string{
+		section + "/list.html",
+		"indexes/" + section + ".html",
+		"_default/" + KindSection + ".html",
+		"_default/list.html",
+		"_default/indexes.html",
+	}
+}
+
+// taxonomyLayouts are the layouts for the list of content of an index term:
+//
+//	indexes/<singular>.html, _default/taxonomy.html, _default/list.html
+func taxonomyLayouts(singular string) []string {
+	return []string{
+		"indexes/" + singular + ".html",
+		"_default/" + KindTaxonomy + ".html",
+		"_default/list.html",
+	}
+}
+
+// taxonomyTermsLayouts are the layouts for the list of terms of an index:
+//
+//	indexes/<plural>.terms.html, indexes/indexes.html,
+//	_default/taxonomyTerms.html, _default/list.html
+func taxonomyTermsLayouts(plural string) []string {
+	return []string{
+		"indexes/" + plural + ".terms.html",
+		"indexes/indexes.html",
+		"_default/" + KindTaxonomyTerms + ".html",
+		"_default/list.html",
+	}
+}
+
+// homeLayouts are the layouts for the homepage:
+//
+//	index.html, _default/home.html, _default/list.html
+func homeLayouts() []string {
+	return []string{
+		"index.html",
+		"_default/" + KindHome + ".html",
+		"_default/list.html",
+	}
+}
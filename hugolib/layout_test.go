@@ -0,0 +1,56 @@
+package hugolib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLayoutLookupOrder(t *testing.T) {
+	p := pageMust(ReadFrom(strings.NewReader(SIMPLE_PAGE_NOLAYOUT), "content/post/a.md"))
+
+	tests := []struct {
+		name     string
+		got      []string
+		expected []string
+	}{
+		{"page", pageLayouts(p), L("post/single.html", "single.html", "_default/single.html")},
+		{"section", sectionLayouts("post"), L("post/list.html", "indexes/post.html", "_default/section.html", "_default/list.html", "_default/indexes.html")},
+		{"taxonomy", taxonomyLayouts("tag"), L("indexes/tag.html", "_default/taxonomy.html", "_default/list.html")},
+		{"terms", taxonomyTermsLayouts("tags"), L("indexes/tags.terms.html", "indexes/indexes.html", "_default/taxonomyTerms.html", "_default/list.html")},
+		{"home", homeLayouts(), L("index.html", "_default/home.html", "_default/list.html")},
+	}
+	for _, test := range tests {
+		if !listEqual(test.got, test.expected) {
+			t.Errorf("%s layouts expected: %s, got: %s", test.name, test.expected, test.got)
+		}
+	}
+}
+
+func TestSectionListLayoutPreferred(t *testing.T) {
+	s := new(Site)
+	s.prepTemplates()
+	must(s.addTemplate("_default/indexes.html", "indexes"))
+	must(s.addTemplate("_default/list.html", "list"))
+	if layout := s.findFirstLayout(sectionLayouts("post")...); layout != "_default/list.html" {
+		t.Errorf("Expected _default/list.html to be used before _default/indexes.html, got: %s", layout)
+	}
+	must(s.addTemplate("post/list.html", "post list"))
+	if layout := s.findFirstLayout(sectionLayouts("post")...); layout != "post/list.html" {
+		t.Errorf("Expected the section's own list to be used, got: %s", layout)
+	}
+}
+
+func TestPageLayoutOverride(t *testing.T) {
+	for _, test := range []struct {
+		layout   string
+		expected []string
+	}{
+		{"landing", L("post/landing.html", "landing.html", "_default/landing.html", "_default/single.html")},
+		{"special/now.html", L("special/now.html", "post/single.html", "single.html", "_default/single.html")},
+	} {
+		p := pageMust(ReadFrom(strings.NewReader("---\ntitle: t\nlayout: "+test.layout+"\n---\n"), "content/post/a.md"))
+		if !listEqual(pageLayouts(p), test.expected) {
+			t.Errorf("layout %s expected: %s, got: %s", test.layout, test.expected, pageLayouts(p))
+		}
+	}
+}
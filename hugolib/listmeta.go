@@ -0,0 +1,95 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"html/template"
+	"path"
+	"strings"
+)
+
+// listMetaName is the content file describing the list of its directory:
+// content/_index.md the homepage, content/post/_index.md the post section,
+// content/tags/_index.md the tags and content/tags/go/_index.md the go tag.
+const listMetaName = "_index.md"
+
+func isListMeta(p *Page) bool {
+	return path.Base(p.FileName) == listMetaName
+}
+
+// listMetaDefaults keeps the pages describing lists, which often have no
+// content of their own, from being rendered or listed unless their _build
+// says otherwise.
+func listMetaDefaults(p *Page) {
+	if isListMeta(p) && !p.buildSet {
+		p.Build = BuildOptions{}
+	}
+}
+
+// buildListMeta finds the page describing each list, by directory.
+func (s *Site) buildListMeta() map[string]*Page {
+	meta := make(map[string]*Page)
+	for _, pages := range []Pages{s.Pages, s.Unlisted} {
+		for _, p := range pages {
+			if isListMeta(p) {
+				meta[strings.ToLower(cleanDir(p.Dir))] = p
+			}
+		}
+	}
+	return meta
+}
+
+// applyListMeta gives n the title, description and summary of the page
+// describing the list at dir, which templates find in .Data.Meta with its
+// content and params. Without a description of its own, the list has the
+// one configured for it, else the text of its summary.
+func (s *Site) applyListMeta(n *Node, dir string) {
+	dir = strings.ToLower(cleanDir(dir))
+	n.Description = s.configDescription(dir)
+	if p, ok := s.Info.listMeta[dir]; ok {
+		if p.Title != "" {
+			n.Title = p.Title
+		}
+		n.linkTitle = p.linkTitle
+		if p.Description != "" {
+			n.Description = p.Description
+		}
+		if len(p.Keywords) > 0 {
+			n.Keywords = p.Keywords
+		}
+		n.Summary = p.Summary
+		n.Data["Meta"] = p
+	}
+
+	if n.Description == "" {
+		n.Description = strings.TrimSpace(StripHTML(string(n.Summary)))
+	}
+	if n.Summary == "" {
+		n.Summary = template.HTML(template.HTMLEscapeString(n.Description))
+	}
+}
+
+// configDescription is the description Config.Descriptions has for the list
+// at dir, the one of the site for the homepage.
+func (s *Site) configDescription(dir string) string {
+	for d, description := range s.Config.Descriptions {
+		if strings.ToLower(cleanDir(d)) == dir {
+			return description
+		}
+	}
+	if dir == "" {
+		return s.Config.Description
+	}
+	return ""
+}
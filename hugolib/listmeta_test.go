@@ -0,0 +1,89 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"testing"
+)
+
+var listMetaSources = []source.ByteSource{
+	{Name: "post/a.md", Content: []byte("---\ntitle: a\ntags: [go]\n---\n"), Section: "post"},
+	{Name: "post/_index.md", Content: []byte("---\ntitle: Articles\ndescription: Everything I wrote\n---\n"), Section: "post"},
+	{Name: "tags/go/_index.md", Content: []byte("---\ntitle: The Go language\n---\nAll about *Go*.\n"), Section: "tags"},
+	{Name: "tags/_index.md", Content: []byte("---\ntitle: Topics\n_build:\n  render: true\n  list: false\n---\n"), Section: "tags"},
+}
+
+func TestListMeta(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: listMetaSources},
+		Config: Config{BaseUrl: "http://auth/", Indexes: map[string]string{"tag": "tags"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "page {{ .Title }}"))
+	must(s.addTemplate("indexes/tag.html", "{{ .Title }}: {{ with .Data.Meta }}{{ .Content }}{{ end }}"))
+	must(s.addTemplate("post/list.html", "{{ .Title }}, {{ .Description }}: {{ range .Data.Pages }}{{ .Title }}{{ end }}"))
+	must(s.addTemplate("indexes/tags.terms.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+	must(s.RenderLists())
+	must(s.RenderIndexes())
+	must(s.RenderIndexesIndexes())
+
+	if len(s.Pages) != 1 || len(s.Info.Sections) != 1 {
+		t.Errorf("Expected the list pages to stay out of the lists, got: %d pages, %d sections", len(s.Pages), len(s.Info.Sections))
+	}
+	for name, expected := range map[string]string{
+		"post":             "Articles, Everything I wrote: a",
+		"tags/go.html":     "The Go language: <p>All about <em>Go</em>.</p>\n",
+		"tags/index.html":  "Topics",
+		"tags/_index.html": "page Topics",
+	} {
+		if got := string(files[name]); got != HTML(expected) {
+			t.Errorf("Expected %s to be %q, got: %q", name, HTML(expected), got)
+		}
+	}
+	for _, name := range []string{"post/_index.html", "tags/go/_index.html"} {
+		if _, ok := files[name]; ok {
+			t.Errorf("Expected no output for %s, got: %v", name, renderedFiles(files))
+		}
+	}
+}
+
+func TestListDescriptions(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: append(listMetaSources,
+			source.ByteSource{Name: "doc/b.md", Content: []byte("---\ntitle: b\n---\n"), Section: "doc"},
+		)},
+		Config: Config{
+			BaseUrl:      "http://auth/",
+			Indexes:      map[string]string{"tag": "tags"},
+			Description:  "A site about Go",
+			Descriptions: map[string]string{"Doc": "The manual", "post": "Overridden by _index.md"},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/list.html", "{{ .Description }}|{{ .Summary }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderHomePage())
+	must(s.RenderLists())
+	must(s.RenderIndexes())
+
+	for name, expected := range map[string]string{
+		"/":            "A site about Go|A site about Go",
+		"post":         "Everything I wrote|Everything I wrote",
+		"doc":          "The manual|The manual",
+		"tags/go.html": "All about Go.|All about Go.",
+	} {
+		if got := string(files[name]); got != HTML(expected) {
+			t.Errorf("Expected %s to be %q, got: %q", name, HTML(expected), got)
+		}
+	}
+}
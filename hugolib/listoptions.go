@@ -0,0 +1,133 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListOptions set how the list of a section and its feed are made, in the
+// ContentType named after the section, in Config.SectionOptions or in the
+// front matter of the section's _index.md, each overriding the options the
+// one before sets.
+type ListOptions struct {
+	Paginate   int    // pages per list page, Config.Paginate when 0
+	NoPaginate bool   // the whole list on one page, whatever Paginate says
+	RSSLimit   int    // items in the feed, Config.RSSLimit when 0
+	Sort       string // "date", the default, "weight", "title" or "linktitle"
+	Order      string // "asc" or "desc", newest first for dates and ascending otherwise by default
+}
+
+// sectionOptions are the list options of section, with the defaults of the
+// site filled in.
+func (s *Site) sectionOptions(section string) (ListOptions, error) {
+	o := s.contentType(section).List
+	for name, options := range s.Config.SectionOptions {
+		if strings.ToLower(name) == strings.ToLower(section) {
+			o = o.merge(options)
+		}
+	}
+	if p, ok := s.Info.listMeta[strings.ToLower(cleanDir(section))]; ok {
+		for k, v := range p.FrontMatter {
+			switch strings.ToLower(k) {
+			case "paginate":
+				o.Paginate, o.NoPaginate = interfaceToInt(v), false
+			case "nopaginate":
+				o.NoPaginate = interfaceToBool(v)
+			case "rsslimit":
+				o.RSSLimit = interfaceToInt(v)
+			case "sort":
+				o.Sort = interfaceToString(v)
+			case "order":
+				o.Order = interfaceToString(v)
+			}
+		}
+	}
+
+	if o.NoPaginate {
+		o.Paginate = 0
+	} else if o.Paginate == 0 {
+		o.Paginate = s.Config.Paginate
+	}
+	if o.RSSLimit == 0 {
+		o.RSSLimit = s.Config.RSSLimit
+	}
+	o.Sort, o.Order = strings.ToLower(o.Sort), strings.ToLower(o.Order)
+	switch o.Sort {
+	case "", "date", "weight", "title", "linktitle":
+	default:
+		return o, fmt.Errorf("Unknown sort %q for section %s, expecting \"date\", \"weight\", \"title\" or \"linktitle\"", o.Sort, section)
+	}
+	switch o.Order {
+	case "", "asc", "desc":
+	default:
+		return o, fmt.Errorf("Unknown order %q for section %s, expecting \"asc\" or \"desc\"", o.Order, section)
+	}
+	return o, nil
+}
+
+// merge is o with the options other sets.
+func (o ListOptions) merge(other ListOptions) ListOptions {
+	if other.Paginate != 0 {
+		o.Paginate, o.NoPaginate = other.Paginate, false
+	}
+	if other.NoPaginate {
+		o.NoPaginate = true
+	}
+	if other.RSSLimit != 0 {
+		o.RSSLimit = other.RSSLimit
+	}
+	if other.Sort != "" {
+		o.Sort = other.Sort
+	}
+	if other.Order != "" {
+		o.Order = other.Order
+	}
+	return o
+}
+
+// sortPages returns pages, sorted by date already, in the order of o.
+func (o ListOptions) sortPages(pages Pages) Pages {
+	sorted := make(Pages, len(pages))
+	copy(sorted, pages)
+	desc := false
+	switch o.Sort {
+	case "weight":
+		sorted = sorted.ByWeight()
+	case "title", "linktitle":
+		sort.Stable(pagesByTitle{sorted, o.Sort == "linktitle"})
+	default:
+		desc = true
+	}
+	if o.Order != "" && (o.Order == "desc") != desc {
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return sorted
+}
+
+type pagesByTitle struct {
+	Pages
+	link bool
+}
+
+func (p pagesByTitle) Less(i, j int) bool {
+	if p.link {
+		return strings.ToLower(p.Pages[i].LinkTitle()) < strings.ToLower(p.Pages[j].LinkTitle())
+	}
+	return strings.ToLower(p.Pages[i].Title) < strings.ToLower(p.Pages[j].Title)
+}
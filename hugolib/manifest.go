@@ -0,0 +1,162 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Manifest records what a build published, so the next build can tell
+// which pages moved, and where the pages that moved before are now.
+type Manifest struct {
+	Pages map[string]string // content file, permalink
+	Moved map[string]string // old permalink, permalink of the page now
+}
+
+// Moved is a page whose permalink changed since the last build.
+type Moved struct {
+	Source, From, To string
+}
+
+func (s *Site) manifest() (*Manifest, error) {
+	m := &Manifest{Pages: make(map[string]string), Moved: make(map[string]string)}
+	for _, pages := range []Pages{s.Pages, s.Unlisted} {
+		for _, p := range pages {
+			if !p.Build.Render {
+				continue
+			}
+			plink, err := p.Permalink()
+			if err != nil {
+				return nil, err
+			}
+			m.Pages[p.sourcePath()] = plink
+		}
+	}
+	return m, nil
+}
+
+// readManifest loads the manifest the last build left behind, nil when
+// there is none yet.
+func (s *Site) readManifest() (*Manifest, error) {
+	data, err := ioutil.ReadFile(s.Config.GetAbsPath(s.Config.Manifest))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("Error parsing manifest %s: %s", s.Config.Manifest, err)
+	}
+	return m, nil
+}
+
+func (s *Site) writeManifest(m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.Config.GetAbsPath(s.Config.Manifest), data, 0644)
+}
+
+// moved lists the pages of m whose permalink differs from the one in the
+// last build's manifest, sorted by content file.
+func moved(last, m *Manifest) (moves []Moved) {
+	if last == nil {
+		return
+	}
+	for src, from := range last.Pages {
+		if to, ok := m.Pages[src]; ok && to != from {
+			moves = append(moves, Moved{Source: src, From: from, To: to})
+		}
+	}
+	sort.Sort(movedBySource(moves))
+	return
+}
+
+type movedBySource []Moved
+
+func (m movedBySource) Len() int           { return len(m) }
+func (m movedBySource) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m movedBySource) Less(i, j int) bool { return m[i].Source < m[j].Source }
+
+// RenderMoved compares the permalinks of this build with the manifest of the
+// last one and warns about every page that moved. The moves are kept in the
+// manifest, those of earlier builds following the pages moving again, and
+// with AliasMoved set every build writes an alias from each old url to the
+// new one. An old url is left alone when another page lives there now or
+// when it isn't below BaseUrl anymore. The manifest is then replaced by the
+// one of this build.
+func (s *Site) RenderMoved() error {
+	if s.Config.Manifest == "" {
+		return nil
+	}
+	last, err := s.readManifest()
+	if err != nil {
+		return err
+	}
+	m, err := s.manifest()
+	if err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool)
+	for _, plink := range m.Pages {
+		taken[plink] = true
+	}
+
+	now := make(map[string]string)
+	for _, mv := range moved(last, m) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s moved from %s to %s\n", mv.Source, mv.From, mv.To)
+		now[mv.From] = mv.To
+	}
+	if last != nil {
+		for from, to := range last.Moved {
+			if next, ok := now[to]; ok {
+				to = next
+			}
+			if taken[to] {
+				m.Moved[from] = to
+			}
+		}
+	}
+	for from, to := range now {
+		m.Moved[from] = to
+	}
+
+	if s.Config.AliasMoved {
+		var froms []string
+		for from := range m.Moved {
+			froms = append(froms, from)
+		}
+		sort.Strings(froms)
+		for _, from := range froms {
+			if taken[from] || !strings.HasPrefix(from, s.Config.BaseUrl) {
+				continue
+			}
+			alias := strings.TrimPrefix(from, s.Config.BaseUrl)
+			if err = s.WriteAlias(alias, template.HTML(m.Moved[from])); err != nil {
+				return err
+			}
+		}
+	}
+	return s.writeManifest(m)
+}
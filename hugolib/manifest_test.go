@@ -0,0 +1,102 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func manifestSite(files map[string][]byte, manifest string, fake []source.ByteSource) *Site {
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Alias:  &InMemoryAliasTarget{files: files},
+		Source: &source.InMemorySource{ByteSource: fake},
+		Config: Config{BaseUrl: "http://auth/", Manifest: manifest, AliasMoved: true},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.Render())
+	return s
+}
+
+func TestAliasMovedPages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, "manifest.json")
+
+	files := make(map[string][]byte)
+	manifestSite(files, manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /old/\n---\n"), Section: "post"},
+		{Name: "post/b.md", Content: []byte("---\ntitle: b\nurl: /b/\n---\n"), Section: "post"},
+		{Name: "post/c.md", Content: []byte("---\ntitle: c\nurl: /c/\n---\n"), Section: "post"},
+	})
+	if _, ok := files["old/index.html"]; ok {
+		t.Errorf("Expected no alias on the first build")
+	}
+
+	files = make(map[string][]byte)
+	manifestSite(files, manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /new/\n---\n"), Section: "post"},
+		{Name: "post/b.md", Content: []byte("---\ntitle: b\nurl: /c/\n---\n"), Section: "post"},
+		{Name: "post/c.md", Content: []byte("---\ntitle: c\nurl: /d/\n---\n"), Section: "post"},
+	})
+	if _, ok := files["old/index.html"]; !ok {
+		t.Errorf("Expected an alias from the old url of a, got: %v", renderedFiles(files))
+	}
+	if _, ok := files["b/index.html"]; !ok {
+		t.Errorf("Expected an alias from the old url of b, got: %v", renderedFiles(files))
+	}
+	if string(files["/c/index.html"]) != HTML("b") {
+		t.Errorf("Expected b to keep the old url of c, got: %q", files["/c/index.html"])
+	}
+}
+
+func TestAliasMovedPagesKept(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, "manifest.json")
+
+	manifestSite(make(map[string][]byte), manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /old/\n---\n"), Section: "post"},
+	})
+	manifestSite(make(map[string][]byte), manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /new/\n---\n"), Section: "post"},
+	})
+
+	files := make(map[string][]byte)
+	manifestSite(files, manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /new/\n---\n"), Section: "post"},
+	})
+	if _, ok := files["old/index.html"]; !ok {
+		t.Errorf("Expected the alias from the old url to be written again, got: %v", renderedFiles(files))
+	}
+
+	files = make(map[string][]byte)
+	s := manifestSite(files, manifest, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\nurl: /newer/\n---\n"), Section: "post"},
+	})
+	m, err := s.readManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, from := range []string{"http://auth/old/", "http://auth/new/"} {
+		if m.Moved[from] != "http://auth/newer/" {
+			t.Errorf("Expected %s to lead to the latest url, got: %v", from, m.Moved)
+		}
+	}
+	if _, ok := files["old/index.html"]; !ok {
+		t.Errorf("Expected the alias from the first url to be kept, got: %v", renderedFiles(files))
+	}
+}
@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"strings"
+	"testing"
+)
+
+const PAGE_WITH_FOOTNOTES_AND_DEFINITIONS = `---
+title: extensions
+---
+# A Header {#top}
+
+Some text[^1].
+
+[^1]: The note.
+
+Term
+: Definition
+`
+
+func TestMarkdownExtensionsOff(t *testing.T) {
+	p, err := readFrom(strings.NewReader(PAGE_WITH_FOOTNOTES_AND_DEFINITIONS), "content/post/my-post.md", SiteInfo{Config: &Config{}})
+	if err != nil {
+		t.Fatalf("Unable to read page: %s", err)
+	}
+
+	if strings.Contains(string(p.Content), "footnote") {
+		t.Errorf("Content should not contain footnotes: %s", p.Content)
+	}
+	if strings.Contains(string(p.Content), "<dl>") {
+		t.Errorf("Content should not contain definition lists: %s", p.Content)
+	}
+	if !strings.Contains(string(p.Content), `<h1 id="top">A Header</h1>`) {
+		t.Errorf("Content should keep the header ids of MarkdownCommon: %s", p.Content)
+	}
+}
+
+func TestMarkdownExtensionsOn(t *testing.T) {
+	c := &Config{Footnotes: true, DefinitionLists: true, FootnoteAnchorPrefix: "note-"}
+	p, err := readFrom(strings.NewReader(PAGE_WITH_FOOTNOTES_AND_DEFINITIONS), "content/post/my-post.md", SiteInfo{Config: c})
+	if err != nil {
+		t.Fatalf("Unable to read page: %s", err)
+	}
+
+	for _, expected := range []string{
+		"<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>",
+		`href="#fn:note-content-post-my-post:1"`,
+		`id="fnref:note-content-post-my-post:1"`,
+	} {
+		if !strings.Contains(string(p.Content), expected) {
+			t.Errorf("Content should contain %q: %s", expected, p.Content)
+		}
+	}
+}
+
+func TestFootnoteAnchorsOfSameNamedPages(t *testing.T) {
+	c := &Config{Footnotes: true}
+	var anchors []string
+	for _, name := range []string{"post/trip/index.md", "doc/trip/index.md"} {
+		p, err := readFrom(strings.NewReader(PAGE_WITH_FOOTNOTES_AND_DEFINITIONS), name, SiteInfo{Config: c})
+		if err != nil {
+			t.Fatalf("Unable to read page: %s", err)
+		}
+		anchors = append(anchors, p.footnoteAnchorPrefix())
+	}
+	if anchors[0] == anchors[1] {
+		t.Errorf("Expected different footnote anchors, got %q twice", anchors[0])
+	}
+	if anchors[0] != "post-trip-index:" {
+		t.Errorf("Expected the anchor to follow the source path, got %q", anchors[0])
+	}
+}
@@ -0,0 +1,95 @@
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+// Delimiters of the math understood by MathJax and KaTeX out of the box.
+var defaultMathDelimiters = [][]string{
+	{"$$", "$$"},
+	{`\[`, `\]`},
+	{`\(`, `\)`},
+}
+
+func (page *Page) mathDelimiters() [][]string {
+	c := page.Site.Config
+	if c == nil || !c.Math {
+		return nil
+	}
+	if len(c.MathDelimiters) > 0 {
+		return c.MathDelimiters
+	}
+	return defaultMathDelimiters
+}
+
+func mathPlaceholder(i int) []byte {
+	return []byte(fmt.Sprintf("HUGOMATH%dHTAMOGUH", i))
+}
+
+// protectMath swaps every span of math in content, delimiters included, for
+// a placeholder markdown leaves alone.  Code spans and fenced code blocks are
+// skipped.  The spans are returned in the order of their placeholders.
+func protectMath(content []byte, delims [][]string) ([]byte, []string) {
+	if len(delims) == 0 {
+		return content, nil
+	}
+
+	var (
+		out   = new(bytes.Buffer)
+		spans []string
+	)
+
+	for i := 0; i < len(content); {
+		if content[i] == '`' {
+			fence := i
+			for fence < len(content) && content[fence] == '`' {
+				fence++
+			}
+			ticks := content[i:fence]
+			end := bytes.Index(content[fence:], ticks)
+			if end == -1 {
+				out.Write(content[i:])
+				break
+			}
+			end += fence + len(ticks)
+			out.Write(content[i:end])
+			i = end
+			continue
+		}
+
+		matched := false
+		for _, d := range delims {
+			if len(d) != 2 || d[0] == "" || d[1] == "" || !bytes.HasPrefix(content[i:], []byte(d[0])) {
+				continue
+			}
+			end := bytes.Index(content[i+len(d[0]):], []byte(d[1]))
+			if end == -1 {
+				continue
+			}
+			end += i + len(d[0]) + len(d[1])
+			out.Write(mathPlaceholder(len(spans)))
+			spans = append(spans, string(content[i:end]))
+			i = end
+			matched = true
+			break
+		}
+
+		if !matched {
+			out.WriteByte(content[i])
+			i++
+		}
+	}
+
+	return out.Bytes(), spans
+}
+
+// restoreMath puts the spans taken out by protectMath back into the
+// rendered html.
+func restoreMath(rendered []byte, spans []string) []byte {
+	for i, span := range spans {
+		rendered = bytes.Replace(rendered, mathPlaceholder(i), []byte(html.EscapeString(span)), -1)
+	}
+	return rendered
+}
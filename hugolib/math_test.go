@@ -0,0 +1,40 @@
+package hugolib
+
+import (
+	"strings"
+	"testing"
+)
+
+const PAGE_WITH_MATH = `---
+title: math
+---
+Inline \(a_1 * b_1\) and display:
+
+$$x_i = \frac{a*b}{c_i} < 1$$
+
+Code ` + "`$$not math$$`" + ` stays.`
+
+func TestMathPassthrough(t *testing.T) {
+	p, err := readFrom(strings.NewReader(PAGE_WITH_MATH), "content/math.md", SiteInfo{Config: &Config{Math: true}})
+	if err != nil {
+		t.Fatalf("Unable to read page: %s", err)
+	}
+
+	expected := "<p>Inline \\(a_1 * b_1\\) and display:</p>\n\n<p>$$x_i = \\frac{a*b}{c_i} &lt; 1$$</p>\n\n<p>Code <code>$$not math$$</code> stays.</p>\n"
+	if string(p.Content) != expected {
+		t.Errorf("Math content expected:\n%q\ngot:\n%q", expected, p.Content)
+	}
+}
+
+func TestMathDelimitersConfigured(t *testing.T) {
+	c := &Config{Math: true, MathDelimiters: [][]string{{"@@", "@@"}}}
+	p, err := readFrom(strings.NewReader("@@a *b* c@@ and $$a *b* c$$"), "content/math.md", SiteInfo{Config: c})
+	if err != nil {
+		t.Fatalf("Unable to read page: %s", err)
+	}
+
+	expected := "<p>@@a *b* c@@ and $$a <em>b</em> c$$</p>\n"
+	if string(p.Content) != expected {
+		t.Errorf("Math content expected:\n%q\ngot:\n%q", expected, p.Content)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"html/template"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MenuEntry is a single link in one of the site menus.
+type MenuEntry struct {
+	Name       string
+	Url        string
+	Menu       string
+	Permalink  template.HTML
+	Weight     int    // orders the entries, lightest first; unweighted entries come last
+	Identifier string // what the children give as Parent, the name by default
+	Parent     string
+	Children   Menu
+}
+
+// HasChildren is true when entries are nested below me.
+func (me *MenuEntry) HasChildren() bool {
+	return len(me.Children) > 0
+}
+
+func (me *MenuEntry) identifier() string {
+	if me.Identifier != "" {
+		return me.Identifier
+	}
+	return me.Name
+}
+
+type Menu []*MenuEntry
+
+func (m Menu) Len() int      { return len(m) }
+func (m Menu) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m Menu) Less(i, j int) bool {
+	wi, wj := m[i].Weight, m[j].Weight
+	if wi == 0 || wj == 0 {
+		return wj == 0 && wi != 0
+	}
+	return wi < wj
+}
+
+// sort orders m and the children of its entries by weight. Entries of the
+// same weight keep the order they were added in.
+func (m Menu) sort() {
+	sort.Stable(m)
+	for _, me := range m {
+		me.Children.sort()
+	}
+}
+
+// nest moves the entries naming a parent below it. Entries whose parent
+// isn't in the menu stay where they are.
+func (m Menu) nest() (top Menu) {
+	byId := make(map[string]*MenuEntry)
+	for _, me := range m {
+		if _, dup := byId[me.identifier()]; !dup {
+			byId[me.identifier()] = me
+		}
+	}
+	for _, me := range m {
+		if parent, ok := byId[me.Parent]; ok && me.Parent != "" && parent != me {
+			parent.Children = append(parent.Children, me)
+		} else {
+			top = append(top, me)
+		}
+	}
+	return
+}
+
+// Menus holds every site menu by name.
+type Menus map[string]Menu
+
+// buildMenus collects the menu entries from the config, followed by the
+// pages that list the menu in their frontmatter, then orders them by weight
+// and nests them below their parents.
+func (s *Site) buildMenus() Menus {
+	menus := make(Menus)
+
+	for name, entries := range s.Config.Menu {
+		for _, e := range entries {
+			me := e
+			me.Menu, me.Permalink, me.Children = name, s.menuPermalink(e.Url), nil
+			menus[name] = append(menus[name], &me)
+		}
+	}
+
+	for _, p := range s.Pages {
+		for _, e := range p.menus {
+			link, err := p.Permalink()
+			if err != nil {
+				continue
+			}
+			me := e
+			me.Url, me.Permalink = link, template.HTML(link)
+			if me.Name == "" {
+				me.Name = p.LinkTitle()
+			}
+			menus[me.Menu] = append(menus[me.Menu], &me)
+		}
+	}
+
+	for name, menu := range menus {
+		menu = menu.nest()
+		menu.sort()
+		menus[name] = menu
+	}
+	return menus
+}
+
+// pageMenus reads the menu frontmatter: the name of a menu, a list of them,
+// or a map from names to the name, weight, identifier and parent of the
+// entry in that menu.
+func pageMenus(v interface{}) (entries []MenuEntry) {
+	if name, ok := v.(string); ok {
+		return []MenuEntry{{Menu: name}}
+	}
+	m, ok := lowerMap(v)
+	if !ok {
+		for _, name := range interfaceArrayToStringArray(v) {
+			entries = append(entries, MenuEntry{Menu: name})
+		}
+		return
+	}
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		me := MenuEntry{Menu: name}
+		if opts, ok := lowerMap(m[name]); ok {
+			if v, ok := opts["name"]; ok {
+				me.Name = interfaceToString(v)
+			}
+			if v, ok := opts["weight"]; ok {
+				me.Weight = interfaceToInt(v)
+			}
+			if v, ok := opts["identifier"]; ok {
+				me.Identifier = interfaceToString(v)
+			}
+			if v, ok := opts["parent"]; ok {
+				me.Parent = interfaceToString(v)
+			}
+		}
+		entries = append(entries, me)
+	}
+	return
+}
+
+// menuPermalink resolves a menu url against the base url. Urls starting
+// with a slash are taken to be relative to the site, not the host.
+func (s *Site) menuPermalink(link string) template.HTML {
+	if u, err := url.Parse(link); err != nil || u.IsAbs() {
+		return template.HTML(link)
+	}
+	return permalink(s, strings.TrimPrefix(link, "/"))
+}
+
+// menuLink normalizes a permalink for comparison.
+func menuLink(link string) string {
+	return strings.TrimSuffix(link, "index.html")
+}
+
+func isMenuCurrent(current string, menu string, me *MenuEntry) bool {
+	return me != nil && me.Menu == menu && current != "" && menuLink(current) == menuLink(string(me.Permalink))
+}
+
+func hasMenuCurrent(current string, menu string, me *MenuEntry) bool {
+	if me == nil || me.Menu != menu || current == "" {
+		return false
+	}
+	for _, child := range me.Children {
+		if isMenuCurrent(current, menu, child) || hasMenuCurrent(current, menu, child) {
+			return true
+		}
+	}
+	link, current := menuLink(string(me.Permalink)), menuLink(current)
+	return strings.HasSuffix(link, "/") && link != current && strings.HasPrefix(current, link)
+}
+
+// IsMenuCurrent is true when me in the named menu links to this node.
+func (n *Node) IsMenuCurrent(menu string, me *MenuEntry) bool {
+	return isMenuCurrent(string(n.Permalink), menu, me)
+}
+
+// HasMenuCurrent is true when this node lives below the entry me in the
+// named menu, e.g. a section entry while showing one of its pages.
+func (n *Node) HasMenuCurrent(menu string, me *MenuEntry) bool {
+	return hasMenuCurrent(string(n.Permalink), menu, me)
+}
+
+func (p *Page) IsMenuCurrent(menu string, me *MenuEntry) bool {
+	link, _ := p.Permalink()
+	return isMenuCurrent(link, menu, me)
+}
+
+func (p *Page) HasMenuCurrent(menu string, me *MenuEntry) bool {
+	link, _ := p.Permalink()
+	return hasMenuCurrent(link, menu, me)
+}
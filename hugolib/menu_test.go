@@ -0,0 +1,139 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var menuFakeSource = []source.ByteSource{
+	{Name: "blog/first.md", Content: []byte("---\ntitle: First\nmenu: main\n---\n"), Section: "blog"},
+	{Name: "about.md", Content: []byte("---\ntitle: About\nmenu: [main, footer]\nurl: /about/\n---\n"), Section: ""},
+}
+
+func TestMenus(t *testing.T) {
+	s := &Site{
+		Config: Config{
+			BaseUrl: "http://auth/bub/",
+			Menu: map[string][]MenuEntry{
+				"main": {{Name: "Blog", Url: "/blog/"}, {Name: "Hugo", Url: "http://hugo.spf13.com/"}},
+			},
+		},
+		Source: &source.InMemorySource{ByteSource: menuFakeSource},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	main := s.Info.Menus["main"]
+	if len(main) != 4 || len(s.Info.Menus["footer"]) != 1 {
+		t.Fatalf("Unexpected menus: %v", s.Info.Menus)
+	}
+	if main[0].Permalink != "http://auth/bub/blog/" || main[1].Permalink != "http://hugo.spf13.com/" {
+		t.Errorf("Unexpected config entry permalinks: %s %s", main[0].Permalink, main[1].Permalink)
+	}
+
+	var first, about *Page
+	for _, p := range s.Pages {
+		switch p.Title {
+		case "First":
+			first = p
+		case "About":
+			about = p
+		}
+	}
+	blog, firstEntry := main[0], menuEntry(t, main, "First")
+
+	if !first.IsMenuCurrent("main", firstEntry) || first.IsMenuCurrent("footer", firstEntry) {
+		t.Errorf("First should only be current in the main menu")
+	}
+	if first.IsMenuCurrent("main", blog) || !first.HasMenuCurrent("main", blog) {
+		t.Errorf("The blog entry should contain, not be, the first post")
+	}
+	if about.HasMenuCurrent("main", blog) || first.HasMenuCurrent("main", main[1]) {
+		t.Errorf("Unrelated entries should not contain the page")
+	}
+	if !about.IsMenuCurrent("footer", s.Info.Menus["footer"][0]) {
+		t.Errorf("About should be current in the footer menu")
+	}
+
+	n := s.NewNode()
+	n.Url = "blog/index.html"
+	n.Permalink = permalink(s, n.Url)
+	if !n.IsMenuCurrent("main", blog) || n.HasMenuCurrent("main", blog) {
+		t.Errorf("The blog list should be the current blog entry")
+	}
+}
+
+func menuEntry(t *testing.T, m Menu, name string) *MenuEntry {
+	for _, me := range m {
+		if me.Name == name {
+			return me
+		}
+	}
+	t.Fatalf("No menu entry named %s", name)
+	return nil
+}
+
+func TestMenuWeightsAndNesting(t *testing.T) {
+	s := &Site{
+		Config: Config{
+			BaseUrl: "http://auth/",
+			Menu: map[string][]MenuEntry{
+				"main": {
+					{Name: "Last", Url: "/last/"},
+					{Name: "Docs", Url: "/docs/", Weight: 20, Identifier: "docs"},
+					{Name: "Home", Url: "/", Weight: 10},
+				},
+			},
+		},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "docs/b.md", Content: []byte("---\ntitle: b\nmenu:\n  main:\n    parent: docs\n---\n"), Section: "docs"},
+			{Name: "docs/a.md", Content: []byte("---\ntitle: a\nmenu:\n  main:\n    parent: docs\n    weight: 1\n    name: Intro\n---\n"), Section: "docs"},
+		}},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	var names []string
+	for _, me := range s.Info.Menus["main"] {
+		names = append(names, me.Name)
+	}
+	if expected := []string{"Home", "Docs", "Last"}; !listEqual(names, expected) {
+		t.Errorf("Expected the top entries %v, got: %v", expected, names)
+	}
+
+	docs := s.Info.Menus["main"][1]
+	if !docs.HasChildren() || len(docs.Children) != 2 || docs.Children[0].Name != "Intro" || docs.Children[1].Name != "b" {
+		t.Fatalf("Expected Intro and b below docs, got: %v", docs.Children)
+	}
+	var a *Page
+	for _, p := range s.Pages {
+		if p.Title == "a" {
+			a = p
+		}
+	}
+	if !a.IsMenuCurrent("main", docs.Children[0]) || !a.HasMenuCurrent("main", docs) {
+		t.Errorf("Expected a to be current in its entry and below docs")
+	}
+}
+
+func TestPageMenusWithoutOptions(t *testing.T) {
+	stderr, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stderr.Name())
+	defer func(f *os.File) { os.Stderr = f }(os.Stderr)
+	os.Stderr = stderr
+
+	entries := pageMenus(map[string]interface{}{"main": map[string]interface{}{"parent": "docs"}})
+	if len(entries) != 1 || entries[0].Parent != "docs" || entries[0].Name != "" || entries[0].Weight != 0 {
+		t.Errorf("Expected a main entry under docs, got %+v", entries)
+	}
+	if warnings, _ := ioutil.ReadFile(stderr.Name()); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for the options left out, got %q", warnings)
+	}
+}
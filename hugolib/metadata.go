@@ -4,30 +4,44 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
-func interfaceToStringToDate(i interface{}) time.Time {
-	s := interfaceToString(i)
+// dateLayouts are those of the dates of the front matter.  The ones without
+// a time zone are read in the Timezone of the site.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"2006-01-02 15:04:05Z07:00",
+	"02 Jan 06 15:04 MST",
+	"2006-01-02",
+	"02 Jan 2006",
+}
 
-	if d, e := parseDateWith(s, []string{
-		time.RFC3339,
-		time.RFC1123Z,
-		time.RFC1123,
-		time.RFC822Z,
-		time.RFC822,
-		time.ANSIC,
-		time.UnixDate,
-		time.RubyDate,
-		"2006-01-02 15:04:05Z07:00",
-		"02 Jan 06 15:04 MST",
-		"2006-01-02",
-		"02 Jan 2006",
-	}); e == nil {
-		return d
+// interfaceToDate is the date i holds: a time, such as the dates toml
+// decodes, or a string in one of the dateLayouts, read in loc unless it has
+// a time zone of its own.
+func interfaceToDate(i interface{}, loc *time.Location) (time.Time, error) {
+	switch d := i.(type) {
+	case time.Time:
+		return d, nil
+	case string:
+		return parseDateWith(strings.TrimSpace(d), dateLayouts, loc)
 	}
-
-	return time.Unix(0, 0)
+	return time.Time{}, fmt.Errorf("Unable to parse date: %v", i)
 }
 
 // TODO remove this and return a proper error.
@@ -35,9 +49,9 @@ func errorf(str string, a ...interface{}) {
 	fmt.Fprintln(os.Stderr, str, a)
 }
 
-func parseDateWith(s string, dates []string) (d time.Time, e error) {
+func parseDateWith(s string, dates []string, loc *time.Location) (d time.Time, e error) {
 	for _, dateType := range dates {
-		if d, e = time.Parse(dateType, s); e == nil {
+		if d, e = time.ParseInLocation(dateType, s, loc); e == nil {
 			return
 		}
 	}
@@ -56,6 +70,21 @@ func interfaceToBool(i interface{}) bool {
 
 }
 
+func interfaceToInt(i interface{}) int {
+	switch n := i.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		errorf("Only Integers are supported for this YAML key")
+	}
+
+	return 0
+}
+
 func interfaceArrayToStringArray(i interface{}) []string {
 	var a []string
 
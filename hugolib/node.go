@@ -15,19 +15,65 @@ package hugolib
 
 import (
 	"html/template"
+	"sort"
+	"strings"
 	"time"
 )
 
+// The kinds of page, see Node.Kind.
+const (
+	KindPage          = "page"
+	KindHome          = "home"
+	KindSection       = "section"
+	KindTaxonomy      = "taxonomy"
+	KindTaxonomyTerms = "taxonomyTerms"
+)
+
 type Node struct {
 	RSSlink template.HTML
 	Site    SiteInfo
+	// Kind is the kind of page, one of the Kind constants.
+	Kind string
 	//	layout      string
 	Data        map[string]interface{}
 	Title       string
 	Description string
-	Keywords    []string
-	Date        time.Time
+	// Summary of the content of the node's _index.md, else its description.
+	Summary  template.HTML
+	Keywords []string
+	Date     time.Time
+	// Alternates are the other outputs of the node, such as its feed.
+	Alternates []*Alternate
+	// Paginator is the part of a paginated list shown by the node.
+	Paginator *Pager
 	UrlPath
+	linkTitle         string
+	languageCode      string
+	languageDirection string
+	scratch           *Scratch // see Scratch
+}
+
+// Alternate is another output of a node, for templates to link with
+// <link rel="alternate">.
+type Alternate struct {
+	Rel       string
+	Type      string
+	Title     string
+	Permalink template.HTML
+}
+
+// LinkTitle is the title for menus and lists, the linktitle of the front
+// matter when the title is too long for them, else the title.
+func (n *Node) LinkTitle() string {
+	if n.linkTitle != "" {
+		return n.linkTitle
+	}
+	return n.Title
+}
+
+// RSSLink is the permalink of the feed of the node.
+func (n *Node) RSSLink() template.HTML {
+	return n.RSSlink
 }
 
 type UrlPath struct {
@@ -36,3 +82,66 @@ type UrlPath struct {
 	Slug      string
 	Section   string
 }
+
+// Languages written right to left, used when no direction is configured.
+var rtlLanguages = []string{"ar", "arc", "dv", "fa", "ha", "he", "khw", "ks", "ku", "ps", "ur", "yi"}
+
+// LanguageCode is the language of the node, e.g. "en-us", falling back to the
+// language of the site.
+func (n *Node) LanguageCode() string {
+	if n.languageCode != "" {
+		return n.languageCode
+	}
+	return n.Site.LanguageCode
+}
+
+// LanguageDirection is the text direction of the node, "ltr" or "rtl".  When
+// neither the node nor the site set one it is guessed from the language.
+func (n *Node) LanguageDirection() string {
+	if n.languageDirection != "" {
+		return n.languageDirection
+	}
+	if n.Site.LanguageDirection != "" {
+		return n.Site.LanguageDirection
+	}
+
+	lang := strings.ToLower(n.LanguageCode())
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+	for _, rtl := range rtlLanguages {
+		if lang == rtl {
+			return "rtl"
+		}
+	}
+	return "ltr"
+}
+
+// Nodes is a list holding both content pages (*Page) and generated nodes
+// (*Node) such as the homepage, section lists and index pages.
+type Nodes []interface{}
+
+func nodeDate(n interface{}) time.Time {
+	switch n := n.(type) {
+	case *Page:
+		return n.Date
+	case *Node:
+		return n.Date
+	}
+	return time.Time{}
+}
+
+func (n Nodes) Len() int { return len(n) }
+func (n Nodes) Less(i, j int) bool {
+	if di, dj := nodeDate(n[i]).Unix(), nodeDate(n[j]).Unix(); di != dj {
+		return di > dj
+	}
+	// Content comes before the nodes listing it.
+	_, pi := n[i].(*Page)
+	_, pj := n[j].(*Page)
+	return pi && !pj
+}
+func (n Nodes) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+
+// Sort orders the nodes newest first, like Pages.
+func (n Nodes) Sort() { sort.Stable(n) }
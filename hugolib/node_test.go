@@ -0,0 +1,74 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"strings"
+	"testing"
+)
+
+func TestNodeLanguage(t *testing.T) {
+	tests := []struct {
+		site              SiteInfo
+		frontmatter       string
+		languageCode      string
+		languageDirection string
+	}{
+		{SiteInfo{}, "", "", "ltr"},
+		{SiteInfo{LanguageCode: "en-us"}, "", "en-us", "ltr"},
+		{SiteInfo{LanguageCode: "ar"}, "", "ar", "rtl"},
+		{SiteInfo{LanguageCode: "en-us"}, "languagecode: he-IL\n", "he-IL", "rtl"},
+		{SiteInfo{LanguageCode: "fa", LanguageDirection: "ltr"}, "", "fa", "ltr"},
+		{SiteInfo{LanguageCode: "en"}, "languagedirection: RTL\n", "en", "rtl"},
+	}
+
+	for _, test := range tests {
+		p := pageMust(ReadFrom(strings.NewReader("---\ntitle: lang\n"+test.frontmatter+"---\ncontent"), "content/lang.md"))
+		p.Site = test.site
+
+		if p.LanguageCode() != test.languageCode {
+			t.Errorf("LanguageCode expected: %q, got: %q", test.languageCode, p.LanguageCode())
+		}
+		if p.LanguageDirection() != test.languageDirection {
+			t.Errorf("LanguageDirection expected: %q, got: %q", test.languageDirection, p.LanguageDirection())
+		}
+	}
+}
+
+func TestSitePagesAndRegularPages(t *testing.T) {
+	s := &Site{
+		Config: Config{BaseUrl: "http://auth/bub/", Indexes: map[string]string{"tag": "tags"}},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "blog/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-01\ntags: [go]\n---\n"), Section: "blog"},
+			{Name: "blog/b.md", Content: []byte("---\ntitle: b\ndate: 2013-02-01\n---\n"), Section: "blog"},
+		}},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	if len(s.Info.RegularPages) != 2 {
+		t.Errorf("Expected 2 regular pages, got: %d", len(s.Info.RegularPages))
+	}
+
+	// two pages, the homepage, the blog section and the go tag
+	var pages, nodes int
+	var urls []string
+	for _, n := range s.Info.Pages {
+		switch n := n.(type) {
+		case *Page:
+			pages++
+		case *Node:
+			nodes++
+			urls = append(urls, n.Url)
+		}
+	}
+	if pages != 2 || nodes != 3 {
+		t.Errorf("Expected 2 pages and 3 nodes, got: %d and %d: %v", pages, nodes, urls)
+	}
+	if p, ok := s.Info.Pages[0].(*Page); !ok || p.Title != "b" {
+		t.Errorf("Site pages should be sorted newest first, got: %v", s.Info.Pages[0])
+	}
+	if len(s.Pages[0].Site.Pages) != 5 {
+		t.Errorf("Pages should see all the site pages")
+	}
+}
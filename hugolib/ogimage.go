@@ -0,0 +1,221 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Social cards are the images made for the pages without an image param
+// when OGImages is on, the title of the page over a plain background.
+const (
+	cardName       = "og.png" // published below the url of the page, like resources
+	cardWidth      = 1200
+	cardHeight     = 630
+	cardMargin     = 80
+	cardMaxPixel   = 16 // the largest a pixel of the font is drawn
+	cardBackground = "#222222"
+	cardColor      = "#ffffff"
+)
+
+// cardStyle is the font and colors of the social cards of a site.
+type cardStyle struct {
+	font       *bitmapFont
+	background color.Color
+	color      color.Color
+}
+
+// initCards loads the style of the social cards when they are on.
+func (s *Site) initCards() error {
+	if !s.Config.OGImages || s.cards != nil {
+		return nil
+	}
+	style := &cardStyle{font: defaultFont()}
+	if s.Config.OGImageFont != "" {
+		file, err := os.Open(s.Config.GetAbsPath(s.Config.OGImageFont))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if style.font, err = parseBDF(file); err != nil {
+			return fmt.Errorf("%s: %s", s.Config.OGImageFont, err)
+		}
+	}
+
+	var err error
+	if style.background, err = parseHexColor(s.Config.OGImageBackground, cardBackground); err != nil {
+		return err
+	}
+	if style.color, err = parseHexColor(s.Config.OGImageColor, cardColor); err != nil {
+		return err
+	}
+	s.cards = style
+	return nil
+}
+
+// parseHexColor reads a css color like "#ff8800" or "#f80", def when it is
+// empty.
+func parseHexColor(hex, def string) (color.Color, error) {
+	if hex == "" {
+		hex = def
+	}
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) != 6 || !strings.HasPrefix(hex, "#") {
+		return nil, fmt.Errorf("Invalid color %q", hex)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// OGImage is the image shown for the node when it is shared. Lists have
+// none.
+func (n *Node) OGImage() string {
+	return ""
+}
+
+// OGImage is the image shown for the page when it is shared: its image
+// param, the permalink of the resource when it names one, else its social
+// card when OGImages is on.
+func (p *Page) OGImage() string {
+	if image, _ := p.GetParam("image").(string); image != "" {
+		if r := p.Resources.Get(image); r != nil {
+			return string(r.Permalink)
+		}
+		return image
+	}
+	if p.hasCard() {
+		_, link := p.resourceBase()
+		return link + cardName
+	}
+	return ""
+}
+
+func (p *Page) hasCard() bool {
+	return p.Site.Config != nil && p.Site.Config.OGImages && p.GetParam("image") == nil && !p.noCard
+}
+
+// checkCards leaves out the cards of the pages whose title has characters
+// the font lacks, which would be drawn as "?", with a warning.
+func (s *Site) checkCards(pages Pages) {
+	if s.cards == nil {
+		return
+	}
+	for _, p := range pages {
+		p.noCard = false
+		if !p.hasCard() {
+			continue
+		}
+		if missing := s.cards.font.missing(p.Title); len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "WARNING: No social card for %s, the font lacks %q\n", p.FileName, string(missing))
+			p.noCard = true
+		}
+	}
+}
+
+// publishCard writes the social card of p, if it has one.
+func (s *Site) publishCard(p *Page) error {
+	if s.cards == nil || !p.hasCard() {
+		return nil
+	}
+	out := new(bytes.Buffer)
+	if err := png.Encode(out, s.cards.render(p.Title)); err != nil {
+		return err
+	}
+	target, _ := p.resourceBase()
+	return s.publishFile(target+"/"+cardName, out)
+}
+
+// render draws title over the background, wrapped and scaled to fill the
+// card within its margins.
+func (c *cardStyle) render(title string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c.background}, image.ZP, draw.Src)
+
+	f := c.font
+	lineHeight := f.height + (f.height+3)/4
+	pixel := cardMaxPixel
+	var lines []string
+	for ; pixel > 1; pixel-- {
+		lines = wrapText(title, f, (cardWidth-2*cardMargin)/pixel)
+		if len(lines)*lineHeight*pixel <= cardHeight-2*cardMargin && f.measure(longest(lines, f)) <= (cardWidth-2*cardMargin)/pixel {
+			break
+		}
+	}
+	if pixel == 1 {
+		lines = wrapText(title, f, cardWidth-2*cardMargin)
+	}
+
+	fg := &image.Uniform{c.color}
+	y := (cardHeight - (len(lines)*lineHeight-(lineHeight-f.height))*pixel) / 2
+	for _, line := range lines {
+		x := cardMargin
+		for _, r := range line {
+			g := f.glyph(r)
+			if g == nil {
+				continue
+			}
+			for gy := range g.rows {
+				for gx := 0; gx < g.width; gx++ {
+					if g.set(gx, gy) {
+						dot := image.Rect(x+gx*pixel, y+gy*pixel, x+(gx+1)*pixel, y+(gy+1)*pixel)
+						draw.Draw(img, dot, fg, image.ZP, draw.Src)
+					}
+				}
+			}
+			x += g.advance * pixel
+		}
+		y += lineHeight * pixel
+	}
+	return img
+}
+
+// wrapText breaks s into lines at most width pixels of f wide, as far as
+// its words allow.
+func wrapText(s string, f *bitmapFont, width int) (lines []string) {
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && f.measure(line+" "+word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return
+}
+
+func longest(lines []string, f *bitmapFont) (l string) {
+	for _, line := range lines {
+		if f.measure(line) > f.measure(l) {
+			l = line
+		}
+	}
+	return
+}
@@ -0,0 +1,201 @@
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testBDF = `STARTFONT 2.1
+FONT -test-fixed-medium-r-normal--4-40-75-75-c-30-iso10646-1
+FONTBOUNDINGBOX 3 4 0 -1
+CHARS 2
+STARTCHAR A
+ENCODING 65
+DWIDTH 4 0
+BBX 3 3 0 0
+BITMAP
+40
+A0
+E0
+ENDCHAR
+STARTCHAR j
+ENCODING 106
+DWIDTH 2 0
+BBX 1 3 1 -1
+BITMAP
+80
+80
+80
+ENDCHAR
+ENDFONT
+`
+
+func TestParseBDF(t *testing.T) {
+	f, err := parseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.height != 4 || len(f.glyphs) != 2 {
+		t.Fatalf("Expected 2 glyphs 4 pixels high, got %d glyphs %d high", len(f.glyphs), f.height)
+	}
+	for r, expected := range map[rune][]string{
+		'A': {".#.", "#.#", "###", "..."},
+		'j': {"..", ".#", ".#", ".#"},
+	} {
+		g := f.glyphs[r]
+		for y, row := range expected {
+			for x, c := range row {
+				if g.set(x, y) != (c == '#') {
+					t.Errorf("Expected %q to be %v, got pixel %d,%d wrong", r, expected, x, y)
+				}
+			}
+		}
+	}
+	if w := f.measure("Aj?"); w != 6 {
+		t.Errorf("Expected Aj to be 6 pixels wide, the missing ? taking none, got %d", w)
+	}
+
+	if _, err := parseBDF(strings.NewReader("STARTFONT 2.1\nENDFONT\n")); err == nil {
+		t.Errorf("Expected an error for a font without glyphs")
+	}
+	if _, err := parseBDF(strings.NewReader("STARTFONT 2.1\nFONTBOUNDINGBOX 3 4 0 -1\nDWIDTH 4 0\nENDFONT\n")); err == nil || err.Error() != "Line 3: unexpected DWIDTH" {
+		t.Errorf("Expected an error for a DWIDTH outside a glyph, got %v", err)
+	}
+}
+
+func TestDefaultFont(t *testing.T) {
+	f := defaultFont()
+	if f.height != 7 {
+		t.Fatalf("Expected the default font to be 7 pixels high, got %d", f.height)
+	}
+	seen := map[string]rune{}
+	for r := ' ' + 1; r <= '~'; r++ {
+		rows := fmt.Sprint(f.glyph(r).rows)
+		if other, ok := seen[rows]; ok {
+			t.Errorf("Expected %q and %q to be drawn differently", other, r)
+		}
+		seen[rows] = r
+	}
+	if f.glyph('é') != f.glyph('?') {
+		t.Errorf("Expected a missing glyph to be drawn as ?")
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	for hex, expected := range map[string]color.RGBA{"#ff8800": {0xff, 0x88, 0, 0xff}, "#f80": {0xff, 0x88, 0, 0xff}, "": {0x22, 0x22, 0x22, 0xff}} {
+		if c, err := parseHexColor(hex, "#222"); err != nil || c != expected {
+			t.Errorf("parseHexColor(%q) expected %v, got %v, %v", hex, expected, c, err)
+		}
+	}
+	for _, hex := range []string{"ff8800", "#ff880", "#gg8800", "red"} {
+		if _, err := parseHexColor(hex, ""); err == nil {
+			t.Errorf("parseHexColor(%q) expected an error", hex)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("a bb ccc dddd", defaultFont(), 6*6)
+	if expected := "a bb|ccc|dddd"; strings.Join(lines, "|") != expected {
+		t.Errorf("Expected the lines %q, got %q", expected, lines)
+	}
+}
+
+func TestSocialCards(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: A long title that has to be wrapped over several lines\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\nimage: /img/b.png\n---\n"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", OGImages: true, OGImageColor: "#f00"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ partial "opengraph" . }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	if !strings.Contains(string(files["post/a.html"]), `<meta property="og:image" content="http://auth/post/a/og.png"/>`) {
+		t.Errorf("Expected a to show its card, got: %s", files["post/a.html"])
+	}
+	if !strings.Contains(string(files["post/b.html"]), `<meta property="og:image" content="/img/b.png"/>`) {
+		t.Errorf("Expected b to show its image, got: %s", files["post/b.html"])
+	}
+	if _, ok := files["post/b/og.png"]; ok {
+		t.Errorf("Expected no card for a page with an image")
+	}
+
+	img, err := png.Decode(bytes.NewReader(files["post/a/og.png"]))
+	if err != nil {
+		t.Fatalf("Expected a png card, got %s", err)
+	}
+	if b := img.Bounds(); b.Dx() != cardWidth || b.Dy() != cardHeight {
+		t.Errorf("Expected a card of %dx%d, got %v", cardWidth, cardHeight, b)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0x22 || g>>8 != 0x22 || b>>8 != 0x22 {
+		t.Errorf("Expected the default background, got %v", img.At(0, 0))
+	}
+	text := 0
+	for y := 0; y < cardHeight; y++ {
+		for x := 0; x < cardWidth; x++ {
+			if r, g, _, _ := img.At(x, y).RGBA(); r == 0xffff && g == 0 {
+				if x < cardMargin || x >= cardWidth-cardMargin || y < cardMargin || y >= cardHeight-cardMargin {
+					t.Fatalf("Expected the title within the margins, got a pixel at %d,%d", x, y)
+				}
+				text++
+			}
+		}
+	}
+	if text == 0 {
+		t.Errorf("Expected the title drawn in red")
+	}
+}
+
+func TestSocialCardMissingGlyphs(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: Привет мир\n---\n"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/", OGImages: true},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ partial "opengraph" . }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	stderr := os.Stderr
+	log, err := ioutil.TempFile("", "hugo-cards")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(log.Name())
+	os.Stderr = log
+	err = s.RenderPages()
+	os.Stderr = stderr
+	must(err)
+
+	if _, ok := files["post/a/og.png"]; ok {
+		t.Errorf("Expected no card for a title the font can't draw")
+	}
+	if strings.Contains(string(files["post/a.html"]), "og:image") {
+		t.Errorf("Expected a to show no image, got: %s", files["post/a.html"])
+	}
+	warning, _ := ioutil.ReadFile(log.Name())
+	if !strings.Contains(string(warning), "WARNING: No social card for post/a.md") {
+		t.Errorf("Expected a warning about the missing glyphs, got: %q", warning)
+	}
+}
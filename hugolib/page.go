@@ -27,6 +27,7 @@ import (
 	"launchpad.net/goyaml"
 	json "launchpad.net/rjson"
 	"net/url"
+	"os"
 	"path"
 	"sort"
 	"strings"
@@ -34,19 +35,37 @@ import (
 )
 
 type Page struct {
-	Status      string
-	Images      []string
-	Content     template.HTML
-	Summary     template.HTML
-	RawMarkdown string // TODO should be []byte
-	Params      map[string]interface{}
-	contentType string
-	Draft       bool
-	Aliases     []string
-	Tmpl        bundle.Template
-	Markup      string
-	renderable  bool
-	layout      string
+	Status              string
+	Images              []string
+	Content             template.HTML
+	Summary             template.HTML
+	RawMarkdown         string // TODO should be []byte
+	Params              map[string]interface{}
+	FrontMatter         map[string]interface{} // everything the frontmatter holds, keys as written
+	contentType         string
+	Draft               bool
+	Headless            bool
+	Featured            bool
+	NoIndex             bool // kept out of the sitemap, with a robots noindex meta tag
+	Weight              int
+	PublishDate         time.Time // when the content goes live, its date by default
+	ExpiryDate          time.Time
+	Build               BuildOptions
+	Aliases             []string
+	Resources           Resources
+	Gallery             Gallery
+	Tmpl                bundle.Template
+	Markup              string
+	renderable          bool
+	layout              string
+	menus               []MenuEntry // menu names and entry options, see pageMenus
+	resources           []resourceMeta
+	noCard              bool                // the card font can't draw the title, see Site.checkCards
+	terms               map[string][]string // plural, terms in frontmatter order
+	translationKeyParam string
+	buildSet            bool  // _build is in the frontmatter
+	contentLine         int   // lines of the file before the content, see ShortcodeError
+	translations        Pages // see Translations
 	PageMeta
 	File
 	Position
@@ -57,9 +76,63 @@ type File struct {
 	FileName, Extension, Dir string
 }
 
+// BuildOptions control what is generated for a page.
+type BuildOptions struct {
+	Render           bool // the page gets its own output file
+	List             bool // the page appears in sections, indexes, feeds...
+	PublishResources bool // the resources of a bundle are published
+}
+
+var defaultBuildOptions = BuildOptions{Render: true, List: true, PublishResources: true}
+
+func (b *BuildOptions) update(v interface{}) error {
+	m, ok := lowerMap(v)
+	if !ok {
+		return fmt.Errorf("expected a map of options, got %v", v)
+	}
+
+	for k, u := range m {
+		on, ok := u.(bool)
+		if !ok {
+			return fmt.Errorf("%s must be true or false", k)
+		}
+		switch k {
+		case "render":
+			b.Render = on
+		case "list":
+			b.List = on
+		case "publishresources":
+			b.PublishResources = on
+		default:
+			return fmt.Errorf("unknown option %s", k)
+		}
+	}
+	return nil
+}
+
+// lowerMap returns the frontmatter map v with lower cased keys, whichever
+// decoder produced it.
+func lowerMap(v interface{}) (map[string]interface{}, bool) {
+	m := make(map[string]interface{})
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, u := range vv {
+			m[strings.ToLower(k)] = u
+		}
+	case map[interface{}]interface{}:
+		for k, u := range vv {
+			m[strings.ToLower(fmt.Sprint(k))] = u
+		}
+	default:
+		return nil, false
+	}
+	return m, true
+}
+
 type PageMeta struct {
 	WordCount      int
 	FuzzyWordCount int
+	ReadingTime    int
 }
 
 type Position struct {
@@ -74,39 +147,126 @@ func (p Pages) Less(i, j int) bool { return p[i].Date.Unix() > p[j].Date.Unix()
 func (p Pages) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
 // TODO eliminate unnecessary things
-func (p Pages) Sort()             { sort.Sort(p) }
+func (p Pages) Sort()             { sort.Stable(p) } // same dates keep the source order
 func (p Pages) Limit(n int) Pages { return p[0:n] }
 
-func getSummaryString(content []byte, fmt string) []byte {
+// ByWeight returns the pages ordered by weight, lightest first. Pages without
+// a weight come last, and pages of the same weight are ordered by date.
+func (p Pages) ByWeight() Pages {
+	sorted := make(Pages, len(p))
+	copy(sorted, p)
+	sort.Stable(pagesByWeight(sorted))
+	return sorted
+}
+
+type pagesByWeight Pages
+
+func (p pagesByWeight) Len() int      { return len(p) }
+func (p pagesByWeight) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p pagesByWeight) Less(i, j int) bool {
+	wi, wj := p[i].Weight, p[j].Weight
+	if wi != wj {
+		return wj == 0 || (wi != 0 && wi < wj)
+	}
+	return Pages(p).Less(i, j)
+}
+
+func (page *Page) getSummaryString(content []byte, fmt string) []byte {
 	if bytes.Contains(content, summaryDivider) {
 		// If user defines split:
 		// Split then render
-		return renderBytes(bytes.Split(content, summaryDivider)[0], fmt)
+		return page.renderBytes(bytes.Split(content, summaryDivider)[0], fmt)
 	} else {
 		// If hugo defines split:
 		// render, strip html, then split
-		plainContent := StripHTML(StripShortcodes(string(renderBytes(content, fmt))))
+		plainContent := StripHTML(StripShortcodes(string(page.renderBytes(content, fmt))))
+		if page.hasCJKLanguage() {
+			return []byte(TruncateWordsToWholeSentenceCJK(plainContent, summaryLength))
+		}
 		return []byte(TruncateWordsToWholeSentence(plainContent, summaryLength))
 	}
 }
 
-func renderBytes(content []byte, fmt string) []byte {
+func (page *Page) renderBytes(content []byte, fmt string) []byte {
 	switch fmt {
 	default:
-		return blackfriday.MarkdownCommon(content)
+		return page.renderMarkdown(content)
 	case "markdown":
-		return blackfriday.MarkdownCommon(content)
+		return page.renderMarkdown(content)
 	case "rst":
 		return []byte(getRstContent(content))
 	}
 }
 
+func (page *Page) renderMarkdown(content []byte) []byte {
+	content, math := protectMath(content, page.mathDelimiters())
+	return restoreMath(page.markdown(content), math)
+}
+
+// The flags of blackfriday.MarkdownCommon, which it doesn't export.
+const (
+	commonHtmlFlags = blackfriday.HTML_USE_XHTML |
+		blackfriday.HTML_USE_SMARTYPANTS |
+		blackfriday.HTML_SMARTYPANTS_FRACTIONS |
+		blackfriday.HTML_SMARTYPANTS_DASHES |
+		blackfriday.HTML_SMARTYPANTS_LATEX_DASHES
+	commonExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS |
+		blackfriday.EXTENSION_HEADER_IDS |
+		blackfriday.EXTENSION_BACKSLASH_LINE_BREAK |
+		blackfriday.EXTENSION_DEFINITION_LISTS
+)
+
+// markdown renders content the same way blackfriday.MarkdownCommon does,
+// with footnotes and definition lists turned on or off as the site
+// configuration says.
+func (page *Page) markdown(content []byte) []byte {
+	c := page.Site.Config
+	if c == nil {
+		return blackfriday.MarkdownCommon(content)
+	}
+
+	htmlFlags := commonHtmlFlags
+	extensions := commonExtensions &^ (blackfriday.EXTENSION_FOOTNOTES | blackfriday.EXTENSION_DEFINITION_LISTS)
+
+	params := blackfriday.HtmlRendererParameters{}
+	if c.Footnotes {
+		extensions |= blackfriday.EXTENSION_FOOTNOTES
+		htmlFlags |= blackfriday.HTML_FOOTNOTE_RETURN_LINKS
+		params.FootnoteAnchorPrefix = page.footnoteAnchorPrefix()
+		params.FootnoteReturnLinkContents = c.FootnoteReturnLinkContents
+	}
+	if c.DefinitionLists {
+		extensions |= blackfriday.EXTENSION_DEFINITION_LISTS
+	}
+
+	renderer := blackfriday.HtmlRendererWithParameters(htmlFlags, "", "", params)
+	if c.PygmentsCodeFences {
+		renderer = &highlightRenderer{Renderer: renderer, config: c}
+	}
+	return blackfriday.Markdown(content, renderer, extensions)
+}
+
+// footnoteAnchorPrefix keeps the footnote anchors of different pages apart,
+// so they still work when several pages are shown on one list page. The
+// whole source path is used, as pages in different directories, bundles
+// in particular, may share a file name.
+func (page *Page) footnoteAnchorPrefix() string {
+	file := strings.TrimSuffix(page.FileName, path.Ext(page.FileName))
+	return page.Site.Config.FootnoteAnchorPrefix + helper.Urlize(strings.Replace(file, "/", "-", -1)) + ":"
+}
+
 // TODO abstract further to support loading from more
 // than just files on disk. Should load reader (file, []byte)
 func newPage(filename string) *Page {
 	page := Page{contentType: "",
 		File:   File{FileName: filename, Extension: "html"},
-		Node:   Node{Keywords: make([]string, 10, 30)},
+		Build:  defaultBuildOptions,
+		Node:   Node{Kind: KindPage, Keywords: make([]string, 10, 30)},
 		Params: make(map[string]interface{})}
 	page.Date, _ = time.Parse("20060102", "20080101")
 	page.guessSection()
@@ -176,6 +336,10 @@ func (page *Page) Type() string {
 }
 
 func (page *Page) Layout(l ...string) []string {
+	if strings.HasSuffix(page.layout, ".html") {
+		// A template name, tried before the usual layouts.
+		return append([]string{page.layout}, layouts(page.Type(), "single")...)
+	}
 	if page.layout != "" {
 		return layouts(page.Type(), page.layout)
 	}
@@ -201,11 +365,18 @@ func layouts(types string, layout string) (layouts []string) {
 }
 
 func ReadFrom(buf io.Reader, name string) (page *Page, err error) {
+	return readFrom(buf, name, SiteInfo{})
+}
+
+// readFrom is ReadFrom for a page belonging to site, so that the site's
+// configuration applies while the content is rendered.
+func readFrom(buf io.Reader, name string, site SiteInfo) (page *Page, err error) {
 	if len(name) == 0 {
 		return nil, errors.New("Zero length page name")
 	}
 
 	p := newPage(name)
+	p.Site = site
 
 	if err = p.parse(buf); err != nil {
 		return
@@ -217,10 +388,20 @@ func ReadFrom(buf io.Reader, name string) (page *Page, err error) {
 }
 
 func (p *Page) analyzePage() {
-	p.WordCount = TotalWords(p.RawMarkdown)
+	if p.hasCJKLanguage() {
+		p.WordCount = TotalWordsCJK(p.RawMarkdown)
+		p.ReadingTime = (p.WordCount + 500) / 501
+	} else {
+		p.WordCount = TotalWords(p.RawMarkdown)
+		p.ReadingTime = (p.WordCount + 212) / 213
+	}
 	p.FuzzyWordCount = int((p.WordCount+100)/100) * 100
 }
 
+func (p *Page) hasCJKLanguage() bool {
+	return p.Site.Config != nil && p.Site.Config.HasCJKLanguage
+}
+
 func (p *Page) permalink() (*url.URL, error) {
 	baseUrl := string(p.Site.BaseUrl)
 	dir := strings.TrimSpace(p.Dir)
@@ -231,7 +412,7 @@ func (p *Page) permalink() (*url.URL, error) {
 		if p.Site.Config != nil && p.Site.Config.UglyUrls {
 			permalink = path.Join(dir, p.Slug, p.Extension)
 		} else {
-			permalink = dir + "/" + p.Slug + "/"
+			permalink = path.Join(dir, p.Slug) + "/"
 		}
 	} else if len(pUrl) > 2 {
 		permalink = pUrl
@@ -309,13 +490,28 @@ func (page *Page) handleJsonMetaData(datum []byte) (interface{}, error) {
 	return f, nil
 }
 
+// frontMatterDate is the date v of the front matter key, in the Timezone of
+// the site unless it has a time zone of its own.  A date that can't be read
+// is reported and taken to be the Unix epoch.
+func (page *Page) frontMatterDate(key string, v interface{}) time.Time {
+	d, err := interfaceToDate(v, page.Site.Config.location())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: %s: %s: %s\n", page.FileName, key, err)
+		return time.Unix(0, 0)
+	}
+	return d
+}
+
 func (page *Page) update(f interface{}) error {
 	m := f.(map[string]interface{})
+	page.FrontMatter = dataValue(m).(map[string]interface{})
 
 	for k, v := range m {
 		switch strings.ToLower(k) {
 		case "title":
 			page.Title = interfaceToString(v)
+		case "linktitle":
+			page.linkTitle = interfaceToString(v)
 		case "description":
 			page.Description = interfaceToString(v)
 		case "slug":
@@ -326,13 +522,36 @@ func (page *Page) update(f interface{}) error {
 			}
 			page.Url = helper.Urlize(interfaceToString(v))
 		case "type":
-			page.contentType = interfaceToString(v)
+			page.contentType = strings.Trim(strings.TrimSpace(interfaceToString(v)), "/")
 		case "keywords":
 			page.Keywords = interfaceArrayToStringArray(v)
 		case "date", "pubdate":
-			page.Date = interfaceToStringToDate(v)
+			page.Date = page.frontMatterDate(k, v)
+		case "publishdate":
+			page.PublishDate = page.frontMatterDate(k, v)
+		case "expirydate":
+			page.ExpiryDate = page.frontMatterDate(k, v)
 		case "draft":
 			page.Draft = interfaceToBool(v)
+		case "headless":
+			page.Headless = interfaceToBool(v)
+		case "featured":
+			page.Featured = interfaceToBool(v)
+		case "noindex", "private":
+			page.NoIndex = page.NoIndex || interfaceToBool(v)
+		case "weight":
+			page.Weight = interfaceToInt(v)
+		case "_build":
+			if err := page.Build.update(v); err != nil {
+				return fmt.Errorf("Invalid _build in %s: %s", page.FileName, err)
+			}
+			page.buildSet = true
+		case "resources":
+			meta, err := parseResourceMeta(v)
+			if err != nil {
+				return fmt.Errorf("Invalid resources in %s: %s", page.FileName, err)
+			}
+			page.resources = meta
 		case "layout":
 			page.layout = interfaceToString(v)
 		case "markup":
@@ -346,30 +565,70 @@ func (page *Page) update(f interface{}) error {
 			}
 		case "status":
 			page.Status = interfaceToString(v)
+		case "menu":
+			page.menus = pageMenus(v)
+		case "languagecode":
+			page.languageCode = interfaceToString(v)
+		case "translationkey":
+			page.translationKeyParam = interfaceToString(v)
+		case "languagedirection":
+			page.languageDirection = strings.ToLower(interfaceToString(v))
 		default:
 			// If not one of the explicit values, store in Params
-			switch vv := v.(type) {
-			case string: // handle string values
-				page.Params[strings.ToLower(k)] = vv
-			default: // handle array of strings as well
-				switch vvv := vv.(type) {
-				case []interface{}:
-					var a = make([]string, len(vvv))
-					for i, u := range vvv {
-						a[i] = interfaceToString(u)
-					}
-					page.Params[strings.ToLower(k)] = a
-				}
+			if pv := paramValue(v); pv != nil {
+				page.Params[strings.ToLower(k)] = pv
 			}
 		}
 	}
+
+	if page.Headless {
+		page.Build = BuildOptions{}
+	}
+	if page.PublishDate.IsZero() {
+		page.PublishDate = page.Date
+	}
 	return nil
 
 }
 
-func (page *Page) GetParam(key string) interface{} {
-	v := page.Params[strings.ToLower(key)]
+// paramValue normalizes a frontmatter value for storage in Params. Strings
+// are kept, arrays become []string and nested maps are stored with lower
+// cased keys so they can be reached with a dotted path. Anything else is
+// dropped.
+func paramValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case []interface{}:
+		var a = make([]string, len(vv))
+		for i, u := range vv {
+			a[i] = interfaceToString(u)
+		}
+		return a
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, u := range vv {
+			if pu := paramValue(u); pu != nil {
+				m[strings.ToLower(k)] = pu
+			}
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, u := range vv {
+			if pu := paramValue(u); pu != nil {
+				m[strings.ToLower(fmt.Sprint(k))] = pu
+			}
+		}
+		return m
+	}
+	return nil
+}
 
+// GetParam returns the string or []string stored under key. The key may be
+// a dotted path such as "meta.topics" to reach into nested frontmatter.
+func (page *Page) GetParam(key string) interface{} {
+	v := page.rawParam(key)
 	if v == nil {
 		return nil
 	}
@@ -383,6 +642,19 @@ func (page *Page) GetParam(key string) interface{} {
 	return nil
 }
 
+// rawParam is the param at the dotted path key, whatever its type.
+func (page *Page) rawParam(key string) interface{} {
+	var v interface{} = page.Params
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
 type frontmatterType struct {
 	markstart, markend []byte
 	parse              func([]byte) (interface{}, error)
@@ -439,13 +711,26 @@ func (page *Page) guessMarkupType() string {
 	return "unknown"
 }
 
+func (page *Page) isMarkdown() bool {
+	switch page.guessMarkupType() {
+	case "md", "markdown", "mdown":
+		return true
+	}
+	return false
+}
+
 func (page *Page) parse(reader io.Reader) error {
-	p, err := parser.ReadFrom(reader)
+	raw := new(bytes.Buffer)
+	p, err := parser.ReadFrom(io.TeeReader(reader, raw))
 	if err != nil {
 		return err
 	}
 
 	page.renderable = p.IsRenderable()
+	page.RawMarkdown = string(p.Content())
+	if head := raw.Len() - len(p.Content()); head > 0 {
+		page.contentLine = bytes.Count(raw.Bytes()[:head], []byte("\n"))
+	}
 
 	front := p.FrontMatter()
 
@@ -478,8 +763,8 @@ func (page *Page) convertMarkdown(lines io.Reader) {
 	b := new(bytes.Buffer)
 	b.ReadFrom(lines)
 	content := b.Bytes()
-	page.Content = template.HTML(string(blackfriday.MarkdownCommon(RemoveSummaryDivider(content))))
-	summary := getSummaryString(content, "markdown")
+	page.Content = template.HTML(string(page.renderMarkdown(RemoveSummaryDivider(content))))
+	summary := page.getSummaryString(content, "markdown")
 	page.Summary = template.HTML(string(summary))
 }
 
@@ -488,7 +773,7 @@ func (page *Page) convertRestructuredText(lines io.Reader) {
 	b.ReadFrom(lines)
 	content := b.Bytes()
 	page.Content = template.HTML(getRstContent(content))
-	summary := getSummaryString(content, "rst")
+	summary := page.getSummaryString(content, "rst")
 	page.Summary = template.HTML(string(summary))
 }
 
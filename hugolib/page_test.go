@@ -376,3 +376,55 @@ func listEqual(left, right []string) bool {
 
 	return true
 }
+
+func TestFrontMatter(t *testing.T) {
+	p, err := ReadFrom(strings.NewReader("---\ntitle: Simple\nWeight: 3\ndraft: false\nauthor:\n  name: Steve\n  links: [a, b]\n---\nSimple Page\n"), "simple.md")
+	if err != nil {
+		t.Fatalf("Unable to create a page with frontmatter: %s", err)
+	}
+	fm := p.FrontMatter
+	if fm["title"] != "Simple" || fm["Weight"] != 3 || fm["draft"] != false {
+		t.Errorf("Expected the known fields as written, got: %v", fm)
+	}
+	author, ok := fm["author"].(map[string]interface{})
+	if !ok || author["name"] != "Steve" || len(author["links"].([]interface{})) != 2 {
+		t.Errorf("Expected the nested frontmatter with string keys, got: %#v", fm["author"])
+	}
+}
+
+func TestPageWithCRLF(t *testing.T) {
+	content := "---\r\ntitle: Windows\r\ntags:\r\n - a\r\n - b\n---\r\n\r\nSummary line\r\n<!--more-->\r\nSome more {{% \r\ntext %}}\r\n"
+	p, err := ReadFrom(strings.NewReader(content), "windows.md")
+	if err != nil {
+		t.Fatalf("Unable to create a page with CRLF line endings: %s", err)
+	}
+	checkPageTitle(t, p, "Windows")
+	checkPageSummary(t, p, "<p>Summary line</p>\n")
+	if tags, _ := p.GetParam("tags").([]string); len(tags) != 2 {
+		t.Errorf("Expected the tags of the frontmatter, got: %v", p.GetParam("tags"))
+	}
+	if got := StripShortcodes(string(p.Content)); strings.Contains(got, "{{%") {
+		t.Errorf("Expected the shortcode spanning lines to be found, got: %q", got)
+	}
+}
+
+func TestLinkTitle(t *testing.T) {
+	p, err := ReadFrom(strings.NewReader("---\ntitle: A very long title for search engines\nlinktitle: Short\n---\nContent\n"), "long.md")
+	if err != nil {
+		t.Fatalf("Unable to create a page with a linktitle: %s", err)
+	}
+	if p.LinkTitle() != "Short" || p.Title != "A very long title for search engines" {
+		t.Errorf("Expected the linktitle apart from the title, got: %q, %q", p.LinkTitle(), p.Title)
+	}
+	if crumbs := p.Breadcrumbs(); crumbs[len(crumbs)-1].Title != "Short" {
+		t.Errorf("Expected the breadcrumb of the page to use the linktitle, got: %q", crumbs[len(crumbs)-1].Title)
+	}
+
+	p, err = ReadFrom(strings.NewReader("---\ntitle: Simple\n---\nContent\n"), "simple.md")
+	if err != nil {
+		t.Fatalf("Unable to create a page: %s", err)
+	}
+	if p.LinkTitle() != "Simple" {
+		t.Errorf("Expected the linktitle to fall back to the title, got: %q", p.LinkTitle())
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PageGroup is the pages sharing a key, e.g. a section or a month.
+type PageGroup struct {
+	Key   interface{}
+	Pages Pages
+}
+
+func (g PageGroup) Count() int { return len(g.Pages) }
+
+// PagesGroup is a list of groups, ordered by key.
+type PagesGroup []PageGroup
+
+// GroupBy groups the pages by a field or a method without arguments of
+// Page, e.g. "Section" or "Type". The groups are ordered by key, "asc" by
+// default or "desc", and keep the order of the pages.
+func (p Pages) GroupBy(key string, order ...string) (PagesGroup, error) {
+	desc, err := groupOrder(order, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return nil, nil
+	}
+	if _, ok := pageKey(p[0], key); !ok {
+		return nil, fmt.Errorf("Page has no field or method %s", key)
+	}
+	groups := groupPages(p, func(page *Page) (interface{}, bool) { return pageKey(page, key) })
+	sortGroups(groups, desc)
+	return groups, nil
+}
+
+// GroupByDate groups the pages by their date as formatted by format, e.g.
+// "2006-01" for months or "2006" for years. The groups are ordered by date,
+// newest first by default, "asc" for the oldest first.
+func (p Pages) GroupByDate(format string, order ...string) (PagesGroup, error) {
+	desc, err := groupOrder(order, true)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make(Pages, len(p))
+	copy(sorted, p)
+	sorted.Sort()
+	if !desc {
+		sort.Stable(sort.Reverse(sorted))
+	}
+	return groupPages(sorted, func(page *Page) (interface{}, bool) {
+		return page.Date.Format(format), !page.Date.IsZero()
+	}), nil
+}
+
+// GroupByParam groups the pages by a param of their front matter, a dotted
+// path for nested ones. Pages without the param are left out. The groups
+// are ordered by the value of the param, "asc" by default or "desc".
+func (p Pages) GroupByParam(param string, order ...string) (PagesGroup, error) {
+	desc, err := groupOrder(order, false)
+	if err != nil {
+		return nil, err
+	}
+	groups := groupPages(p, func(page *Page) (interface{}, bool) {
+		v := page.param(param)
+		return v, v != nil
+	})
+	sortGroups(groups, desc)
+	return groups, nil
+}
+
+// param is the value of the dotted path key in the params, nil when unset.
+func (page *Page) param(key string) interface{} {
+	var v interface{} = page.Params
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+func groupOrder(order []string, desc bool) (bool, error) {
+	if len(order) == 0 {
+		return desc, nil
+	}
+	switch strings.ToLower(order[0]) {
+	case "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	}
+	return false, fmt.Errorf("Unknown order %q, expecting \"asc\" or \"desc\"", order[0])
+}
+
+// pageKey is the field or the result of the method key of page.
+func pageKey(page *Page, key string) (interface{}, bool) {
+	v := reflect.ValueOf(page)
+	if m := v.MethodByName(key); m.IsValid() {
+		if m.Type().NumIn() != 0 || m.Type().NumOut() == 0 {
+			return nil, false
+		}
+		return m.Call(nil)[0].Interface(), true
+	}
+	if f := v.Elem().FieldByName(key); f.IsValid() && f.CanInterface() {
+		return f.Interface(), true
+	}
+	return nil, false
+}
+
+// groupPages groups p by key in the order the keys first appear.
+func groupPages(p Pages, key func(*Page) (interface{}, bool)) (groups PagesGroup) {
+	index := make(map[string]int)
+	for _, page := range p {
+		k, ok := key(page)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprintf("%T %v", k, k)
+		i, ok := index[id]
+		if !ok {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, PageGroup{Key: k})
+		}
+		groups[i].Pages = append(groups[i].Pages, page)
+	}
+	return
+}
+
+func sortGroups(groups PagesGroup, desc bool) {
+	if desc {
+		sort.Stable(sort.Reverse(groupsByKey(groups)))
+	} else {
+		sort.Stable(groupsByKey(groups))
+	}
+}
+
+type groupsByKey PagesGroup
+
+func (g groupsByKey) Len() int           { return len(g) }
+func (g groupsByKey) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+func (g groupsByKey) Less(i, j int) bool { return lessKey(g[i].Key, g[j].Key) }
+
+// lessKey orders numbers, strings and times, and other keys by how they
+// print.
+func lessKey(a, b interface{}) bool {
+	switch av := a.(type) {
+	case int:
+		if bv, ok := b.(int); ok {
+			return av < bv
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return av < bv
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return av < bv
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Before(bv)
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
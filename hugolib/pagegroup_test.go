@@ -0,0 +1,65 @@
+package hugolib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// groupPagesFrom reads the pages of files, pairs of a name and a content.
+func groupPagesFrom(t *testing.T, files ...[2]string) (p Pages) {
+	for _, file := range files {
+		page, err := ReadFrom(strings.NewReader(file[1]), file[0])
+		if err != nil {
+			t.Fatalf("Unable to create a page: %s", err)
+		}
+		p = append(p, page)
+	}
+	return
+}
+
+func groupSummary(groups PagesGroup) (summary []string) {
+	for _, g := range groups {
+		var titles []string
+		for _, p := range g.Pages {
+			titles = append(titles, p.Title)
+		}
+		summary = append(summary, g.Key.(string)+": "+strings.Join(titles, " "))
+	}
+	return
+}
+
+func TestGroupBy(t *testing.T) {
+	pages := groupPagesFrom(t,
+		[2]string{"post/a.md", "---\ntitle: a\ndate: 2013-03-02\nauthor: steve\n---\n"},
+		[2]string{"doc/b.md", "---\ntitle: b\ndate: 2013-01-05\n---\n"},
+		[2]string{"post/c.md", "---\ntitle: c\ndate: 2012-12-24\nauthor: bep\n---\n"},
+		[2]string{"doc/d.md", "---\ntitle: d\ndate: 2013-03-20\nauthor: steve\n---\n"},
+	)
+
+	for _, test := range []struct {
+		groups   func() (PagesGroup, error)
+		expected []string
+	}{
+		{func() (PagesGroup, error) { return pages.GroupBy("Section") }, []string{"doc: b d", "post: a c"}},
+		{func() (PagesGroup, error) { return pages.GroupBy("Type", "desc") }, []string{"post: a c", "doc: b d"}},
+		{func() (PagesGroup, error) { return pages.GroupByDate("2006-01") }, []string{"2013-03: d a", "2013-01: b", "2012-12: c"}},
+		{func() (PagesGroup, error) { return pages.GroupByDate("2006", "asc") }, []string{"2012: c", "2013: b a d"}},
+		{func() (PagesGroup, error) { return pages.GroupByParam("author") }, []string{"bep: c", "steve: a d"}},
+	} {
+		groups, err := test.groups()
+		if err != nil {
+			t.Fatalf("Unable to group: %s", err)
+		}
+		if got := groupSummary(groups); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected %v, got: %v", test.expected, got)
+		}
+	}
+
+	if _, err := pages.GroupBy("Missing"); err == nil {
+		t.Errorf("Expected an error grouping by a missing field")
+	}
+	if _, err := pages.GroupBy("Section", "up"); err == nil {
+		t.Errorf("Expected an error for an unknown order")
+	}
+}
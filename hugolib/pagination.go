@@ -0,0 +1,106 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	helpers "github.com/spf13/hugo/template"
+	"html/template"
+	"path"
+	"strconv"
+)
+
+// Pager is one page of a list of Pages split over several pages.
+type Pager struct {
+	PageNumber int
+	Pages      Pages
+	Url        string
+	Permalink  template.HTML
+	path       string
+	pagers     []*Pager
+}
+
+func (p *Pager) TotalPages() int  { return len(p.pagers) }
+func (p *Pager) Pagers() []*Pager { return p.pagers }
+func (p *Pager) First() *Pager    { return p.pagers[0] }
+func (p *Pager) Last() *Pager     { return p.pagers[len(p.pagers)-1] }
+func (p *Pager) IsFirst() bool    { return p.PageNumber == 1 }
+func (p *Pager) IsLast() bool     { return p.PageNumber == len(p.pagers) }
+func (p *Pager) HasPrev() bool    { return p.PageNumber > 1 }
+func (p *Pager) HasNext() bool    { return p.PageNumber < len(p.pagers) }
+
+// TotalItems is the number of pages listed over all the pagers.
+func (p *Pager) TotalItems() (n int) {
+	for _, pager := range p.pagers {
+		n += len(pager.Pages)
+	}
+	return
+}
+
+func (p *Pager) Prev() *Pager {
+	if !p.HasPrev() {
+		return nil
+	}
+	return p.pagers[p.PageNumber-2]
+}
+
+func (p *Pager) Next() *Pager {
+	if !p.HasNext() {
+		return nil
+	}
+	return p.pagers[p.PageNumber]
+}
+
+// paginate splits pages into pagers of size pages each.  The first pager
+// lives at base, the following ones at base/page/2, base/page/3, and so on.
+// A size of 0 or less puts all the pages in a single pager.
+func (s *Site) paginate(pages Pages, size int, base string) []*Pager {
+	if size <= 0 {
+		size = len(pages)
+	}
+
+	var pagers []*Pager
+	for start := 0; start == 0 || start < len(pages); start += size {
+		end := start + size
+		if end > len(pages) {
+			end = len(pages)
+		}
+
+		number := len(pagers) + 1
+		p := &Pager{
+			PageNumber: number,
+			Pages:      pages[start:end],
+			path:       base,
+		}
+		if number > 1 {
+			p.path = path.Join(base, "page", strconv.Itoa(number))
+		}
+
+		if s.Config.UglyUrls {
+			p.Url = helpers.Urlize(p.path) + ".html"
+		} else {
+			p.Url = helpers.Urlize(p.path) + "/"
+		}
+		p.Permalink = permalink(s, p.Url)
+		pagers = append(pagers, p)
+
+		if size == 0 {
+			break
+		}
+	}
+
+	for _, p := range pagers {
+		p.pagers = pagers
+	}
+	return pagers
+}
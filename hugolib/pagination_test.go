@@ -0,0 +1,188 @@
+package hugolib
+
+import (
+	"fmt"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"testing"
+)
+
+const PAGER_TEMPLATE = "{{ with .Data.Pager }}{{ .PageNumber }}/{{ .TotalPages }}:{{ range .Pages }}{{ .Title }},{{ end }}{{ if .HasPrev }}prev={{ .Prev.Url }}{{ end }}{{ if .HasNext }}next={{ .Next.Url }}{{ end }}{{ end }}"
+
+func TestPaginateIndexes(t *testing.T) {
+	var sources []source.ByteSource
+	for i := 1; i <= 5; i++ {
+		sources = append(sources, source.ByteSource{
+			Name:    fmt.Sprintf("sect/doc%d.md", i),
+			Content: []byte(fmt.Sprintf("---\ntitle: doc%d\ndate: 2013-01-0%d\ntags: [a]\n---\ncontent", i, i)),
+			Section: "sect",
+		})
+	}
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: sources},
+		Config: Config{
+			BaseUrl:       "http://auth/",
+			Indexes:       map[string]string{"tag": "tags"},
+			IndexPaginate: map[string]int{"tags": 2},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("indexes/tag.html", PAGER_TEMPLATE))
+
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderIndexes())
+
+	tests := []struct {
+		file, expected string
+	}{
+		{"tags/a.html", "1/3:doc5,doc4,next=tags/a/page/2/"},
+		{"tags/a/page/2.html", "2/3:doc3,doc2,prev=tags/a/next=tags/a/page/3/"},
+		{"tags/a/page/3.html", "3/3:doc1,prev=tags/a/page/2/"},
+	}
+
+	for _, test := range tests {
+		content, ok := files[test.file]
+		if !ok {
+			t.Fatalf("Did not find %s in target. %v", test.file, files)
+		}
+		if string(content) != HTML(test.expected) {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", test.file, HTML(test.expected), string(content))
+		}
+	}
+}
+
+func TestPagerPosition(t *testing.T) {
+	pagers := new(Site).paginate(make(Pages, 7), 3, "post")
+	for i, expected := range []struct {
+		first, last bool
+		items       int
+	}{
+		{true, false, 3}, {false, false, 3}, {false, true, 1},
+	} {
+		p := pagers[i]
+		if p.IsFirst() != expected.first || p.IsLast() != expected.last || len(p.Pages) != expected.items {
+			t.Errorf("Unexpected position of pager %d: first %t, last %t, %d pages", p.PageNumber, p.IsFirst(), p.IsLast(), len(p.Pages))
+		}
+		if p.PageNumber != i+1 || p.TotalPages() != 3 || p.TotalItems() != 7 {
+			t.Errorf("Expected page %d of 3 listing 7 pages, got page %d of %d listing %d", i+1, p.PageNumber, p.TotalPages(), p.TotalItems())
+		}
+	}
+}
+
+func TestPaginateSingle(t *testing.T) {
+	s := new(Site)
+	pages := Pages{new(Page), new(Page), new(Page)}
+
+	for _, size := range []int{0, 3, 10} {
+		pagers := s.paginate(pages, size, "tags/a")
+		if len(pagers) != 1 {
+			t.Fatalf("Expected a single pager with size %d, got: %d", size, len(pagers))
+		}
+		if len(pagers[0].Pages) != 3 || pagers[0].HasNext() || pagers[0].HasPrev() || !pagers[0].IsFirst() || !pagers[0].IsLast() {
+			t.Errorf("Pager with size %d should hold all pages without neighbours", size)
+		}
+	}
+
+	if pagers := s.paginate(Pages{}, 2, "tags/a"); len(pagers) != 1 {
+		t.Errorf("An empty list should still have one pager, got: %d", len(pagers))
+	}
+}
+
+func TestPaginateHomeAndSections(t *testing.T) {
+	var sources []source.ByteSource
+	for i := 1; i <= 5; i++ {
+		sources = append(sources, source.ByteSource{
+			Name:    fmt.Sprintf("sect/doc%d.md", i),
+			Content: []byte(fmt.Sprintf("---\ntitle: doc%d\ndate: 2013-01-0%d\n---\ncontent", i, i)),
+			Section: "sect",
+		})
+	}
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: sources},
+		Config: Config{BaseUrl: "http://auth/", Paginate: 2},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/list.html", "{{ .Url }} {{ with .Paginator }}{{ .PageNumber }}/{{ .TotalPages }}:{{ range .Pages }}{{ .Title }},{{ end }}{{ if .HasPrev }}prev={{ .Prev.Url }}{{ end }}{{ if .HasNext }}next={{ .Next.Url }}{{ end }}{{ end }}"))
+
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderLists())
+	must(s.RenderHomePage())
+
+	for file, expected := range map[string]string{
+		"/":           "http//auth/ 1/3:doc5,doc4,next=page/2/",
+		"page/2":      "page/2/ 2/3:doc3,doc2,prev=/next=page/3/",
+		"page/3":      "page/3/ 3/3:doc1,prev=page/2/",
+		"sect":        "sect/index.html 1/3:doc5,doc4,next=sect/page/2/",
+		"sect/page/2": "sect/page/2/ 2/3:doc3,doc2,prev=sect/next=sect/page/3/",
+		"sect/page/3": "sect/page/3/ 3/3:doc1,prev=sect/page/2/",
+	} {
+		if string(files[file]) != HTML(expected) {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", file, HTML(expected), files[file])
+		}
+	}
+}
+
+func TestSectionOptions(t *testing.T) {
+	var sources []source.ByteSource
+	for i := 1; i <= 5; i++ {
+		for _, section := range []string{"news", "doc", "blog"} {
+			sources = append(sources, source.ByteSource{
+				Name:    fmt.Sprintf("%s/%s%d.md", section, section, i),
+				Content: []byte(fmt.Sprintf("---\ntitle: %s%d\ndate: 2013-01-0%d\nweight: %d\n---\ncontent", section, i, i, 6-i)),
+				Section: section,
+			})
+		}
+	}
+	sources = append(sources, source.ByteSource{
+		Name:    "doc/_index.md",
+		Content: []byte("---\ntitle: Docs\nsort: weight\npaginate: 3\nrsslimit: 1\n---\n"),
+		Section: "doc",
+	})
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: sources},
+		Config: Config{
+			BaseUrl:        "http://auth/",
+			Paginate:       2,
+			SectionOptions: map[string]ListOptions{"news": {RSSLimit: 2, Sort: "title"}, "doc": {Sort: "title"}, "blog": {NoPaginate: true}},
+		},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/list.html", "{{ with .Paginator }}{{ .PageNumber }}/{{ .TotalPages }}:{{ range .Pages }}{{ .Title }},{{ end }}{{ end }}"))
+	must(s.addTemplate("rss.xml", "{{ range .Data.Pages }}{{ .Title }},{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderLists())
+
+	for file, expected := range map[string]string{
+		"news":        HTML("1/3:news1,news2,"),
+		"news/page/3": HTML("3/3:news5,"),
+		"news.xml":    "news5,news4,",
+		"doc":         HTML("1/2:doc5,doc4,doc3,"),
+		"doc/page/2":  HTML("2/2:doc2,doc1,"),
+		"doc.xml":     "doc5,",
+		"blog":        HTML("1/1:blog5,blog4,blog3,blog2,blog1,"),
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s content expected:\n%q\ngot:\n%q", file, expected, files[file])
+		}
+	}
+
+	s.Config.SectionOptions = map[string]ListOptions{"news": {Sort: "author"}}
+	if err := s.BuildSiteMeta(); err == nil {
+		t.Errorf("Expected an error for an unknown sort")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"html/template"
+	"path"
+	"strings"
+	"sync"
+)
+
+// partials renders the templates of layouts/partials for the partial and
+// partialCached template funcs.
+type partials struct {
+	s     *Site
+	mu    sync.Mutex
+	cache map[string]template.HTML
+}
+
+func (p *partials) funcs() template.FuncMap {
+	return template.FuncMap{"partial": p.partial, "partialCached": p.partialCached}
+}
+
+// reset empties the cache of partialCached, e.g. when content changed.
+func (p *partials) reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.cache = nil
+	p.mu.Unlock()
+}
+
+// partial renders layouts/partials/name with context, e.g.
+// {{ partial "header" . }}. The ".html" may be left out. The built in
+// partials, like "opengraph", are used when the layouts have none.
+func (p *partials) partial(name string, context interface{}) (template.HTML, error) {
+	if path.Ext(name) == "" {
+		name += ".html"
+	}
+	layout := "partials/" + name
+	if p.s.Tmpl.Lookup(layout) == nil && p.s.Tmpl.Lookup(internalPrefix+layout) != nil {
+		layout = internalPrefix + layout
+	}
+	buf := new(bytes.Buffer)
+	if err := p.s.Tmpl.ExecuteTemplate(buf, layout, context); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// partialCached is partial rendered once per build and per variant, e.g.
+// {{ partialCached "footer" . }} or {{ partialCached "nav" . .Section }} for
+// partials the same on every page, or on every page of a section.
+func (p *partials) partialCached(name string, context interface{}, variants ...string) (template.HTML, error) {
+	key := name + "\x00" + strings.Join(variants, "\x00")
+	p.mu.Lock()
+	out, ok := p.cache[key]
+	p.mu.Unlock()
+	if ok {
+		return out, nil
+	}
+
+	out, err := p.partial(name, context)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	if p.cache == nil {
+		p.cache = make(map[string]template.HTML)
+	}
+	p.cache[key] = out
+	p.mu.Unlock()
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package hugolib
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPartials(t *testing.T) {
+	s := new(Site)
+	s.prepTemplates()
+	calls := 0
+	s.Tmpl.AddFuncs(map[string]interface{}{"count": func() int { calls++; return calls }})
+	must(s.addTemplate("partials/header.html", "<h1>{{ . }}</h1>"))
+	must(s.addTemplate("partials/nav.html", "nav {{ . }} {{ count }}"))
+	must(s.addTemplate("page.html", `{{ partial "header" .Title }}|{{ partial "header.html" .Title }}|{{ partialCached "nav" .Title }}|{{ partialCached "nav" .Title .Section }}`))
+	must(s.addTemplate("broken.html", `{{ partial "missing" . }}`))
+
+	for _, test := range []struct {
+		title, section, expected string
+	}{
+		{"a", "post", "<h1>a</h1>|<h1>a</h1>|nav a 1|nav a 2"},
+		{"b", "post", "<h1>b</h1>|<h1>b</h1>|nav a 1|nav a 2"},
+		{"c", "doc", "<h1>c</h1>|<h1>c</h1>|nav a 1|nav c 3"},
+	} {
+		out := new(bytes.Buffer)
+		must(s.Tmpl.ExecuteTemplate(out, "page.html", map[string]string{"Title": test.title, "Section": test.section}))
+		if out.String() != test.expected {
+			t.Errorf("Expected %q, got: %q", test.expected, out.String())
+		}
+	}
+
+	s.partials.reset()
+	out := new(bytes.Buffer)
+	must(s.Tmpl.ExecuteTemplate(out, "page.html", map[string]string{"Title": "d", "Section": "post"}))
+	if expected := "<h1>d</h1>|<h1>d</h1>|nav d 4|nav d 5"; out.String() != expected {
+		t.Errorf("Expected the cache to be emptied, got: %q", out.String())
+	}
+
+	if err := s.Tmpl.ExecuteTemplate(new(bytes.Buffer), "missing.html", nil); err == nil {
+		t.Errorf("Expected an error for a missing template")
+	}
+	if err := s.Tmpl.ExecuteTemplate(new(bytes.Buffer), "broken.html", nil); err == nil {
+		t.Errorf("Expected an error for a missing partial")
+	}
+}
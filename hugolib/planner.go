@@ -3,6 +3,11 @@ package hugolib
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 func (s *Site) ShowPlan(out io.Writer) (err error) {
@@ -18,7 +23,7 @@ func (s *Site) ShowPlan(out io.Writer) (err error) {
 			fmt.Fprintf(out, " (renderer: n/a)")
 		}
 		if s.Tmpl != nil {
-			for _, l := range p.Layout() {
+			for _, l := range pageLayouts(p) {
 				fmt.Fprintf(out, " (layout: %s, exists: %t)", l, s.Tmpl.Lookup(l) != nil)
 			}
 		}
@@ -50,3 +55,222 @@ func (s *Site) ShowPlan(out io.Writer) (err error) {
 	}
 	return
 }
+
+// IndexSuggestion describes a frontmatter key that looks like an index but
+// isn't configured as one.
+type IndexSuggestion struct {
+	Key   string
+	Pages int // pages that set the key
+	Terms int // distinct values across those pages
+}
+
+type indexSuggestions []IndexSuggestion
+
+func (s indexSuggestions) Len() int      { return len(s) }
+func (s indexSuggestions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s indexSuggestions) Less(i, j int) bool {
+	if s[i].Pages != s[j].Pages {
+		return s[i].Pages > s[j].Pages
+	}
+	return s[i].Key < s[j].Key
+}
+
+// IndexSuggestions returns the frontmatter keys holding lists (or comma
+// separated strings) that are not configured as indexes, most used first.
+func (s *Site) IndexSuggestions() []IndexSuggestion {
+	configured := make(map[string]bool)
+	for _, plural := range s.Config.Indexes {
+		configured[plural] = true
+		if path, ok := s.Config.IndexSources[plural]; ok {
+			configured[strings.ToLower(strings.Split(path, ".")[0])] = true
+		}
+	}
+
+	var suggestions indexSuggestions
+	for _, key := range s.possibleIndexes() {
+		if configured[key] {
+			continue
+		}
+		suggestion := IndexSuggestion{Key: key}
+		terms := make(map[string]bool)
+		for _, p := range s.Pages {
+			var vals []string
+			switch v := p.Params[key].(type) {
+			case []string:
+				vals = v
+			case string:
+				if !strings.Contains(v, ",") {
+					continue
+				}
+				vals = splitTerms(v)
+			default:
+				continue
+			}
+			suggestion.Pages++
+			for _, val := range vals {
+				terms[kp(val)] = true
+			}
+		}
+		if suggestion.Pages > 0 {
+			suggestion.Terms = len(terms)
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	sort.Sort(suggestions)
+	return suggestions
+}
+
+// ShowIndexSuggestions reports frontmatter keys that could be enabled as
+// indexes.
+func (s *Site) ShowIndexSuggestions(out io.Writer) {
+	suggestions := s.IndexSuggestions()
+	if len(suggestions) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Possible indexes not configured:\n")
+	for _, sg := range suggestions {
+		fmt.Fprintf(out, " %s (%d pages, %d terms)\n", sg.Key, sg.Pages, sg.Terms)
+	}
+}
+
+// Content is reported as expiring soon or as a stale draft using these
+// unless the configuration says otherwise.
+const (
+	defaultExpirySoon  = 7 * 24 * time.Hour
+	defaultStaleDrafts = 30 * 24 * time.Hour
+)
+
+// StaleContent is a page in need of editorial attention.
+type StaleContent struct {
+	Page   *Page
+	Reason string // "expired", "expires" or "draft since"
+	Date   time.Time
+}
+
+type staleContent []StaleContent
+
+func (s staleContent) Len() int           { return len(s) }
+func (s staleContent) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s staleContent) Less(i, j int) bool { return s[i].Date.Before(s[j].Date) }
+
+// StaleContent lists, oldest first, the pages which expired or will within
+// ExpirySoon of now, and the drafts dated more than StaleDrafts before now.
+func (s *Site) StaleContent(now time.Time) []StaleContent {
+	soon := s.duration("expirysoon", s.Config.ExpirySoon, defaultExpirySoon)
+	stale := s.duration("staledrafts", s.Config.StaleDrafts, defaultStaleDrafts)
+
+	var report staleContent
+	for _, pages := range []Pages{s.Pages, s.Unlisted, s.held} {
+		for _, p := range pages {
+			switch {
+			case p.ExpiryDate.IsZero():
+			case !p.ExpiryDate.After(now):
+				report = append(report, StaleContent{p, "expired", p.ExpiryDate})
+			case p.ExpiryDate.Before(now.Add(soon)):
+				report = append(report, StaleContent{p, "expires", p.ExpiryDate})
+			}
+			if p.Draft && p.Date.Before(now.Add(-stale)) {
+				report = append(report, StaleContent{p, "draft since", p.Date})
+			}
+		}
+	}
+	sort.Stable(report)
+	return report
+}
+
+// duration parses a configured duration, warning about invalid ones.
+func (s *Site) duration(name, value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid %s %q, expected a duration like 168h\n", name, value)
+		return def
+	}
+	return d
+}
+
+// ShowStaleContent reports expired and expiring content and old drafts.
+func (s *Site) ShowStaleContent(out io.Writer, now time.Time) {
+	report := s.StaleContent(now)
+	if len(report) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Content to review:\n")
+	for _, c := range report {
+		fmt.Fprintf(out, " %s %s %s\n", c.Page.sourcePath(), c.Reason, c.Date.Format("2006-01-02"))
+	}
+}
+
+// ScheduledContent is a page published later than the time asked about.
+type ScheduledContent struct {
+	Page  *Page
+	Built bool // part of the site as built
+}
+
+type scheduledContent []ScheduledContent
+
+func (s scheduledContent) Len() int      { return len(s) }
+func (s scheduledContent) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s scheduledContent) Less(i, j int) bool {
+	return s[i].Page.PublishDate.Before(s[j].Page.PublishDate)
+}
+
+// Scheduled lists, in publishing order, the content published after now.
+func (s *Site) Scheduled(now time.Time) []ScheduledContent {
+	var scheduled scheduledContent
+	for i, pages := range []Pages{s.Pages, s.Unlisted, s.held} {
+		for _, p := range pages {
+			if p.PublishDate.After(now) {
+				scheduled = append(scheduled, ScheduledContent{p, i < 2})
+			}
+		}
+	}
+	sort.Stable(scheduled)
+	return scheduled
+}
+
+// ShowScheduled reports the content published after now and whether the
+// site as built includes it already.
+func (s *Site) ShowScheduled(out io.Writer, now time.Time) {
+	scheduled := s.Scheduled(now)
+	if len(scheduled) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Scheduled content:\n")
+	for _, c := range scheduled {
+		state := "built now"
+		if !c.Built {
+			state = "held back"
+		}
+		if c.Page.Draft {
+			state += ", draft"
+		}
+		fmt.Fprintf(out, " %s goes live %s (%s)\n", c.Page.sourcePath(), c.Page.PublishDate.Format("2006-01-02 15:04"), state)
+	}
+}
+
+// ShowTemplateOverrides reports the templates defined more than once and
+// which definition is used, so an edited template that has no effect can be
+// told apart from one that is replaced.
+func (s *Site) ShowTemplateOverrides(out io.Writer) {
+	if s.Tmpl == nil || len(s.Tmpl.Overrides()) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Template overrides:\n")
+	for _, o := range s.Tmpl.Overrides() {
+		fmt.Fprintf(out, " %s: %s replaces %s\n", o.Name, s.relPath(o.Used), s.relPath(o.Replaced))
+	}
+}
+
+// relPath is name relative to the site when it is below it.
+func (s *Site) relPath(name string) string {
+	if !filepath.IsAbs(name) {
+		return name
+	}
+	if rel, err := filepath.Rel(s.Config.GetPath(), name); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return name
+}
@@ -0,0 +1,251 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"github.com/spf13/hugo/source"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ReBuild updates a built site after the given files changed. Content files
+// are named by their absolute path or their path relative to the content
+// directory; removed files may be listed too.
+//
+// Only the changed content is read again. What gets rendered again are the
+// changed pages, the pages before and after them, the homepage and the
+// lists of the sections and index terms the pages were and are filed under.
+// When a page is added or removed, or a change shows in the collections of
+// the whole site, like .Site.Pages, .Site.Recent, .Site.Featured or
+// .Site.Menus, every page and list is rendered again. Anything else, like a template, a bundle or a source unable to read single
+// files, triggers a full Build. Outputs of removed pages are left in place.
+func (s *Site) ReBuild(changedFiles []string) error {
+	src, ok := s.Source.(source.FileReader)
+	if !ok || s.Tmpl == nil {
+		return s.Build()
+	}
+	names, ok := s.changedContent(changedFiles)
+	if !ok {
+		return s.Build()
+	}
+	s.partials.reset()
+	s.remoteData.reset()
+
+	deps := newRebuildDeps()
+	var added Pages
+	for _, name := range names {
+		old := s.removePage(name)
+		if old != nil {
+			deps.add(s, old)
+		}
+
+		file, err := src.File(name)
+		if os.IsNotExist(err) {
+			deps.all = deps.all || old != nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		page, err := s.readPage(file)
+		if err != nil {
+			return err
+		}
+		if s.addPage(page) {
+			added = append(added, page)
+			deps.all = deps.all || old == nil || siteWide(old) != siteWide(page)
+		} else {
+			deps.all = deps.all || old != nil
+		}
+	}
+
+	s.Pages.Sort()
+	s.setupPrevNext()
+	if err := s.BuildSiteMeta(); err != nil {
+		return err
+	}
+
+	for _, p := range added {
+		if err := s.processShortcodes(p); err != nil {
+			return err
+		}
+		if err := s.renderAliases(p); err != nil {
+			return err
+		}
+		deps.add(s, p)
+	}
+	if err := s.renderDeps(deps); err != nil {
+		return err
+	}
+	return s.RenderStatic()
+}
+
+// changedContent returns the paths of the changed files relative to the
+// content directory, or false when a file can't be rebuilt on its own.
+func (s *Site) changedContent(files []string) (names []string, ok bool) {
+	contentDir := s.absContentDir()
+	bundles := bundleDirs(s.Source.Files())
+	for _, name := range files {
+		if filepath.IsAbs(name) {
+			rel, err := filepath.Rel(contentDir, name)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil, false
+			}
+			name = rel
+		}
+		name = path.Clean(filepath.ToSlash(name))
+
+		if path.Base(name) == bundleIndex {
+			return nil, false
+		}
+		if _, in := bundleOf(bundles, cleanDir(path.Dir(name))); in {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+// removePage takes the page read from the content file name out of the site.
+func (s *Site) removePage(name string) *Page {
+	for _, pages := range []*Pages{&s.Pages, &s.Unlisted} {
+		for i, p := range *pages {
+			if p.sourcePath() == name {
+				*pages = append((*pages)[:i], (*pages)[i+1:]...)
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// rebuildDeps is what has to be rendered again after pages changed.
+type rebuildDeps struct {
+	pages    map[*Page]bool
+	sections map[string]bool
+	terms    map[string]map[string]bool // plural, term
+	all      bool                       // every page and list, see siteWide
+}
+
+func newRebuildDeps() *rebuildDeps {
+	return &rebuildDeps{
+		pages:    make(map[*Page]bool),
+		sections: make(map[string]bool),
+		terms:    make(map[string]map[string]bool),
+	}
+}
+
+// add records the outputs showing p as it is filed at the moment.
+func (d *rebuildDeps) add(s *Site, p *Page) {
+	for _, q := range []*Page{p, p.Prev, p.Next} {
+		if q != nil {
+			d.pages[q] = true
+		}
+	}
+	d.sections[p.Section] = true
+	for plural, terms := range p.terms {
+		if d.terms[plural] == nil {
+			d.terms[plural] = make(map[string]bool)
+		}
+		for _, term := range terms {
+			d.terms[plural][term] = true
+			if s.Config.HierarchicalIndexes {
+				for _, parent := range parentTerms(term) {
+					d.terms[plural][parent] = true
+				}
+			}
+		}
+	}
+}
+
+// siteWide is what of p shows in the collections of the whole site, which
+// any page or list may range over.
+func siteWide(p *Page) string {
+	plink, _ := p.Permalink()
+	return fmt.Sprintf("%q %q %s %d %t %q %v", p.Title, p.LinkTitle(), p.Date, p.Weight, p.Featured, plink, p.menus)
+}
+
+// renderDeps renders what is recorded in deps and still part of the site.
+func (s *Site) renderDeps(deps *rebuildDeps) error {
+	if deps.all {
+		return s.renderAll()
+	}
+
+	var pages Pages
+	for _, list := range []Pages{s.Pages, s.Unlisted} {
+		for _, p := range list {
+			if deps.pages[p] {
+				pages = append(pages, p)
+			}
+		}
+	}
+	if err := s.renderPages(pages); err != nil {
+		return err
+	}
+
+	for name := range deps.sections {
+		if info := s.Info.Sections.Get(name); info != nil {
+			if err := s.renderSection(info); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, singular := range s.indexSingulars() {
+		plural := s.Config.Indexes[singular]
+		if len(deps.terms[plural]) == 0 {
+			continue
+		}
+		for _, k := range s.Indexes[plural].keys() {
+			if !deps.terms[plural][k] {
+				continue
+			}
+			if err := s.renderTerm(singular, k); err != nil {
+				return err
+			}
+		}
+		if err := s.renderTermsList(singular); err != nil {
+			return err
+		}
+	}
+
+	if err := s.RenderHomePage(); err != nil {
+		return err
+	}
+	return s.RenderSitemap()
+}
+
+// renderAll renders every page and list again, the shortcodes of the
+// pages being rendered already.
+func (s *Site) renderAll() error {
+	if err := s.RenderIndexes(); err != nil {
+		return err
+	}
+	if err := s.RenderIndexesIndexes(); err != nil {
+		return err
+	}
+	if err := s.RenderLists(); err != nil {
+		return err
+	}
+	if err := s.RenderPages(); err != nil {
+		return err
+	}
+	if err := s.RenderHomePage(); err != nil {
+		return err
+	}
+	return s.RenderSitemap()
+}
@@ -0,0 +1,126 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"sort"
+	"testing"
+)
+
+func rebuildSite(files map[string][]byte, fake []source.ByteSource) *Site {
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: fake},
+		Config: Config{Indexes: map[string]string{"tag": "tags"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}{{ with .Next }} next {{ .Title }}{{ end }}"))
+	must(s.addTemplate("_default/list.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	s.setupPrevNext()
+	must(s.BuildSiteMeta())
+	must(s.Render())
+	return s
+}
+
+func renderedFiles(files map[string][]byte) (names []string) {
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func TestReBuild(t *testing.T) {
+	files := make(map[string][]byte)
+	fake := []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\ntags: [go]\n---\n"), Section: "post"},
+		{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-02\ntags: [go]\n---\n"), Section: "post"},
+		{Name: "blog/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-01\n---\n"), Section: "blog"},
+		{Name: "post/d.md", Content: []byte("---\ntitle: d\ndate: 2012-01-01\n---\n"), Section: "post"},
+	}
+	s := rebuildSite(files, fake)
+
+	for name := range files {
+		delete(files, name)
+	}
+	s.Source.(*source.InMemorySource).ByteSource[0].Content = []byte("---\ntitle: a\ndate: 2013-01-03\ntags: [web]\n---\n")
+	must(s.ReBuild([]string{"post/a.md"}))
+
+	expected := []string{".xml", "/", "post", "post.xml", "post/a.html", "post/b.html", "sitemap.xml",
+		"tags.xml", "tags/go.html", "tags/go.xml", "tags/index.html", "tags/web.html", "tags/web.xml"}
+	if got := renderedFiles(files); !listEqual(got, expected) {
+		t.Errorf("Expected to render %v again, got: %v", expected, got)
+	}
+	if string(files["post/a.html"]) != HTML("a next b") {
+		t.Errorf("Expected a to be rendered again, got: %q", files["post/a.html"])
+	}
+	if len(s.Indexes["tags"]["go"]) != 1 || len(s.Indexes["tags"]["web"]) != 1 {
+		t.Errorf("Expected a to move from go to web, got: %v", s.Indexes["tags"])
+	}
+}
+
+func TestReBuildRemovedPage(t *testing.T) {
+	files := make(map[string][]byte)
+	fake := []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\n---\n"), Section: "post"},
+		{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-02\n---\n"), Section: "post"},
+		{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-01\n---\n"), Section: "post"},
+	}
+	s := rebuildSite(files, fake)
+
+	src := s.Source.(*source.InMemorySource)
+	src.ByteSource = append(src.ByteSource[:1], src.ByteSource[2:]...)
+	must(s.ReBuild([]string{"post/b.md"}))
+
+	if len(s.Pages) != 2 {
+		t.Fatalf("Expected b to be removed, got %d pages", len(s.Pages))
+	}
+	if string(files["post/a.html"]) != HTML("a next c") {
+		t.Errorf("Expected a to link to c, got: %q", files["post/a.html"])
+	}
+}
+
+func TestReBuildShortcodeError(t *testing.T) {
+	files := make(map[string][]byte)
+	fake := []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+	}
+	s := rebuildSite(files, fake)
+
+	s.Source.(*source.InMemorySource).ByteSource[0].Content = []byte("---\ntitle: a\n---\n{{< missing >}}\n")
+	err := s.ReBuild([]string{"post/a.md"})
+	if _, ok := err.(*ShortcodeError); !ok {
+		t.Fatalf("Expected the shortcode error to stop the rebuild, got %v", err)
+	}
+
+	s.Config.LenientShortcodes = true
+	must(s.ReBuild([]string{"post/a.md"}))
+}
+
+func TestReBuildMenuTitle(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\nmenu: main\n---\n"), Section: "post"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-02\n---\n"), Section: "post"},
+			{Name: "blog/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-01\n---\n"), Section: "blog"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ range .Site.Menus.main }}{{ .Name }}{{ end }}"))
+	must(s.CreatePages())
+	s.setupPrevNext()
+	must(s.BuildSiteMeta())
+	must(s.Render())
+
+	s.Source.(*source.InMemorySource).ByteSource[0].Content = []byte("---\ntitle: A\ndate: 2013-01-03\nmenu: main\n---\n")
+	must(s.ReBuild([]string{"post/a.md"}))
+
+	if string(files["blog/b.html"]) != HTML("A") {
+		t.Errorf("Expected b to show the new menu title, got: %q", files["blog/b.html"])
+	}
+}
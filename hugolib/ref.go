@@ -0,0 +1,56 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ID identifies the page for as long as its content file keeps its name:
+// the path of the file relative to the content directory, without its
+// extension, e.g. "post/hello" for content/post/hello.md.  Changing the
+// title, slug or url of the page leaves it alone.
+func (p *Page) ID() string {
+	name, _ := fileExt(path.Base(p.FileName))
+	return path.Join(cleanDir(p.Dir), name)
+}
+
+// ref is the ref template func, the permalink of the page with the id of
+// ref, optionally followed by a #fragment, e.g. {{ ref "post/hello#setup" }}.
+func (s *Site) ref(ref string) (string, error) {
+	return s.refLink(ref, (*Page).Permalink)
+}
+
+// relref is ref with the permalink relative to the host.
+func (s *Site) relref(ref string) (string, error) {
+	return s.refLink(ref, (*Page).RelPermalink)
+}
+
+func (s *Site) refLink(ref string, link func(*Page) (string, error)) (string, error) {
+	id, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i != -1 {
+		id, fragment = ref[:i], ref[i:]
+	}
+	p := s.Info.GetPage(id)
+	if p == nil {
+		return "", fmt.Errorf("No page with the id %q to refer to", id)
+	}
+	l, err := link(p)
+	if err != nil {
+		return "", err
+	}
+	return l + fragment, nil
+}
@@ -0,0 +1,33 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"testing"
+)
+
+func TestRefs(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/hello.md", Content: []byte("---\ntitle: Hello\nslug: hello-world\n---\n"), Section: "post"},
+			{Name: "post/refs.md", Content: []byte("---\ntitle: Refs\n---\n"), Section: "post"},
+		}},
+		Config: Config{BaseUrl: "http://auth/"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ .ID }} {{ ref "post/hello" }} {{ relref "post/hello.md#setup" }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	expected := HTML("post/refs http://auth/post/hello-world/ /post/hello-world/#setup")
+	if string(files["post/refs.html"]) != expected {
+		t.Errorf("post/refs.html expected: %q, got: %q", expected, files["post/refs.html"])
+	}
+	if _, err := s.ref("post/missing"); err == nil {
+		t.Errorf("Expected a reference to a missing page to fail")
+	}
+}
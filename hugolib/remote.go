@@ -0,0 +1,227 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL is how long fetched data is used before it's fetched again.
+const defaultCacheTTL = 24 * time.Hour
+
+// remoteData reads the data of the getJSON and getCSV template funcs from
+// urls or files of the site. Responses are cached in Config.CacheDir for
+// Config.CacheTTL, and every source is read once per build, pages using one
+// being read waiting for it.
+type remoteData struct {
+	s      *Site
+	client *http.Client
+	mu     sync.Mutex
+	read   map[string]*remoteRead
+}
+
+// remoteRead is a source being read, or read already once done is closed.
+type remoteRead struct {
+	done    chan struct{}
+	content []byte
+	err     error
+}
+
+func (r *remoteData) funcs() template.FuncMap {
+	return template.FuncMap{"getJSON": r.getJSON, "getCSV": r.getCSV}
+}
+
+// reset forgets what was read, e.g. when the site is rebuilt.
+func (r *remoteData) reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.read = nil
+	r.mu.Unlock()
+}
+
+// getJSON decodes the JSON at the url joined from parts, e.g.
+// {{ $user := getJSON "https://api.github.com/users/" .Params.github }}.
+func (r *remoteData) getJSON(parts ...string) (interface{}, error) {
+	url := strings.Join(parts, "")
+	content, err := r.get(url)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err = json.Unmarshal(content, &v); err != nil {
+		return nil, fmt.Errorf("Error decoding JSON from %s: %s", url, err)
+	}
+	return dataValue(v), nil
+}
+
+// getCSV reads the records of the CSV at the url joined from parts, fields
+// separated by sep, e.g. {{ range getCSV ";" "data/prices.csv" }}.
+func (r *remoteData) getCSV(sep string, parts ...string) ([][]string, error) {
+	url := strings.Join(parts, "")
+	if len(sep) != 1 {
+		return nil, fmt.Errorf("The separator of getCSV must be a single character, got: %q", sep)
+	}
+	content, err := r.get(url)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = rune(sep[0])
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading CSV from %s: %s", url, err)
+	}
+	return records, nil
+}
+
+// get reads url, a file relative to the site unless it starts with
+// http:// or https://.
+func (r *remoteData) get(url string) ([]byte, error) {
+	r.mu.Lock()
+	if r.read == nil {
+		r.read = make(map[string]*remoteRead)
+	}
+	read, reading := r.read[url]
+	if !reading {
+		read = &remoteRead{done: make(chan struct{})}
+		r.read[url] = read
+	}
+	r.mu.Unlock()
+
+	if reading {
+		<-read.done
+		return read.content, read.err
+	}
+
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		read.content, read.err = r.fetch(url)
+	} else {
+		var name string
+		if name, read.err = r.s.sitePath(url); read.err == nil {
+			read.content, read.err = ioutil.ReadFile(name)
+		}
+	}
+	if read.err != nil {
+		// The next page using the source tries again.
+		r.mu.Lock()
+		if r.read[url] == read {
+			delete(r.read, url)
+		}
+		r.mu.Unlock()
+	}
+	close(read.done)
+	return read.content, read.err
+}
+
+// fetch gets url from the cache when it was fetched less than
+// Config.CacheTTL ago, else from the network. A stale copy is used when
+// the network fails.
+func (r *remoteData) fetch(url string) ([]byte, error) {
+	name := r.cacheFile(url)
+	ttl := r.s.duration("cachettl", r.s.Config.CacheTTL, defaultCacheTTL)
+	fi, statErr := os.Stat(name)
+	if statErr == nil && time.Since(fi.ModTime()) < ttl {
+		if content, err := ioutil.ReadFile(name); err == nil {
+			return content, nil
+		}
+	}
+
+	content, err := r.download(url)
+	if err != nil {
+		if statErr == nil {
+			if stale, readErr := ioutil.ReadFile(name); readErr == nil {
+				fmt.Fprintf(os.Stderr, "WARNING: %s, using the copy cached on %s\n", err, fi.ModTime().Format(time.RFC3339))
+				return stale, nil
+			}
+		}
+		return nil, err
+	}
+
+	if err = writeCacheFile(name, content); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: unable to cache %s: %s\n", url, err)
+	}
+	return content, nil
+}
+
+// writeCacheFile writes content to a temporary file renamed to name, so a
+// build stopped halfway never leaves a truncated copy behind.
+func writeCacheFile(name string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (r *remoteData) download(url string) ([]byte, error) {
+	client := r.client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Error fetching %s: %s", url, resp.Status)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %s", url, err)
+	}
+	return content, nil
+}
+
+// cacheFile is where the response of url is cached: Config.CacheDir, or
+// hugo_cache in the temporary directory.
+func (r *remoteData) cacheFile(url string) string {
+	dir := filepath.Join(os.TempDir(), "hugo_cache")
+	if r.s.Config.CacheDir != "" {
+		dir = r.s.Config.GetAbsPath(r.s.Config.CacheDir)
+	}
+	sum := md5.Sum([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,120 @@
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetJSONAndCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	must(ioutil.WriteFile(filepath.Join(dir, "prices.csv"), []byte("apple;1\npear;2\n"), 0644))
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/users/steve" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"name": "Steve", "repos": [{"name": "hugo"}]}`)
+	}))
+
+	s := &Site{Config: Config{Path: dir, CacheDir: "cache", CacheTTL: "1h"}}
+	s.prepTemplates()
+	must(s.addTemplate("remote.html", `{{ with getJSON .Url "/users/" .User }}{{ .name }}{{ range .repos }} {{ .name }}{{ end }}{{ end }}`))
+	must(s.addTemplate("csv.html", `{{ range getCSV ";" "prices.csv" }}{{ index . 0 }}={{ index . 1 }},{{ end }}`))
+	must(s.addTemplate("missing.html", `{{ getJSON .Url "/users/nobody" }}`))
+	data := map[string]string{"Url": server.URL, "User": "steve"}
+
+	render := func(name string) (string, error) {
+		out := new(bytes.Buffer)
+		err := s.Tmpl.ExecuteTemplate(out, name, data)
+		return out.String(), err
+	}
+	for i := 0; i < 2; i++ {
+		if out, err := render("remote.html"); err != nil || out != "Steve hugo" {
+			t.Errorf("Expected the remote JSON, got: %q, %v", out, err)
+		}
+	}
+	if out, err := render("csv.html"); err != nil || out != "apple=1,pear=2," {
+		t.Errorf("Expected the local CSV, got: %q, %v", out, err)
+	}
+	if _, err := render("missing.html"); err == nil {
+		t.Errorf("Expected an error for a missing resource")
+	}
+	if requests != 2 {
+		t.Errorf("Expected one request per url and build, got: %d", requests)
+	}
+
+	// The next build reads the cache, or the stale copy when it can't fetch.
+	s.remoteData.reset()
+	if out, _ := render("remote.html"); out != "Steve hugo" || requests != 2 {
+		t.Errorf("Expected the cached JSON without a request, got: %q after %d requests", out, requests)
+	}
+	server.Close()
+	s.remoteData.reset()
+	s.Config.CacheTTL = "1ns"
+	if out, err := render("remote.html"); err != nil || out != "Steve hugo" {
+		t.Errorf("Expected the stale JSON when the server is gone, got: %q, %v", out, err)
+	}
+}
+
+func TestGetJSONOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	requests := 0
+	release := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		<-release
+		fmt.Fprint(w, `{"name": "Steve"}`)
+	}))
+	defer server.Close()
+
+	s := &Site{Config: Config{Path: dir, CacheDir: "cache", CacheTTL: "1h"}}
+	s.prepTemplates()
+	must(s.addTemplate("remote.html", `{{ with getJSON . }}{{ .name }}{{ end }}`))
+
+	outs := make(chan string)
+	for i := 0; i < 4; i++ {
+		go func() {
+			out := new(bytes.Buffer)
+			s.Tmpl.ExecuteTemplate(out, "remote.html", server.URL+"/users/steve")
+			outs <- out.String()
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < 4; i++ {
+		if out := <-outs; out != "Steve" {
+			t.Errorf("Expected the remote JSON, got: %q", out)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request for a url read at once, got: %d", requests)
+	}
+
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "cache"))
+	if len(files) != 1 || files[0].Name() != filepath.Base(s.remoteData.cacheFile(server.URL+"/users/steve")) {
+		t.Errorf("Expected only the cached response in the cache, got: %v", files)
+	}
+}
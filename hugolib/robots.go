@@ -0,0 +1,89 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// indexedPages are the pages meant for search engines: the listed pages
+// without noindex (or private) in their frontmatter.
+func (s *Site) indexedPages() (pages Pages) {
+	for _, p := range s.Pages {
+		if p.Build.Render && !p.NoIndex {
+			pages = append(pages, p)
+		}
+	}
+	return
+}
+
+// RenderSitemap writes sitemap.xml with the "sitemap.xml" layout, or a
+// default one. .Data.Pages are the indexed pages.
+func (s *Site) RenderSitemap() error {
+	layout := s.findFirstLayout("sitemap.xml")
+	if layout == "" {
+		return nil
+	}
+	n := s.NewNode()
+	n.Title = s.Info.Title
+	n.Url = "sitemap.xml"
+	n.Permalink = permalink(s, n.Url)
+	n.Data["Pages"] = s.indexedPages()
+	return s.renderFile(n, n.Url, layout)
+}
+
+// RenderRobots writes robots.txt with the "robots.txt" layout, or a default
+// one pointing to the sitemap. .Data.Sitemap is its permalink if there is
+// one. The noindex pages aren't listed, which would advertise them; they
+// have a robots meta tag of their own instead.
+func (s *Site) RenderRobots() error {
+	layout := s.findFirstLayout("robots.txt")
+	if layout == "" {
+		return nil
+	}
+	n := s.NewNode()
+	n.Title = s.Info.Title
+	n.Url = "robots.txt"
+	n.Permalink = permalink(s, n.Url)
+	if s.findFirstLayout("sitemap.xml") != "" {
+		n.Data["Sitemap"] = permalink(s, "sitemap.xml")
+	}
+	return s.renderFile(n, n.Url, layout)
+}
+
+// renderFile writes d with layout to out as it is: neither transformed
+// like pages nor given a directory of its own with pretty urls.
+func (s *Site) renderFile(d interface{}, out, layout string) error {
+	buf := new(bytes.Buffer)
+	if err := s.Tmpl.ExecuteTemplate(buf, layout, d); err != nil {
+		return err
+	}
+	return s.publishFile(out, buf)
+}
+
+// publishFile writes r to path. Targets able to write files as they are
+// (see target.Filesystem.PublishFile) keep the name intact.
+func (s *Site) publishFile(path string, r io.Reader) error {
+	s.initTarget()
+	s.recordOutput(path, path)
+	if s.Config.Verbose {
+		fmt.Println(path)
+	}
+	if fp, ok := s.Target.(filePublisher); ok {
+		return fp.PublishFile(path, r)
+	}
+	return s.Target.Publish(path, r)
+}
@@ -0,0 +1,75 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"testing"
+)
+
+var robotsSources = []source.ByteSource{
+	{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-03\n---\n"), Section: "post"},
+	{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-02\nnoindex: true\n---\n"), Section: "post"},
+	{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-01\nprivate: true\n---\n"), Section: "post"},
+}
+
+func robotsSite(files map[string][]byte, layouts map[string]string) *Site {
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: robotsSources},
+		Config: Config{BaseUrl: "http://auth/"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	for name, layout := range layouts {
+		must(s.addTemplate(name, layout))
+	}
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderSitemap())
+	must(s.RenderRobots())
+	return s
+}
+
+func TestRobotsAndSitemap(t *testing.T) {
+	files := make(map[string][]byte)
+	robotsSite(files, map[string]string{"sitemap.xml": "{{ range .Data.Pages }}{{ .Permalink }} {{ end }}"})
+
+	expected := "User-agent: *\nSitemap: http://auth/sitemap.xml\n"
+	if string(files["robots.txt"]) != expected {
+		t.Errorf("Expected the default robots.txt %q, got: %q", expected, files["robots.txt"])
+	}
+	if string(files["sitemap.xml"]) != "http://auth/post/a " {
+		t.Errorf("Expected only the indexed page in the sitemap, got: %q", files["sitemap.xml"])
+	}
+}
+
+func TestRobotsLayoutAndDefaultSitemap(t *testing.T) {
+	files := make(map[string][]byte)
+	robotsSite(files, map[string]string{"robots.txt": "User-agent: *\nDisallow: /tmp/\n"})
+
+	expected := "User-agent: *\nDisallow: /tmp/\n"
+	if string(files["robots.txt"]) != expected {
+		t.Errorf("Expected the robots.txt layout %q, got: %q", expected, files["robots.txt"])
+	}
+	expected = "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n" +
+		"  <url>\n    <loc>http://auth/post/a</loc>\n    <lastmod>2013-01-03</lastmod>\n  </url>\n</urlset>\n"
+	if string(files["sitemap.xml"]) != expected {
+		t.Errorf("Expected the default sitemap %q, got: %q", expected, files["sitemap.xml"])
+	}
+}
+
+func TestNoIndexMeta(t *testing.T) {
+	files := make(map[string][]byte)
+	s := robotsSite(files, map[string]string{"_default/single.html": "<html><head></head><body>{{ .Title }}</body></html>"})
+	must(s.RenderPages())
+
+	for name, expected := range map[string]string{
+		"post/a.html": `<html><head></head><body>a</body></html>`,
+		"post/b.html": `<html><head><meta name="robots" content="noindex"></head><body>b</body></html>`,
+		"post/c.html": `<html><head><meta name="robots" content="noindex"></head><body>c</body></html>`,
+	} {
+		if got := string(files[name]); got != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, got)
+		}
+	}
+}
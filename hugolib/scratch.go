@@ -0,0 +1,161 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Scratch holds values templates set while they render, as the variables
+// of a template can't be changed from inside a range or with, e.g.
+//
+//	{{ range .Data.Pages }}{{ $.Scratch.Add "words" .WordCount }}{{ end }}
+//	{{ .Scratch.Get "words" }} words
+//
+// Set and the other setters return an empty string so they print nothing.
+type Scratch struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+func newScratch() *Scratch {
+	return &Scratch{values: make(map[string]interface{})}
+}
+
+// Set stores value under key, replacing what it held.
+func (s *Scratch) Set(key string, value interface{}) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return ""
+}
+
+// Get is the value stored under key, nil when there is none.
+func (s *Scratch) Get(key string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
+
+// Add adds value to the one stored under key, or stores it when there is
+// none.  Numbers are summed, strings joined and to a slice value is
+// appended, its elements when it is a slice itself.
+func (s *Scratch) Add(key string, value interface{}) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.values[key]
+	if !ok {
+		s.values[key] = value
+		return "", nil
+	}
+	sum, err := addValues(existing, value)
+	if err != nil {
+		return "", fmt.Errorf("Can't add to %s: %s", key, err)
+	}
+	s.values[key] = sum
+	return "", nil
+}
+
+// SetInMap stores value under mapKey in the map stored under key, which is
+// created when there is none.
+func (s *Scratch) SetInMap(key, mapKey string, value interface{}) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.values[key].(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+		s.values[key] = m
+	}
+	m[mapKey] = value
+	return ""
+}
+
+// GetSortedMapValues are the values of the map stored under key with
+// SetInMap, in the order of their keys, nil when there is no such map.
+func (s *Scratch) GetSortedMapValues(key string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.values[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return values
+}
+
+// addValues is a and b summed, joined or appended, see Scratch.Add.
+func addValues(a, b interface{}) (interface{}, error) {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Int() + bv.Int(), nil
+		case reflect.Float32, reflect.Float64:
+			return float64(av.Int()) + bv.Float(), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch bv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return av.Float() + float64(bv.Int()), nil
+		case reflect.Float32, reflect.Float64:
+			return av.Float() + bv.Float(), nil
+		}
+	case reflect.String:
+		if bv.Kind() == reflect.String {
+			return av.String() + bv.String(), nil
+		}
+	case reflect.Slice:
+		if bv.Kind() == reflect.Slice && bv.Type().AssignableTo(av.Type()) {
+			return reflect.AppendSlice(av, bv).Interface(), nil
+		}
+		if bv.IsValid() && bv.Type().AssignableTo(av.Type().Elem()) {
+			return reflect.Append(av, bv).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("can't add %v to %v", b, a)
+}
+
+// scratchMu guards the creation of the scratches of nodes rendered at once.
+var scratchMu sync.Mutex
+
+// Scratch is the scratch of the node, see Scratch.  Every page and list has
+// its own.
+func (n *Node) Scratch() *Scratch {
+	scratchMu.Lock()
+	defer scratchMu.Unlock()
+	if n.scratch == nil {
+		n.scratch = newScratch()
+	}
+	return n.scratch
+}
+
+// resetScratch empties the scratch of the node, so building it again
+// starts afresh.
+func (n *Node) resetScratch() {
+	scratchMu.Lock()
+	defer scratchMu.Unlock()
+	n.scratch = nil
+}
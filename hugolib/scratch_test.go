@@ -0,0 +1,118 @@
+package hugolib
+
+import (
+	"bytes"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScratch(t *testing.T) {
+	s := newScratch()
+	s.Set("title", "a")
+	for _, add := range []interface{}{"b", "c"} {
+		if _, err := s.Add("title", add); err != nil {
+			t.Fatalf("Unable to add %v: %s", add, err)
+		}
+	}
+	s.Add("count", 1)
+	s.Add("count", 2)
+	s.Add("ratio", 0.5)
+	s.Add("ratio", 1)
+	s.Add("list", []interface{}{"x"})
+	s.Add("list", "y")
+	s.Add("list", []interface{}{"z"})
+	if _, err := s.Add("title", 3); err == nil {
+		t.Errorf("Expected adding a number to a string to fail")
+	}
+
+	for key, expected := range map[string]interface{}{
+		"title":   "abc",
+		"count":   int64(3),
+		"ratio":   1.5,
+		"list":    []interface{}{"x", "y", "z"},
+		"missing": nil,
+	} {
+		if got := s.Get(key); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected %s to be %#v, got: %#v", key, expected, got)
+		}
+	}
+
+	s.SetInMap("authors", "smith", "Smith")
+	s.SetInMap("authors", "jones", "Jones")
+	s.SetInMap("authors", "brown", "Brown")
+	if got := s.GetSortedMapValues("authors"); !reflect.DeepEqual(got, []interface{}{"Brown", "Jones", "Smith"}) {
+		t.Errorf("Expected the authors sorted by key, got: %v", got)
+	}
+	if got := s.GetSortedMapValues("title"); got != nil {
+		t.Errorf("Expected no map values for a string, got: %v", got)
+	}
+}
+
+func TestScratchInTemplates(t *testing.T) {
+	s := new(Site)
+	s.prepTemplates()
+	must(s.addTemplate("words.html", `{{ range .Pages }}{{ $.Scratch.Add "words" .WordCount }}{{ end }}{{ .Scratch.Get "words" }}`))
+
+	var pages Pages
+	for _, content := range []string{"one two", "three four five"} {
+		p, err := ReadFrom(strings.NewReader("---\ntitle: A\n---\n"+content+"\n"), "a.md")
+		if err != nil {
+			t.Fatalf("Unable to create a page: %s", err)
+		}
+		pages = append(pages, p)
+	}
+	n := &Node{}
+	out := new(bytes.Buffer)
+	must(s.Tmpl.ExecuteTemplate(out, "words.html", struct {
+		*Node
+		Pages Pages
+	}{n, pages}))
+	if out.String() != "5" {
+		t.Errorf("Expected the words of the pages added up, got: %q", out.String())
+	}
+}
+
+func TestScratchRenderedAgain(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ .Scratch.Add "n" 1 }}{{ .Scratch.Get "n" }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.Render())
+	must(s.Render())
+
+	if got := string(files["post/a.html"]); got != HTML("1") {
+		t.Errorf("Expected the scratch to start afresh, got: %q", got)
+	}
+}
+
+func TestScratchSetByShortcode(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n{{% mark %}}"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("shortcodes/mark.html", `{{ .Page.Scratch.Set "marked" "yes" }}`))
+	must(s.addTemplate("_default/single.html", `marked: {{ .Scratch.Get "marked" }}`))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.Render())
+
+	if got := string(files["post/a.html"]); got != HTML("marked: yes") {
+		t.Errorf("Expected the layout to see what the shortcode set, got: %q", got)
+	}
+}
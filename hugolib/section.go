@@ -0,0 +1,84 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	helpers "github.com/spf13/hugo/template"
+	"html/template"
+	"sort"
+	"time"
+)
+
+// Section describes a content section and the list page rendered for it.
+type Section struct {
+	Name      string
+	Title     string
+	Url       string
+	Permalink template.HTML
+	RSSLink   template.HTML
+	Pages     Pages // in the order of the section's ListOptions
+	// Date of the newest and oldest page in the section.
+	Date, FirstDate time.Time
+	options         ListOptions
+}
+
+func (s *Section) Count() int { return len(s.Pages) }
+
+// Sections is a list of sections sorted by name.
+type Sections []*Section
+
+func (s Sections) Len() int           { return len(s) }
+func (s Sections) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s Sections) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// Get returns the named section or nil.
+func (s Sections) Get(name string) *Section {
+	for _, section := range s {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// buildSections describes every section in s.Sections, whose pages must
+// already be sorted by date.
+func (s *Site) buildSections() (sections Sections, err error) {
+	for name, pages := range s.Sections {
+		if len(pages) == 0 {
+			continue
+		}
+		options, err := s.sectionOptions(name)
+		if err != nil {
+			return nil, err
+		}
+		url := helpers.Urlize(name + "/" + "index.html")
+		section := &Section{
+			Name:      name,
+			Title:     s.Info.title(s.Config.pluralize(name)),
+			Url:       url,
+			Permalink: permalink(s, url),
+			Pages:     options.sortPages(pages),
+			Date:      pages[0].Date,
+			FirstDate: pages[len(pages)-1].Date,
+			options:   options,
+		}
+		if !s.contentType(name).NoFeed {
+			section.RSSLink = permalink(s, s.feedPath(name))
+		}
+		sections = append(sections, section)
+	}
+	sort.Sort(sections)
+	return
+}
@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"testing"
+)
+
+var sectionsFakeSource = []source.ByteSource{
+	{Name: "content/blue/doc1.md", Content: []byte("---\ntitle: one\ndate: 2013-01-01\n---\n"), Section: "blue"},
+	{Name: "content/blue/doc2.md", Content: []byte("---\ntitle: two\ndate: 2013-03-01\n---\n"), Section: "blue"},
+	{Name: "content/about/me.md", Content: []byte("---\ntitle: me\ndate: 2013-02-01\n---\n"), Section: "about"},
+}
+
+func TestSiteSections(t *testing.T) {
+	s := &Site{
+		Config: Config{BaseUrl: "http://auth/bub/"},
+		Source: &source.InMemorySource{ByteSource: sectionsFakeSource},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	sections := s.Info.Sections
+	if len(sections) != 2 || sections[0].Name != "about" || sections[1].Name != "blue" {
+		t.Fatalf("Unexpected sections: %v", sections)
+	}
+
+	blue := sections.Get("blue")
+	if blue.Count() != 2 || blue.Title != "Blues" {
+		t.Errorf("Unexpected blue section: %v", blue)
+	}
+	if blue.Date.Month() != 3 || blue.FirstDate.Month() != 1 {
+		t.Errorf("Unexpected blue dates: %s %s", blue.Date, blue.FirstDate)
+	}
+	if blue.Permalink != "http://auth/bub/blue/index.html" {
+		t.Errorf("Unexpected blue permalink: %s", blue.Permalink)
+	}
+	if sections.Get("missing") != nil {
+		t.Errorf("Expected no section for a missing name")
+	}
+	if len(s.Pages[0].Site.Sections) != 2 {
+		t.Errorf("Pages should see the site sections")
+	}
+}
+
+func TestSectionPlurals(t *testing.T) {
+	s := &Site{
+		Config: Config{BaseUrl: "http://auth/bub/", Plurals: map[string]string{"about": "about", "blue": "bluez"}},
+		Source: &source.InMemorySource{ByteSource: sectionsFakeSource},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	if title := s.Info.Sections.Get("about").Title; title != "About" {
+		t.Errorf("Expected the plural of about to be overridden, got: %s", title)
+	}
+	if title := s.Info.Sections.Get("blue").Title; title != "Bluez" {
+		t.Errorf("Expected the plural of blue to be overridden, got: %s", title)
+	}
+
+	c := Config{Plurals: map[string]string{"documentation": "documentation"}}
+	for _, test := range []struct{ singular, plural string }{
+		{"documentation", "documentation"},
+		{"Documentation", "documentation"},
+		{"post", "posts"},
+	} {
+		if plural := c.pluralize(test.singular); plural != test.plural {
+			t.Errorf("Expected the plural of %s to be %s, got: %s", test.singular, test.plural, plural)
+		}
+	}
+}
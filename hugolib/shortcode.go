@@ -17,8 +17,14 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/spf13/hugo/template/bundle"
+	"github.com/spf13/hugo/transform"
+	"html"
+	"html/template"
+	"os"
+	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 var _ = fmt.Println
@@ -33,86 +39,426 @@ type Shortcode struct {
 type ShortcodeWithPage struct {
 	Params interface{}
 	Page   *Page
+	// Inner is the content between the opening and the closing tags of
+	// the shortcode, rendered as markdown for {{% %}} shortcodes.
+	Inner template.HTML
+}
+
+// Get returns a parameter by position when key is an int, or by name
+// when key is a string. Missing parameters are returned as "".
+func (s *ShortcodeWithPage) Get(key interface{}) interface{} {
+	switch params := s.Params.(type) {
+	case []string:
+		if i, ok := key.(int); ok && i >= 0 && i < len(params) {
+			return params[i]
+		}
+	case map[string]string:
+		if k, ok := key.(string); ok {
+			return params[k]
+		}
+	}
+	return ""
 }
 
 type Shortcodes map[string]ShortcodeFunc
 
+// shortcodeDelims are the opening and closing delimiters of a shortcode.
+// The output of {{% %}} shortcodes is markdown, that of {{< >}} ones html.
+// Content that isn't markdown has its shortcodes handled once it is html,
+// with the angle brackets escaped.
+var shortcodeDelims = [][2]string{
+	{"{{%", "%}}"},
+	{"{{<", ">}}"},
+	{"{{&lt;", "&gt;}}"},
+}
+
+// shortcodeTag is the opening or the closing tag of a shortcode.
+type shortcodeTag struct {
+	start, end int
+	text       string // between the delimiters
+	delims     [2]string
+}
+
+// nextShortcodeTag finds the first tag in s, starting at from.
+func nextShortcodeTag(s string, from int) (tag shortcodeTag, ok bool) {
+	for _, d := range shortcodeDelims {
+		i := strings.Index(s[from:], d[0])
+		if i < 0 || (ok && from+i >= tag.start) {
+			continue
+		}
+		start := from + i
+		j := strings.Index(s[start+len(d[0]):], d[1])
+		if j < 0 {
+			continue
+		}
+		text := s[start+len(d[0]) : start+len(d[0])+j]
+		tag = shortcodeTag{start: start, end: start + len(d[0]) + j + len(d[1]), text: text, delims: d}
+		ok = true
+	}
+	return
+}
+
+// shortcodeCall is a use of a shortcode. The content between its opening
+// tag and its closing one, if it has any, is parsed into inner, so
+// shortcodes nest.
+type shortcodeCall struct {
+	name     string
+	params   interface{}
+	markdown bool
+	inner    []shortcodeItem // nil without a closing tag
+	pos      int             // of the opening tag in the content
+	source   string          // the call, closing tag included
+	err      error
+}
+
+// shortcodeItem is a piece of content, text or a shortcode.
+type shortcodeItem struct {
+	text string
+	call *shortcodeCall
+}
+
+// parseShortcodes splits s, found at offset in the content, into text and
+// the shortcodes used in it.
+func parseShortcodes(s string, offset int) []shortcodeItem {
+	var items []shortcodeItem
+	for {
+		open, ok := nextShortcodeTag(s, 0)
+		if !ok {
+			break
+		}
+		if open.start > 0 {
+			items = append(items, shortcodeItem{text: s[:open.start]})
+		}
+
+		call := &shortcodeCall{markdown: open.delims[0] == "{{%", pos: offset + open.start}
+		var par string
+		call.name, par = SplitParams(open.text)
+		end := open.end
+		if strings.HasPrefix(call.name, "/") {
+			call.err = fmt.Errorf("Closing tag without an opening one")
+		} else if call.params, call.err = Tokenize(par); call.err == nil {
+			if close, ok := closingTag(s, open, call.name); ok {
+				call.inner = parseShortcodes(s[open.end:close.start], offset+open.end)
+				if call.inner == nil {
+					call.inner = []shortcodeItem{}
+				}
+				end = close.end
+			}
+		}
+		call.source = s[open.start:end]
+
+		items = append(items, shortcodeItem{call: call})
+		s, offset = s[end:], offset+end
+	}
+	if s != "" {
+		items = append(items, shortcodeItem{text: s})
+	}
+	return items
+}
+
+// closingTag finds the tag {{< /name >}} closing open, with the same
+// delimiters, skipping the shortcodes of the same name nested in it.
+func closingTag(s string, open shortcodeTag, name string) (shortcodeTag, bool) {
+	depth := 0
+	for tag, ok := nextShortcodeTag(s, open.end); ok; tag, ok = nextShortcodeTag(s, tag.end) {
+		if tag.delims != open.delims {
+			continue
+		}
+		switch n, _ := SplitParams(tag.text); n {
+		case name:
+			depth++
+		case "/" + name:
+			if depth == 0 {
+				return tag, true
+			}
+			depth--
+		}
+	}
+	return shortcodeTag{}, false
+}
+
+// ShortcodeError is a shortcode of a page failing to parse or to render.
+type ShortcodeError struct {
+	File      string
+	Line, Col int // of the opening tag in the file, 0 when unknown
+	Name      string
+	Err       error
+}
+
+func (e *ShortcodeError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: shortcode %q: %s", e.File, e.Name, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: shortcode %q: %s", e.File, e.Line, e.Col, e.Name, e.Err)
+}
+
+// ShortcodesHandle replaces the shortcodes used in stringToParse by their
+// output, warning about those failing.
 func ShortcodesHandle(stringToParse string, p *Page, t bundle.Template) string {
-	posStart := strings.Index(stringToParse, "{{%")
-	if posStart > 0 {
-		posEnd := strings.Index(stringToParse[posStart:], "%}}") + posStart
-		if posEnd > posStart {
-			name, par := SplitParams(stringToParse[posStart+3 : posEnd])
-			params := Tokenize(par)
-			var data = &ShortcodeWithPage{Params: params, Page: p}
-			newString := stringToParse[:posStart] + ShortcodeRender(name, data, t) + ShortcodesHandle(stringToParse[posEnd+3:], p, t)
-			return newString
+	out, _, errs := expandShortcodes(stringToParse, p, t, false)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
+	}
+	return out
+}
+
+// expandShortcodes replaces the shortcodes used in content by their output.
+// When content is markdown, the html of {{< >}} shortcodes is swapped for
+// placeholders markdown leaves alone, returned in their order for
+// restoreShortcodes. The shortcodes failing are left as they are and
+// returned as errors.
+func expandShortcodes(content string, p *Page, t bundle.Template, markdown bool) (string, []string, []error) {
+	items := parseShortcodes(content, 0)
+	r := &shortcodeRenderer{p: p, t: t, markdown: markdown}
+	if content != p.RawMarkdown {
+		r.positions = sourcePositions(items, parseShortcodes(p.RawMarkdown, 0))
+	}
+	out, spans := r.render(items, markdown)
+	return out, spans, r.errs
+}
+
+// sourcePositions maps the shortcodes of rendered content, html escaped or
+// cleaned, to their positions in the content as written, pairing them in
+// order for as long as their names match.
+func sourcePositions(rendered, source []shortcodeItem) map[*shortcodeCall]int {
+	from, to := flattenShortcodes(rendered, nil), flattenShortcodes(source, nil)
+	positions := make(map[*shortcodeCall]int)
+	for i := 0; i < len(from) && i < len(to) && from[i].name == to[i].name; i++ {
+		positions[from[i]] = to[i].pos
+	}
+	return positions
+}
+
+// flattenShortcodes appends the calls of items, and those nested in them,
+// in the order they are written.
+func flattenShortcodes(items []shortcodeItem, calls []*shortcodeCall) []*shortcodeCall {
+	for _, item := range items {
+		if item.call != nil {
+			calls = flattenShortcodes(item.call.inner, append(calls, item.call))
 		}
 	}
-	return stringToParse
+	return calls
 }
 
-func StripShortcodes(stringToParse string) string {
-	posStart := strings.Index(stringToParse, "{{%")
-	if posStart > 0 {
-		posEnd := strings.Index(stringToParse[posStart:], "%}}") + posStart
-		if posEnd > posStart {
-			newString := stringToParse[:posStart] + StripShortcodes(stringToParse[posEnd+3:])
-			return newString
+// shortcodeRenderer renders the shortcodes used in the content of a page.
+type shortcodeRenderer struct {
+	p         *Page
+	t         bundle.Template
+	markdown  bool                   // the content is markdown
+	positions map[*shortcodeCall]int // in RawMarkdown, nil when the content is RawMarkdown
+	errs      []error
+}
+
+// fail records the error of call.
+func (r *shortcodeRenderer) fail(call *shortcodeCall, err error) {
+	e := &ShortcodeError{File: r.p.FileName, Name: call.name, Err: err}
+	pos, ok := call.pos, true
+	if r.positions != nil {
+		pos, ok = r.positions[call]
+	}
+	if ok && pos <= len(r.p.RawMarkdown) {
+		before := r.p.RawMarkdown[:pos]
+		line := before[strings.LastIndex(before, "\n")+1:]
+		e.Line = r.p.contentLine + strings.Count(before, "\n") + 1
+		e.Col = utf8.RuneCountInString(line) + 1
+	}
+	r.errs = append(r.errs, e)
+}
+
+// render renders items, with placeholders for the output of {{< >}}
+// shortcodes when protect is set.
+func (r *shortcodeRenderer) render(items []shortcodeItem, protect bool) (string, []string) {
+	var (
+		out   = new(bytes.Buffer)
+		spans []string
+	)
+
+	for _, item := range items {
+		call := item.call
+		if call == nil {
+			out.WriteString(item.text)
+			continue
+		}
+		if call.err != nil {
+			r.fail(call, call.err)
+			out.WriteString(call.source)
+			continue
+		}
+
+		data := &ShortcodeWithPage{Params: call.params, Page: r.p, Inner: r.inner(call)}
+		rendered, err := ShortcodeRender(call.name, data, r.t)
+		if err != nil {
+			r.fail(call, err)
+			out.WriteString(call.source)
+			continue
+		}
+		if protect && !call.markdown {
+			out.Write(shortcodePlaceholder(len(spans)))
+			spans = append(spans, rendered)
+		} else {
+			out.WriteString(rendered)
 		}
 	}
-	return stringToParse
+	return out.String(), spans
 }
 
-func Tokenize(in string) interface{} {
-	first := strings.Fields(in)
-	var final = make([]string, 0)
-	var keys = make([]string, 0)
-	inQuote := false
-	start := 0
+// inner renders the content call wraps, shortcodes included. In markdown
+// content the inner content of {{% %}} shortcodes is rendered as markdown.
+func (r *shortcodeRenderer) inner(call *shortcodeCall) template.HTML {
+	if !r.markdown || !call.markdown {
+		inner, _ := r.render(call.inner, false)
+		return template.HTML(inner)
+	}
+	inner, spans := r.render(call.inner, true)
+	return template.HTML(restoreShortcodes(r.p.renderInner(inner), spans))
+}
 
-	for i, v := range first {
-		index := strings.Index(v, "=")
+// renderInner renders the inner content of a shortcode as markdown. Inner
+// content within a line is rendered inline, without a paragraph.
+func (p *Page) renderInner(inner string) string {
+	rendered := string(p.renderMarkdown([]byte(inner)))
+	if strings.Contains(inner, "\n") {
+		return rendered
+	}
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(rendered, "<p>"), "</p>\n")
+	if len(trimmed) == len(rendered)-len("<p></p>\n") && !strings.Contains(trimmed, "<p>") {
+		return trimmed
+	}
+	return rendered
+}
 
-		if !inQuote {
-			if index > 1 {
-				keys = append(keys, v[:index])
-				v = v[index+1:]
-			}
+func shortcodePlaceholder(i int) []byte {
+	return []byte(fmt.Sprintf("HUGOSHORTCODE%dEDOCTROHSOGUH", i))
+}
+
+var shortcodePlaceholderRe = regexp.MustCompile("HUGOSHORTCODE[0-9]+EDOCTROHSOGUH")
+
+// restoreShortcodes puts the html taken out by expandShortcodes back into
+// the rendered content, dropping the paragraph markdown put around a
+// shortcode standing on its own.
+func restoreShortcodes(rendered string, spans []string) string {
+	for i, span := range spans {
+		placeholder := string(shortcodePlaceholder(i))
+		rendered = strings.Replace(rendered, "<p>"+placeholder+"</p>", span, -1)
+		rendered = strings.Replace(rendered, placeholder, span, -1)
+	}
+	return rendered
+}
+
+// renderShortcodes renders the markdown of p again, with the output of the
+// shortcodes it uses, returning the errors of those failing. The content is
+// cleaned with policy, when there is one, before the html of the {{< >}}
+// shortcodes, which comes from the templates, is put back.
+func (p *Page) renderShortcodes(t bundle.Template, policy *transform.Sanitize) []error {
+	if _, ok := nextShortcodeTag(p.RawMarkdown, 0); !ok {
+		return nil
+	}
+	content, spans, errs := expandShortcodes(p.RawMarkdown, p, t, true)
+	p.convertMarkdown(strings.NewReader(content))
+	if policy != nil {
+		p.sanitize(policy)
+	}
+	p.Content = template.HTML(restoreShortcodes(string(p.Content), spans))
+	p.Summary = template.HTML(restoreShortcodes(string(p.Summary), spans))
+	return errs
+}
+
+// StripShortcodes removes the shortcodes, and the placeholders of the
+// shortcodes taken out of markdown, from stringToParse.
+func StripShortcodes(stringToParse string) string {
+	stringToParse = shortcodePlaceholderRe.ReplaceAllString(stringToParse, "")
+	tag, ok := nextShortcodeTag(stringToParse, 0)
+	if !ok {
+		return stringToParse
+	}
+	return stringToParse[:tag.start] + StripShortcodes(stringToParse[tag.end:])
+}
+
+// quoteEntities turns the quotes markdown made typographic back into the
+// ones the author typed.
+var quoteEntities = strings.NewReplacer("\u201c", `"`, "\u201d", `"`, "\u2018", "'", "\u2019", "'")
+
+// Tokenize parses shortcode parameters, returning a []string of positional
+// parameters or a map[string]string of name="value" pairs. Values may be
+// quoted with " or ', and a backslash escapes the next character.
+func Tokenize(in string) (interface{}, error) {
+	in = quoteEntities.Replace(html.UnescapeString(in))
+	positional := make([]string, 0)
+	named := make(map[string]string)
+
+	for i := 0; i < len(in); {
+		r, w := utf8.DecodeRuneInString(in[i:])
+		if unicode.IsSpace(r) {
+			i += w
+			continue
 		}
 
-		if !strings.HasPrefix(v, "&ldquo;") && !inQuote {
-			final = append(final, v)
-		} else if inQuote && strings.HasSuffix(v, "&rdquo;") && !strings.HasSuffix(v, "\\\"") {
-			first[i] = v[:len(v)-7]
-			final = append(final, strings.Join(first[start:i+1], " "))
-			inQuote = false
-		} else if strings.HasPrefix(v, "&ldquo;") && !inQuote {
-			if strings.HasSuffix(v, "&rdquo;") {
-				final = append(final, v[7:len(v)-7])
-			} else {
-				start = i
-				first[i] = v[7:]
-				inQuote = true
-			}
+		key := ""
+		if eq := strings.IndexFunc(in[i:], func(r rune) bool {
+			return r == '=' || r == '"' || r == '\'' || unicode.IsSpace(r)
+		}); eq > 0 && in[i+eq] == '=' {
+			key = in[i : i+eq]
+			i += eq + 1
+		}
+
+		value, n, err := lexValue(in[i:])
+		if err != nil {
+			return nil, err
 		}
+		i += n
 
-		// No closing "... just make remainder the final token
-		if inQuote && i == len(first) {
-			final = append(final, first[start:len(first)]...)
+		if key == "" {
+			positional = append(positional, value)
+		} else {
+			if _, ok := named[key]; ok {
+				return nil, fmt.Errorf("Parameter %q given twice", key)
+			}
+			named[key] = value
 		}
 	}
 
-	if len(keys) > 0 {
-		var m = make(map[string]string)
-		for i, k := range keys {
-			m[k] = final[i]
+	if len(named) > 0 {
+		if len(positional) > 0 {
+			return nil, fmt.Errorf("Named and positional parameters can't be mixed")
 		}
+		return named, nil
+	}
+	return positional, nil
+}
 
-		return m
+// lexValue reads one parameter value from the start of in, returning it
+// unquoted along with the number of bytes it took up.
+func lexValue(in string) (string, int, error) {
+	var value bytes.Buffer
+	quote := byte(0)
+	if len(in) > 0 && (in[0] == '"' || in[0] == '\'') {
+		quote = in[0]
 	}
 
-	return final
+	i := 0
+	if quote != 0 {
+		i++
+	}
+	for i < len(in) {
+		c := in[i]
+		switch {
+		case c == '\\' && i+1 < len(in):
+			value.WriteByte(in[i+1])
+			i += 2
+			continue
+		case quote != 0 && c == quote:
+			return value.String(), i + 1, nil
+		case quote == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			return value.String(), i, nil
+		}
+		value.WriteByte(c)
+		i++
+	}
+
+	if quote != 0 {
+		return "", 0, fmt.Errorf("Unterminated %c in %q", quote, in)
+	}
+	return value.String(), i, nil
 }
 
 func SplitParams(in string) (name string, par2 string) {
@@ -124,8 +470,32 @@ func SplitParams(in string) (name string, par2 string) {
 	return strings.TrimSpace(in[:i+1]), strings.TrimSpace(in[i+1:])
 }
 
-func ShortcodeRender(name string, data *ShortcodeWithPage, t bundle.Template) string {
+// ShortcodeRender executes the template of the shortcode name. A template
+// panicking, e.g. calling a method on a missing resource, is an error too.
+func ShortcodeRender(name string, data *ShortcodeWithPage, t bundle.Template) (out string, err error) {
+	layout := shortcodeTemplate(name, t)
+	if t.Lookup(layout) == nil {
+		return "", fmt.Errorf("No template %s", layout)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
 	buffer := new(bytes.Buffer)
-	t.ExecuteTemplate(buffer, "shortcodes/"+name+".html", data)
-	return buffer.String()
+	if err = t.ExecuteTemplate(buffer, layout, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// shortcodeTemplate is the template of the shortcode name, the one of the
+// site or its theme, else the built in one.
+func shortcodeTemplate(name string, t bundle.Template) string {
+	layout := "shortcodes/" + name + ".html"
+	if t.Lookup(layout) == nil && t.Lookup(internalPrefix+layout) != nil {
+		return internalPrefix + layout
+	}
+	return layout
 }
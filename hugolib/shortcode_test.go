@@ -0,0 +1,304 @@
+package hugolib
+
+import (
+	"bytes"
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"github.com/spf13/hugo/template/bundle"
+	"image"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected interface{}
+	}{
+		{``, []string{}},
+		{`a.png x`, []string{"a.png", "x"}},
+		{`"a b" 'c d'`, []string{"a b", "c d"}},
+		{`src="a.png" alt="x"`, map[string]string{"src": "a.png", "alt": "x"}},
+		{`alt=&ldquo;it&rsquo;s&rdquo;`, map[string]string{"alt": "it's"}},
+		{`t="say \"hi\"" u='a\'b'`, map[string]string{"t": `say "hi"`, "u": "a'b"}},
+		{`a\ b c&amp;d`, []string{"a b", "c&d"}},
+		{`eq="a=b"`, map[string]string{"eq": "a=b"}},
+	}
+
+	for _, test := range tests {
+		got, err := Tokenize(test.in)
+		if err != nil {
+			t.Errorf("Tokenize(%q) failed: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Tokenize(%q) expected %#v, got %#v", test.in, test.expected, got)
+		}
+	}
+
+	for _, in := range []string{`src="a.png`, `a src="b"`, `a="b" a="c"`} {
+		if _, err := Tokenize(in); err == nil {
+			t.Errorf("Tokenize(%q) expected an error", in)
+		}
+	}
+}
+
+func TestShortcodeGet(t *testing.T) {
+	tem := bundle.NewTemplate()
+	tem.AddTemplate("shortcodes/img.html", `<img src="{{ .Get "src" }}{{ .Get 0 }}" alt="{{ .Get "alt" }}{{ .Get 1 }}">`)
+	p := &Page{File: File{FileName: "a.md"}}
+
+	for in, expected := range map[string]string{
+		`<p>{{&lt; img src=&ldquo;a.png&rdquo; alt=&ldquo;x&rdquo; &gt;}}</p>`: `<p><img src="a.png" alt="x"></p>`,
+		`<p>{{&lt; img a.png x &gt;}}</p>`:                                     `<p><img src="a.png" alt="x"></p>`,
+		`{{< img a.png >}} and {{% img b.png "y z" %}}`:                        `<img src="a.png" alt=""> and <img src="b.png" alt="y z">`,
+	} {
+		if got := ShortcodesHandle(in, p, tem); got != expected {
+			t.Errorf("ShortcodesHandle(%q) expected %q, got %q", in, expected, got)
+		}
+	}
+
+	if got := StripShortcodes(`a {{< img a.png >}}b{{% img %}}`); got != "a b" {
+		t.Errorf("StripShortcodes expected %q, got %q", "a b", got)
+	}
+}
+
+func TestMarkdownShortcodes(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte(`---
+title: a
+---
+Some {{% em %}}*loud*{{% /em %}} words, {{% link "http://auth/" %}}.
+
+{{< box >}}*raw*{{< /box >}}
+
+{{% note %}}
+A *note*.
+{{% /note %}}
+`), Section: "post"},
+			{Name: "post/b.html", Content: []byte("---\ntitle: b\n---\n<p>{{% em %}}<b>x</b>{{% /em %}}</p>"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+	must(s.addTemplate("shortcodes/em.html", "<em>{{ .Inner }}</em>"))
+	must(s.addTemplate("shortcodes/link.html", "[home]({{ .Get 0 }})"))
+	must(s.addTemplate("shortcodes/box.html", `<div class="box">{{ .Inner }}</div>`))
+	must(s.addTemplate("shortcodes/note.html", "<aside>\n{{ .Inner }}</aside>"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.ProcessShortcodes())
+	must(s.RenderPages())
+
+	for name, expected := range map[string]string{
+		"post/a.html": HTML(`<p>Some <em><em>loud</em></em> words, <a href="http://auth/">home</a>.</p>
+
+<div class="box">*raw*</div>
+
+<aside>
+<p>A <em>note</em>.</p>
+</aside>
+`),
+		"post/b.html": HTML("<p><em><b>x</b></em></p>"),
+	} {
+		if got := string(files[name]); got != expected {
+			t.Errorf("%s expected:\n%q\ngot:\n%q", name, expected, got)
+		}
+	}
+}
+
+func TestNestedShortcodes(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte(`---
+title: a
+---
+{{< tabs >}}{{< tab "A" >}}{{% col %}}*x*{{% /col %}}{{< /tab >}}{{< tab "B" >}}b{{< /tab >}}{{< /tabs >}}
+
+{{< box >}}a{{< box >}}b{{< /box >}}{{< img >}}{{< /box >}}
+
+{{% note %}}
+A {{< img >}} in a *note*.
+{{% /note %}}
+`), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+	must(s.addTemplate("shortcodes/tabs.html", `<div class="tabs">{{ .Inner }}</div>`))
+	must(s.addTemplate("shortcodes/tab.html", `<section title="{{ .Get 0 }}">{{ .Inner }}</section>`))
+	must(s.addTemplate("shortcodes/col.html", `<div class="col">{{ .Inner }}</div>`))
+	must(s.addTemplate("shortcodes/box.html", "[{{ .Inner }}]"))
+	must(s.addTemplate("shortcodes/img.html", "<b>i</b>"))
+	must(s.addTemplate("shortcodes/note.html", "<aside>\n{{ .Inner }}</aside>"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.ProcessShortcodes())
+	must(s.RenderPages())
+
+	expected := HTML(`<div class="tabs"><section title="A"><div class="col"><em>x</em></div></section><section title="B">b</section></div>
+
+[a[b]<b>i</b>]
+
+<aside>
+<p>A <b>i</b> in a <em>note</em>.</p>
+</aside>
+`)
+	if got := string(files["post/a.html"]); got != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, got)
+	}
+}
+
+func TestParseShortcodes(t *testing.T) {
+	items := parseShortcodes("a {{< x >}}{{< x 1 >}}{{< /x >}}{{< /x >}} {{< y >}} {{< /z >}}", 0)
+	if len(items) != 6 {
+		t.Fatalf("Expected 6 items, got %d: %v", len(items), items)
+	}
+	outer := items[1].call
+	if outer == nil || outer.name != "x" || outer.pos != 2 || len(outer.inner) != 1 {
+		t.Fatalf("Expected x wrapping a shortcode, got %+v", outer)
+	}
+	if inner := outer.inner[0].call; inner == nil || inner.pos != 11 || inner.inner == nil || len(inner.inner) != 0 {
+		t.Errorf("Expected the nested x to be closed and empty, got %+v", inner)
+	}
+	if y := items[3].call; y == nil || y.name != "y" || y.inner != nil {
+		t.Errorf("Expected y on its own, got %+v", y)
+	}
+	if z := items[5].call; z == nil || z.err == nil {
+		t.Errorf("Expected a stray closing tag to be an error, got %+v", z)
+	}
+}
+
+func TestBuiltinShortcodes(t *testing.T) {
+	var img bytes.Buffer
+	must(png.Encode(&img, image.NewGray(image.Rect(0, 0, 5, 2))))
+
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/trip/index.md", Content: []byte(`---
+title: trip
+---
+{{< figure src="a.png" title="A" link="/big.png" >}}
+
+{{% figure src="/b.jpg" alt="b" width="10" class="wide" %}}
+
+{{< youtube 09jf3ow9jfw >}}
+
+{{< vimeo 1234 >}}
+
+{{< gist spf13 7896402 img.html >}}
+
+{{< tweet 666616452582129664 >}}
+`), Section: "trip"},
+			{Name: "post/trip/a.png", Content: img.Bytes(), Section: "trip"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+	must(s.addTemplate("shortcodes/vimeo.html", "<p>vimeo {{ .Get 0 }}</p>"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.ProcessShortcodes())
+	must(s.RenderPages())
+
+	content := string(files["post/trip.html"])
+	for _, expected := range []string{
+		"<figure>\n<a href=\"/big.png\"><img src=\"/post/trip/a.png\" width=\"5\" height=\"2\"/></a>",
+		"<figcaption><h4>A</h4></figcaption>",
+		"<figure class=\"wide\">\n<img src=\"/b.jpg\" alt=\"b\" width=\"10\"/>\n</figure>",
+		`src="//www.youtube.com/embed/09jf3ow9jfw"`,
+		"<p>vimeo 1234</p>",
+		`<script src="https://gist.github.com/spf13/7896402.js?file=img.html"></script>`,
+		`<a href="https://twitter.com/twitter/status/666616452582129664"></a>`,
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", expected, content)
+		}
+	}
+}
+
+func TestShortcodeErrors(t *testing.T) {
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: make(map[string][]byte)},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\nSome text.\n\nSee {{< missing x >}} and {{% em %}}\n  {{% fail %}}{{% /em %}}\n"), Section: "post"},
+			{Name: "post/b.html", Content: []byte("---\ntitle: b\n---\n<p>é {{< fail >}} {{< /em >}}</p>"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+	must(s.addTemplate("shortcodes/em.html", "<em>{{ .Inner }}</em>"))
+	must(s.addTemplate("shortcodes/fail.html", "{{ .Page.Nothing }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	err := s.ProcessShortcodes()
+	if _, ok := err.(*ShortcodeError); !ok {
+		t.Fatalf("Expected a shortcode error to stop the build, got %v", err)
+	}
+
+	s.Config.LenientShortcodes = true
+	if err := s.ProcessShortcodes(); err != nil {
+		t.Fatalf("Expected shortcode errors to be warnings, got %s", err)
+	}
+
+	expected := map[string][]string{
+		"post/a.md": {
+			`post/a.md:6:5: shortcode "missing": No template shortcodes/missing.html`,
+			`post/a.md:7:3: shortcode "fail": `,
+		},
+		"post/b.html": {
+			`post/b.html:4:6: shortcode "fail": `,
+			`post/b.html:4:19: shortcode "/em": Closing tag without an opening one`,
+		},
+	}
+	for _, p := range s.Pages {
+		errs := s.renderPageShortcodes(p)
+		if len(errs) != len(expected[p.FileName]) {
+			t.Fatalf("%s expected %d errors, got %v", p.FileName, len(expected[p.FileName]), errs)
+		}
+		for i, err := range errs {
+			if !strings.HasPrefix(err.Error(), expected[p.FileName][i]) {
+				t.Errorf("%s expected error %q, got %q", p.FileName, expected[p.FileName][i], err)
+			}
+		}
+	}
+	left := map[string]string{"post/a.md": "{{&lt; missing x &gt;}}", "post/b.html": "{{< fail >}}"}
+	for _, p := range s.Pages {
+		if !strings.Contains(string(p.Content), left[p.FileName]) {
+			t.Errorf("Expected the failing shortcode to be left in %s, got %q", p.FileName, p.Content)
+		}
+	}
+}
+
+func TestShortcodeErrorPositions(t *testing.T) {
+	s := &Site{
+		Config: Config{Sanitize: true},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/c.html", Content: []byte("---\ntitle: c\n---\n<script>\nx\n</script><p>{{< em >}}{{< /em >}}</p>\n<p>{{< fail >}}</p>"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("shortcodes/em.html", "<em>{{ .Inner }}</em>"))
+	must(s.addTemplate("shortcodes/fail.html", "{{ .Page.Nothing }}"))
+	must(s.CreatePages())
+
+	errs := s.renderPageShortcodes(s.Pages[0])
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), `post/c.html:7:4: shortcode "fail": `) {
+		t.Errorf("Expected the error of fail at its position in the file, got %v", errs)
+	}
+}
@@ -14,9 +14,9 @@
 package hugolib
 
 import (
-	"bitbucket.org/pkg/inflect"
 	"bytes"
 	"fmt"
+	"github.com/spf13/hugo/parser"
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/hugo/target"
 	helpers "github.com/spf13/hugo/template"
@@ -25,8 +25,13 @@ import (
 	"github.com/spf13/nitro"
 	"html/template"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -55,28 +60,63 @@ func MakePermalink(base *url.URL, path *url.URL) *url.URL {
 //
 // 5. The entire collection of files is written to disk.
 type Site struct {
-	Config      Config
-	Pages       Pages
-	Tmpl        bundle.Template
-	Indexes     IndexList
-	Source      source.Input
-	Sections    Index
-	Info        SiteInfo
-	Shortcodes  map[string]ShortcodeFunc
-	timer       *nitro.B
-	Transformer transform.Transformer
-	Target      target.Output
-	Alias       target.AliasPublisher
-	Completed   chan bool
+	Config       Config
+	Pages        Pages
+	Unlisted     Pages // pages left out of every list, see SiteInfo.GetPage
+	held         Pages // drafts and scheduled content left out of the build, for reports
+	Tmpl         bundle.Template
+	TmplFuncs    template.FuncMap        // extra template funcs, added before the layouts are loaded
+	TitleFunc    func(string) string     // makes the titles of sections and indexes, see titleFunc
+	i18n         map[string]Translations // language, see loadI18n
+	partials     *partials
+	remoteData   *remoteData
+	outputs      *outputs   // published pages, see StaticCollisions
+	cards        *cardStyle // see initCards
+	Indexes      IndexList
+	termNames    map[string]map[string]string // plural, key, see termName
+	Source       source.Input
+	Sections     Index
+	Info         SiteInfo
+	Shortcodes   map[string]ShortcodeFunc
+	timer        *nitro.B
+	Transformer  transform.Transformer
+	Transformers []transform.Entry
+	Target       target.Output
+	Alias        target.AliasPublisher
+	OutputHooks  []OutputHook
+	localizer    *transform.LocalizeAssets
+	Completed    chan bool
+	StaticSync   func() error // copies the static files, see Watch
+	Rebuilt      func(error)  // called by Watch after each rebuild
 }
 
 type SiteInfo struct {
-	BaseUrl    template.URL
-	Indexes    OrderedIndexList
-	Recent     *Pages
-	LastChange time.Time
-	Title      string
-	Config     *Config
+	BaseUrl           template.URL
+	Indexes           OrderedIndexList
+	IndexTrees        map[string]IndexTree
+	Sections          Sections
+	Archives          Archives // the dated pages by month
+	ArchiveYears      Archives // the dated pages by year
+	Menus             Menus
+	Data              map[string]interface{} // the files of the data directory, see loadData
+	Recent            *Pages
+	Featured          Pages // featured content pages, by weight
+	Pages             Nodes // content pages and generated nodes
+	RegularPages      Pages // content pages only
+	LastChange        time.Time
+	BuildDate         time.Time // when the build started, LastChange for deterministic builds
+	Title             string
+	Description       string
+	Author            string
+	Copyright         string
+	LanguageCode      string
+	LanguageDirection string
+	Config            *Config
+	unlisted          *Pages
+	termNodes         map[string]map[string]*Node // plural, term
+	listMeta          map[string]*Page            // directory, see buildListMeta
+	titleFunc         func(string) string
+	translate         func(lang, id string, count ...int) string // see Node.T
 }
 
 func init() {
@@ -111,11 +151,54 @@ func (s *Site) Analyze() {
 		PublishDir: s.absPublishDir(),
 	}
 	s.ShowPlan(os.Stdout)
+	s.ShowIndexSuggestions(os.Stdout)
+	s.ShowStaleContent(os.Stdout, time.Now())
+	s.ShowScheduled(os.Stdout, time.Now())
+	s.ShowTemplateOverrides(os.Stdout)
 }
 
-func (s *Site) prepTemplates() {
+func (s *Site) prepTemplates() error {
 	s.Tmpl = bundle.NewTemplate()
+	if err := s.Tmpl.AddFuncs(template.FuncMap{
+		"T":           s.translate,
+		"readFile":    s.readFile,
+		"readDir":     s.readDir,
+		"ref":         s.ref,
+		"relref":      s.relref,
+		"dateFormat":  s.Config.dateFormat,
+		"humanize":    humanize,
+		"pluralize":   s.Config.pluralize,
+		"singularize": s.Config.singularize,
+		"highlight":   s.highlightFunc,
+		"rfc1123":     s.Config.rfc1123,
+		"rfc3339":     s.Config.rfc3339,
+		"xmlEscape":   xmlEscape,
+	}); err != nil {
+		return err
+	}
+	s.partials = &partials{s: s}
+	if err := s.Tmpl.AddFuncs(s.partials.funcs()); err != nil {
+		return err
+	}
+	s.remoteData = &remoteData{s: s}
+	if err := s.Tmpl.AddFuncs(s.remoteData.funcs()); err != nil {
+		return err
+	}
+	if err := s.Tmpl.AddFuncs(s.TmplFuncs); err != nil {
+		return err
+	}
+	if err := s.addInternalTemplates(); err != nil {
+		return err
+	}
+	themes := s.absThemeLayoutDirs()
+	for i := len(themes) - 1; i >= 0; i-- {
+		s.Tmpl.LoadTemplates(themes[i])
+	}
 	s.Tmpl.LoadTemplates(s.absLayoutDir())
+	if s.Config.Verbose {
+		s.ShowTemplateOverrides(os.Stdout)
+	}
+	return nil
 }
 
 func (s *Site) addTemplate(name, data string) error {
@@ -123,8 +206,13 @@ func (s *Site) addTemplate(name, data string) error {
 }
 
 func (s *Site) Process() (err error) {
-	s.initialize()
-	s.prepTemplates()
+	s.Pages, s.Unlisted, s.held = nil, nil, nil
+	if err = s.initialize(); err != nil {
+		return
+	}
+	if err = s.prepTemplates(); err != nil {
+		return
+	}
 	s.timerStep("initialize & template prep")
 	if err = s.CreatePages(); err != nil {
 		return err
@@ -140,6 +228,7 @@ func (s *Site) Process() (err error) {
 
 func (s *Site) setupPrevNext() {
 	for i, page := range s.Pages {
+		page.Prev, page.Next = nil, nil
 		if i < len(s.Pages)-1 {
 			page.Next = s.Pages[i+1]
 		}
@@ -151,11 +240,14 @@ func (s *Site) setupPrevNext() {
 }
 
 func (s *Site) Render() (err error) {
+	s.outputs.reset()
 	if err = s.RenderAliases(); err != nil {
 		return
 	}
 	s.timerStep("render and write aliases")
-	s.ProcessShortcodes()
+	if err = s.ProcessShortcodes(); err != nil {
+		return
+	}
 	s.timerStep("render shortcodes")
 	s.timerStep("absolute URLify")
 	if err = s.RenderIndexes(); err != nil {
@@ -176,6 +268,21 @@ func (s *Site) Render() (err error) {
 		return
 	}
 	s.timerStep("render and write homepage")
+	if err = s.RenderSitemap(); err != nil {
+		return
+	}
+	if err = s.RenderRobots(); err != nil {
+		return
+	}
+	s.timerStep("render and write sitemap and robots.txt")
+	if err = s.RenderMoved(); err != nil {
+		return
+	}
+	s.timerStep("check and write moved pages")
+	if err = s.RenderStatic(); err != nil {
+		return
+	}
+	s.timerStep("check static collisions")
 	return
 }
 
@@ -200,6 +307,12 @@ func (s *Site) initialize() (err error) {
 	}
 
 	s.initializeSiteInfo()
+	if err = s.loadData(); err != nil {
+		return err
+	}
+	if err = s.loadI18n(); err != nil {
+		return err
+	}
 
 	s.Shortcodes = make(map[string]ShortcodeFunc)
 	return
@@ -207,11 +320,56 @@ func (s *Site) initialize() (err error) {
 
 func (s *Site) initializeSiteInfo() {
 	s.Info = SiteInfo{
-		BaseUrl: template.URL(s.Config.BaseUrl),
-		Title:   s.Config.Title,
-		Recent:  &s.Pages,
-		Config:  &s.Config,
+		BaseUrl:           template.URL(s.Config.BaseUrl),
+		Title:             s.Config.Title,
+		Description:       s.Config.Description,
+		Author:            s.Config.Author,
+		Copyright:         s.Config.Copyright,
+		LanguageCode:      s.Config.LanguageCode,
+		LanguageDirection: s.Config.LanguageDirection,
+		Recent:            &s.Pages,
+		unlisted:          &s.Unlisted,
+		Config:            &s.Config,
+		BuildDate:         time.Now(),
+		titleFunc:         s.titleFunc(),
+		translate:         s.translateIn,
+	}
+}
+
+// buildDate is SiteInfo.BuildDate, the time now for sites whose info isn't
+// initialized.
+func (s *Site) buildDate() time.Time {
+	if s.Info.BuildDate.IsZero() {
+		return time.Now()
+	}
+	return s.Info.BuildDate
+}
+
+// Now is the time the build started, the same for every page of a build so
+// footers, feeds and query strings busting caches agree with each other.
+// Rebuilds of a few pages keep the time of the full build. Deterministic
+// builds use the date of the most recent content instead.
+func (s *SiteInfo) Now() time.Time {
+	return s.BuildDate
+}
+
+// GetPage finds a page, including unlisted ones, by its ID, the path of its
+// content file relative to the content directory. The extension may be left
+// out, e.g. "snippets/signup".
+func (s *SiteInfo) GetPage(ref string) *Page {
+	ref = strings.TrimPrefix(ref, "/")
+	ref = strings.TrimSuffix(ref, path.Ext(ref))
+	for _, pages := range []*Pages{s.Recent, s.unlisted} {
+		if pages == nil {
+			continue
+		}
+		for _, p := range *pages {
+			if p.ID() == ref {
+				return p
+			}
+		}
 	}
+	return nil
 }
 
 // Check if File / Directory Exists
@@ -230,6 +388,15 @@ func (s *Site) absLayoutDir() string {
 	return s.Config.GetAbsPath(s.Config.LayoutDir)
 }
 
+// absThemeLayoutDirs hold the layouts of the themes, highest precedence
+// first. They are loaded before the site's own so those replace them.
+func (s *Site) absThemeLayoutDirs() (dirs []string) {
+	for _, theme := range s.Config.GetThemeDirs() {
+		dirs = append(dirs, filepath.Join(theme, "layouts"))
+	}
+	return
+}
+
 func (s *Site) absContentDir() string {
 	return s.Config.GetAbsPath(s.Config.ContentDir)
 }
@@ -240,7 +407,19 @@ func (s *Site) absPublishDir() string {
 
 func (s *Site) checkDirectories() (err error) {
 	if b, _ := dirExists(s.absLayoutDir()); !b {
-		return fmt.Errorf("No layout directory found, expecting to find it at " + s.absLayoutDir())
+		themes := s.absThemeLayoutDirs()
+		if len(themes) == 0 {
+			return fmt.Errorf("No layout directory found, expecting to find it at " + s.absLayoutDir())
+		}
+		found := false
+		for _, dir := range themes {
+			if b, _ := dirExists(dir); b {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("No layout directory found, expecting to find it at %s or in the themes at %s", s.absLayoutDir(), strings.Join(themes, ", "))
+		}
 	}
 	if b, _ := dirExists(s.absContentDir()); !b {
 		return fmt.Errorf("No source directory found, expecting to find it at " + s.absContentDir())
@@ -248,11 +427,43 @@ func (s *Site) checkDirectories() (err error) {
 	return
 }
 
-func (s *Site) ProcessShortcodes() {
-	for _, page := range s.Pages {
-		page.Content = template.HTML(ShortcodesHandle(string(page.Content), page, s.Tmpl))
-		page.Summary = template.HTML(ShortcodesHandle(string(page.Summary), page, s.Tmpl))
+// ProcessShortcodes renders the shortcodes of every page.
+func (s *Site) ProcessShortcodes() error {
+	for _, pages := range []Pages{s.Pages, s.Unlisted} {
+		for _, page := range pages {
+			if err := s.processShortcodes(page); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// processShortcodes renders the shortcodes of page. A shortcode failing
+// stops the build, or only warns with LenientShortcodes. The scratch of
+// the page is emptied first, so a build starts afresh and what the
+// shortcodes set in it is still there for the layout.
+func (s *Site) processShortcodes(page *Page) error {
+	page.resetScratch()
+	for _, err := range s.renderPageShortcodes(page) {
+		if !s.Config.LenientShortcodes {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", err)
 	}
+	return nil
+}
+
+func (s *Site) renderPageShortcodes(page *Page) []error {
+	if page.isMarkdown() {
+		return page.renderShortcodes(s.Tmpl, s.sanitizer())
+	}
+	content, _, errs := expandShortcodes(string(page.Content), page, s.Tmpl, false)
+	page.Content = template.HTML(content)
+	// The summary is cut from the content, its errors are the same.
+	summary, _, _ := expandShortcodes(string(page.Summary), page, s.Tmpl, false)
+	page.Summary = template.HTML(summary)
+	return errs
 }
 
 func (s *Site) CreatePages() (err error) {
@@ -262,43 +473,146 @@ func (s *Site) CreatePages() (err error) {
 	if len(s.Source.Files()) < 1 {
 		return fmt.Errorf("No source files found in", s.absContentDir())
 	}
-	for _, file := range s.Source.Files() {
-		page, err := ReadFrom(file.Contents, file.LogicalName)
-		if err != nil {
-			return err
+	files := s.Source.Files()
+	bundles := bundleDirs(files)
+	bundlePages := make(map[string]*Page)
+	var resources []*source.File
+
+	for _, file := range files {
+		var page *Page
+		if isBundleIndex(file) {
+			dir := cleanDir(file.Dir)
+			if page, err = s.readBundle(file, dir); err != nil {
+				return err
+			}
+			bundlePages[dir] = page
+		} else if _, ok := bundleOf(bundles, cleanDir(file.Dir)); ok {
+			resources = append(resources, file)
+			continue
+		} else {
+			if page, err = s.readPage(file); err != nil {
+				return err
+			}
 		}
-		page.Site = s.Info
-		page.Tmpl = s.Tmpl
-		page.Section = file.Section
-		page.Dir = file.Dir
-		if s.Config.BuildDrafts || !page.Draft {
-			s.Pages = append(s.Pages, page)
+		s.addPage(page)
+	}
+
+	for _, file := range resources {
+		bundle, _ := bundleOf(bundles, cleanDir(file.Dir))
+		if err = addResource(bundlePages[bundle], bundle, file); err != nil {
+			return
 		}
 	}
+	for _, page := range bundlePages {
+		page.setupResources()
+	}
 
 	s.Pages.Sort()
 	return
 }
 
+func (s *Site) readPage(file *source.File) (*Page, error) {
+	content, err := ioutil.ReadAll(file.Contents)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = parser.ToUTF8(content, s.Config.ContentEncoding); err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", file.LogicalName, err)
+	}
+	page, err := readFrom(bytes.NewReader(content), file.LogicalName, s.Info)
+	if err != nil {
+		return nil, err
+	}
+	page.Section = file.Section
+	page.Dir = file.Dir
+	listMetaDefaults(page)
+	return page, nil
+}
+
+// addPage adds page to the pages or the unlisted pages of the site, unless
+// it is a draft which isn't built, or published after the build date with
+// HideFuture.
+func (s *Site) addPage(page *Page) bool {
+	page.Site = s.Info
+	page.Tmpl = s.Tmpl
+	s.applyContentType(page)
+	if policy := s.sanitizer(); policy != nil {
+		page.sanitize(policy)
+	}
+	if (!s.Config.BuildDrafts && page.Draft) || (s.Config.HideFuture && page.PublishDate.After(s.buildDate())) {
+		s.held = append(s.held, page)
+		return false
+	}
+	if !page.Build.List {
+		s.Unlisted = append(s.Unlisted, page)
+	} else {
+		s.Pages = append(s.Pages, page)
+	}
+	return true
+}
+
+// sanitizer is the policy stripping scripts and the like from the content
+// of pages written by people who can't be trusted, before any template gets
+// to embed it. It is nil unless Sanitize is set.
+func (s *Site) sanitizer() *transform.Sanitize {
+	if !s.Config.Sanitize {
+		return nil
+	}
+	return &transform.Sanitize{
+		Elements:   s.Config.SanitizeElements,
+		Attributes: s.Config.SanitizeAttributes,
+	}
+}
+
+func (p *Page) sanitize(policy *transform.Sanitize) {
+	p.Content = template.HTML(policy.Clean([]byte(p.Content)))
+	p.Summary = template.HTML(policy.Clean([]byte(p.Summary)))
+}
+
 func (s *Site) BuildSiteMeta() (err error) {
 	s.Indexes = make(IndexList)
+	s.termNames = make(map[string]map[string]string)
 	s.Sections = make(Index)
+	for _, p := range s.Pages {
+		p.terms = nil
+	}
 
 	for _, plural := range s.Config.Indexes {
 		s.Indexes[plural] = make(Index)
+		source := plural
+		if path, ok := s.Config.IndexSources[plural]; ok {
+			source = path
+		}
 		for _, p := range s.Pages {
-			vals := p.GetParam(plural)
-
-			if vals != nil {
-				v, ok := vals.([]string)
-				if ok {
-					for _, idx := range v {
-						s.Indexes[plural].Add(idx, p)
-					}
-				} else {
-					if s.Config.Verbose {
-						fmt.Fprintf(os.Stderr, "Invalid %s in %s\n", plural, p.File.FileName)
-					}
+			var terms []string
+			switch v := p.rawParam(source).(type) {
+			case string:
+				terms = []string{v}
+				if s.Config.SplitIndexStrings {
+					terms = splitTerms(v)
+				} else if s.Config.Verbose && strings.Contains(v, ",") {
+					fmt.Fprintf(os.Stderr, "%s in %s is a single term containing commas: %q\n", plural, p.File.FileName, v)
+				}
+			case []string:
+				terms = v
+			case nil:
+			default:
+				if s.Config.Verbose {
+					fmt.Fprintf(os.Stderr, "Invalid %s in %s\n", plural, p.File.FileName)
+				}
+			}
+
+			for _, idx := range terms {
+				p.addTerm(plural, idx)
+				s.addTermName(plural, idx)
+				if !s.Config.HierarchicalIndexes {
+					s.Indexes[plural].Add(idx, p)
+					continue
+				}
+				// Pages roll up into every parent of their terms.
+				s.Indexes[plural].addOnce(idx, p)
+				for _, parent := range parentTerms(kp(idx)) {
+					s.Indexes[plural].addOnce(parent, p)
 				}
 			}
 		}
@@ -316,17 +630,58 @@ func (s *Site) BuildSiteMeta() (err error) {
 	}
 
 	s.Info.Indexes = s.Indexes.BuildOrderedIndexList()
+	s.Info.listMeta = s.buildListMeta()
+	if s.Info.Sections, err = s.buildSections(); err != nil {
+		return
+	}
+	s.Info.Archives = s.buildArchives()
+	s.Info.ArchiveYears = s.Info.Archives.years()
+	s.Info.Menus = s.buildMenus()
+
+	if s.Config.HierarchicalIndexes {
+		s.Info.IndexTrees = make(map[string]IndexTree)
+		for plural, index := range s.Indexes {
+			s.Info.IndexTrees[plural] = index.Tree()
+		}
+	}
+
+	s.Info.Featured = s.featuredPages()
+	s.buildTranslations()
+	s.Info.termNodes = s.buildTermNodes()
+	s.Info.RegularPages = s.Pages
+	s.Info.Pages = s.buildNodes()
 
 	if len(s.Pages) == 0 {
 		return
 	}
 	s.Info.LastChange = s.Pages[0].Date
+	if s.Config.Deterministic {
+		s.Info.BuildDate = s.Info.LastChange
+	}
 
 	// populate pages with site metadata
-	for _, p := range s.Pages {
+	for _, p := range s.Unlisted {
 		p.Site = s.Info
 	}
+	for _, n := range s.Info.Pages {
+		switch n := n.(type) {
+		case *Page:
+			n.Site = s.Info
+		case *Node:
+			n.Site = s.Info
+		}
+	}
+
+	return
+}
 
+// splitTerms splits a comma separated list of terms, dropping blanks.
+func splitTerms(v string) (terms []string) {
+	for _, t := range strings.Split(v, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			terms = append(terms, t)
+		}
+	}
 	return
 }
 
@@ -350,140 +705,192 @@ func inStringArray(arr []string, el string) bool {
 	return false
 }
 
+// RenderAliases writes the aliases of every page.  A page with an invalid
+// alias doesn't stop the others; the errors of all of them are returned as
+// RenderErrors.
 func (s *Site) RenderAliases() error {
-	for _, p := range s.Pages {
-		for _, a := range p.Aliases {
-			plink, err := p.Permalink()
-			if err != nil {
-				return err
-			}
-			if err := s.WriteAlias(a, template.HTML(plink)); err != nil {
-				return err
+	var errs RenderErrors
+	for _, pages := range []Pages{s.Pages, s.Unlisted} {
+		for _, p := range pages {
+			if err := s.renderAliases(p); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func (s *Site) RenderPages() (err error) {
-	for _, p := range s.Pages {
-		var layout []string
-
-		if !p.IsRenderable() {
-			self := "__" + p.TargetPath()
-			_, err := s.Tmpl.New(self).Parse(string(p.Content))
-			if err != nil {
-				return err
-			}
-			layout = append(layout, self)
-		} else {
-			layout = append(layout, p.Layout()...)
-			layout = append(layout, "_default/single.html")
-		}
-
-		err := s.render(p, p.TargetPath(), layout...)
+func (s *Site) renderAliases(p *Page) error {
+	if !p.Build.Render {
+		return nil
+	}
+	for _, a := range p.Aliases {
+		plink, err := p.Permalink()
 		if err != nil {
 			return err
 		}
+		if err := s.WriteAlias(a, template.HTML(plink)); err != nil {
+			return fmt.Errorf("%s: alias %s: %s", p.FileName, a, err)
+		}
 	}
 	return nil
 }
 
-func (s *Site) RenderIndexes() error {
-	for singular, plural := range s.Config.Indexes {
-		for k, o := range s.Indexes[plural] {
-			n := s.NewNode()
-			n.Title = strings.Title(k)
-			url := helpers.Urlize(plural + "/" + k)
-			n.Url = url + ".html"
-			plink := n.Url
-			n.Permalink = permalink(s, plink)
-			n.RSSlink = permalink(s, url+".xml")
-			n.Date = o[0].Date
-			n.Data[singular] = o
-			n.Data["Pages"] = o
-			layout := "indexes/" + singular + ".html"
-
-			var base string
-			base = plural + "/" + k
-			err := s.render(n, base+".html", layout)
-			if err != nil {
-				return err
-			}
+// RenderPages writes every page, RenderWorkers of them at once. A failing
+// page doesn't stop the others; the errors of all of them are returned as
+// RenderErrors.
+func (s *Site) RenderPages() error {
+	var pages Pages
+	pages = append(pages, s.Pages...)
+	pages = append(pages, s.Unlisted...)
+	return s.renderPages(pages)
+}
+
+func (s *Site) renderPages(pages Pages) error {
+	// Everything shared by the workers is set up beforehand.
+	s.initTarget()
+	s.initLocalizer()
+	s.initOutputs()
+	if err := s.initCards(); err != nil {
+		return err
+	}
+	s.checkCards(pages)
+
+	type job struct {
+		page    *Page
+		layouts []string
+	}
+	var jobs []job
+	var errs RenderErrors
+	for _, p := range pages {
+		layouts, err := s.pageLayouts(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", p.FileName, err))
+			continue
+		}
+		jobs = append(jobs, job{p, layouts})
+	}
 
-			if a := s.Tmpl.Lookup("rss.xml"); a != nil {
-				// XML Feed
-				n.Url = helpers.Urlize(plural + "/" + k + ".xml")
-				n.Permalink = permalink(s, n.Url)
-				err := s.render(n, base+".xml", "rss.xml")
+	workers := s.Config.RenderWorkers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	queue := make(chan job)
+	results := make(chan error)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range queue {
+				err := s.renderPage(j.page, j.layouts)
 				if err != nil {
-					return err
+					err = fmt.Errorf("%s: %s", j.page.FileName, err)
 				}
+				results <- err
 			}
+		}()
+	}
+	go func() {
+		for _, j := range jobs {
+			queue <- j
 		}
+		close(queue)
+	}()
+
+	for _ = range jobs {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
 	}
 	return nil
 }
 
-func (s *Site) RenderIndexesIndexes() (err error) {
-	layout := "indexes/indexes.html"
-	if s.Tmpl.Lookup(layout) != nil {
-		for singular, plural := range s.Config.Indexes {
-			n := s.NewNode()
-			n.Title = strings.Title(plural)
-			url := helpers.Urlize(plural)
-			n.Url = url + "/index.html"
-			n.Permalink = permalink(s, n.Url)
-			n.Data["Singular"] = singular
-			n.Data["Plural"] = plural
-			n.Data["Index"] = s.Indexes[plural]
-			n.Data["OrderedIndex"] = s.Info.Indexes[plural]
-
-			err := s.render(n, plural+"/index.html", layout)
-			if err != nil {
-				return err
-			}
-		}
+// RenderErrors are the errors of the pages that failed to render.
+type RenderErrors []error
+
+func (e RenderErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
 	}
-	return
+	return strings.Join(msgs, "\n")
 }
 
-func (s *Site) RenderLists() error {
-	for section, data := range s.Sections {
-		n := s.NewNode()
-		n.Title = strings.Title(inflect.Pluralize(section))
-		n.Url = helpers.Urlize(section + "/" + "index.html")
-		n.Permalink = permalink(s, n.Url)
-		n.RSSlink = permalink(s, section+".xml")
-		n.Date = data[0].Date
-		n.Data["Pages"] = data
-		layout := "indexes/" + section + ".html"
+// pageLayouts returns the layouts to render p with. Pages which aren't
+// renderable are their own template, which is parsed here as templates can't
+// be added while others execute.
+func (s *Site) pageLayouts(p *Page) ([]string, error) {
+	if !p.Build.Render {
+		return nil, nil
+	}
+	if p.IsRenderable() {
+		return pageLayouts(p), nil
+	}
 
-		err := s.render(n, section, layout, "_default/indexes.html")
-		if err != nil {
+	self := "__" + p.TargetPath()
+	if _, err := s.Tmpl.New(self).Parse(string(p.Content)); err != nil {
+		return nil, err
+	}
+	return []string{self}, nil
+}
+
+// renderPage writes p and its resources, as far as its build options allow.
+func (s *Site) renderPage(p *Page, layouts []string) error {
+	if p.Build.Render {
+		if err := s.render(p, p.TargetPath(), layouts...); err != nil {
 			return err
 		}
-
-		if a := s.Tmpl.Lookup("rss.xml"); a != nil {
-			// XML Feed
-			n.Url = helpers.Urlize(section + ".xml")
-			n.Permalink = template.HTML(string(n.Site.BaseUrl) + n.Url)
-			err = s.render(n, section+".xml", "rss.xml")
-			if err != nil {
-				return err
-			}
+		if err := s.publishCard(p); err != nil {
+			return err
 		}
 	}
+
+	if p.Build.PublishResources {
+		return s.publishResources(p)
+	}
 	return nil
 }
 
-func (s *Site) RenderHomePage() error {
+// buildNodes lists every page of the site: the content pages along with
+// the homepage, section lists and index pages generated for them.
+func (s *Site) buildNodes() Nodes {
+	var nodes Nodes
+	for _, p := range s.Pages {
+		nodes = append(nodes, p)
+	}
+	if len(s.Pages) > 0 {
+		nodes = append(nodes, s.newHomeNode())
+	}
+	for _, info := range s.Info.Sections {
+		nodes = append(nodes, s.newSectionNode(info))
+	}
+	for _, singular := range s.indexSingulars() {
+		plural := s.Config.Indexes[singular]
+		for _, k := range s.Indexes[plural].keys() {
+			nodes = append(nodes, s.newIndexNode(singular, k, s.Indexes[plural][k]))
+		}
+		if s.Tmpl != nil && s.findFirstLayout(taxonomyTermsLayouts(plural)...) != "" {
+			nodes = append(nodes, s.newIndexesNode(singular, plural))
+		}
+	}
+	nodes.Sort()
+	return nodes
+}
 
+func (s *Site) newHomeNode() *Node {
 	n := s.NewNode()
+	n.Kind = KindHome
 	n.Title = n.Site.Title
 	n.Url = helpers.Urlize(string(n.Site.BaseUrl))
-	n.RSSlink = permalink(s, "index.xml")
 	n.Permalink = permalink(s, "")
+	s.setFeed(n, "")
+	s.applyListMeta(n, "")
 	if len(s.Pages) > 0 {
 		n.Date = s.Pages[0].Date
 		if len(s.Pages) < 9 {
@@ -492,21 +899,283 @@ func (s *Site) RenderHomePage() error {
 			n.Data["Pages"] = s.Pages[:9]
 		}
 	}
-	err := s.render(n, "/", "index.html")
+	return n
+}
+
+func (s *Site) newSectionNode(info *Section) *Node {
+	n := s.NewNode()
+	n.Kind = KindSection
+	n.Title = info.Title
+	n.Url = info.Url
+	n.Permalink = info.Permalink
+	if info.RSSLink != "" {
+		s.setFeed(n, info.Name)
+	}
+	n.Date = info.Date
+	n.Data["Pages"] = info.Pages
+	s.applyListMeta(n, info.Name)
+	return n
+}
+
+// newIndexNode is the first page of the list of pages for the term k.
+func (s *Site) newIndexNode(singular, k string, o Pages) *Node {
+	plural := s.Config.Indexes[singular]
+	base := plural + "/" + k
+	n := s.NewNode()
+	n.Kind = KindTaxonomy
+	n.Title = s.Info.title(s.termName(plural, k))
+	n.Url = helpers.Urlize(base) + ".html"
+	n.Permalink = permalink(s, n.Url)
+	s.setFeed(n, base)
+	n.Date = o[0].Date
+	n.Data[singular] = o
+	n.Data["Pages"] = o
+	if tree, ok := s.Info.IndexTrees[plural]; ok {
+		n.Data["Term"] = tree.Get(k)
+	}
+	s.applyListMeta(n, base)
+	return n
+}
+
+// featuredPages lists the pages marked as featured, by weight.
+func (s *Site) featuredPages() Pages {
+	var featured Pages
+	for _, p := range s.Pages {
+		if p.Featured {
+			featured = append(featured, p)
+		}
+	}
+	return featured.ByWeight()
+}
+
+// buildTermNodes creates the node of every term of every index.
+func (s *Site) buildTermNodes() map[string]map[string]*Node {
+	nodes := make(map[string]map[string]*Node)
+	for singular, plural := range s.Config.Indexes {
+		nodes[plural] = make(map[string]*Node)
+		for k, o := range s.Indexes[plural] {
+			nodes[plural][k] = s.newIndexNode(singular, k, o)
+		}
+	}
+	return nodes
+}
+
+func (s *Site) newIndexesNode(singular, plural string) *Node {
+	n := s.NewNode()
+	n.Kind = KindTaxonomyTerms
+	n.Title = s.Info.title(plural)
+	n.Url = helpers.Urlize(plural) + "/index.html"
+	n.Permalink = permalink(s, n.Url)
+	s.setFeed(n, plural)
+	n.Data["Singular"] = singular
+	n.Data["Plural"] = plural
+	n.Data["Index"] = s.Indexes[plural]
+	n.Data["OrderedIndex"] = s.Info.Indexes[plural]
+	n.Data["Pages"] = s.Indexes[plural].pages()
+	if pages := n.Data["Pages"].(Pages); len(pages) > 0 {
+		n.Date = pages[0].Date
+	}
+	s.applyListMeta(n, plural)
+	return n
+}
+
+// feedPath is the url of the feed of the node at base, matching where the
+// target publishes it.  The home page is at base "".
+func (s *Site) feedPath(base string) string {
+	base = helpers.Urlize(base)
+	switch {
+	case base == "":
+		return "index.xml"
+	case s.Config.UglyUrls:
+		return base + ".xml"
+	}
+	return base + "/index.xml"
+}
+
+var feedLayouts = []string{"rss.xml"}
+
+// setFeed links n to its feed, and lists the feed as an alternate of n
+// when feeds are rendered at all.
+func (s *Site) setFeed(n *Node, base string) {
+	n.RSSlink = permalink(s, s.feedPath(base))
+	if s.Tmpl != nil && s.findFirstLayout(feedLayouts...) != "" {
+		n.Alternates = append(n.Alternates, &Alternate{
+			Rel:       "alternate",
+			Type:      "application/rss+xml",
+			Title:     n.Title,
+			Permalink: n.RSSlink,
+		})
+	}
+}
+
+// renderFeed renders the feed of n, listing the first RSSLimit of its
+// .Data.Pages. n is modified.
+func (s *Site) renderFeed(n *Node, base string) error {
+	return s.renderFeedLimit(n, base, s.Config.RSSLimit)
+}
+
+// renderFeedLimit is renderFeed listing the first limit pages, all of them
+// for 0.
+func (s *Site) renderFeedLimit(n *Node, base string, limit int) error {
+	if s.findFirstLayout(feedLayouts...) == "" {
+		return nil
+	}
+	n.Url = s.feedPath(base)
+	n.Permalink = permalink(s, n.Url)
+	if base == "" && !s.Config.absoluteBaseUrl() {
+		fmt.Fprintf(os.Stderr, "WARNING: BaseUrl %q isn't absolute, so neither are the links of the feeds\n", s.Config.BaseUrl)
+	}
+	if pages, ok := n.Data["Pages"].(Pages); ok {
+		if pages = s.feedPages(pages); limit > 0 && len(pages) > limit {
+			pages = pages[:limit]
+		}
+		n.Data["Pages"] = pages
+	}
+
+	out := helpers.Urlize(base) + ".xml"
+	if base == "" && s.Config.UglyUrls {
+		out = n.Url
+	}
+	return s.renderXML(n, out, s.findFirstLayout(feedLayouts...))
+}
+
+// RenderIndexes writes the pages of every term, index by index and term by
+// term in alphabetical order.
+func (s *Site) RenderIndexes() error {
+	for _, singular := range s.indexSingulars() {
+		plural := s.Config.Indexes[singular]
+		for _, k := range s.Indexes[plural].keys() {
+			if err := s.renderTerm(singular, k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderTerm writes the pages and the feed of the term k of an index.
+func (s *Site) renderTerm(singular, k string) error {
+	plural := s.Config.Indexes[singular]
+	o := s.Indexes[plural][k]
+	base := plural + "/" + k
+	layouts := taxonomyLayouts(singular)
+
+	size, ok := s.Config.IndexPaginate[plural]
+	if !ok {
+		size = s.Config.Paginate
+	}
+	for _, pager := range s.paginate(o, size, base) {
+		n := s.newIndexNode(singular, k, o)
+		n.Url = helpers.Urlize(pager.path) + ".html"
+		n.Permalink = permalink(s, n.Url)
+		n.Data["Pages"] = pager.Pages
+		n.Data["Pager"] = pager
+		n.Paginator = pager
+
+		err := s.render(n, pager.path+".html", layouts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.renderFeed(s.newIndexNode(singular, k, o), base)
+}
+
+func (s *Site) RenderIndexesIndexes() (err error) {
+	for _, singular := range s.indexSingulars() {
+		if err = s.renderTermsList(singular); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// renderTermsList writes the list of the terms of an index and its feed.
+func (s *Site) renderTermsList(singular string) error {
+	plural := s.Config.Indexes[singular]
+	if err := s.renderFeed(s.newIndexesNode(singular, plural), plural); err != nil {
+		return err
+	}
+
+	layouts := taxonomyTermsLayouts(plural)
+	if s.findFirstLayout(layouts...) == "" {
+		return nil
+	}
+
+	n := s.newIndexesNode(singular, plural)
+	return s.render(n, plural+"/index.html", layouts...)
+}
+
+func (s *Site) RenderLists() error {
+	for _, info := range s.Info.Sections {
+		if err := s.renderSection(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderSection writes the list of a section and its feed, as its
+// ListOptions say. The feed lists the newest pages whatever the order of
+// the list.
+func (s *Site) renderSection(info *Section) error {
+	section := info.Name
+	newNode := func() *Node { return s.newSectionNode(info) }
+	err := s.renderPagers(newNode, info.Pages, info.options.Paginate, section, section, sectionLayouts(section))
 	if err != nil {
 		return err
 	}
 
-	if a := s.Tmpl.Lookup("rss.xml"); a != nil {
-		// XML Feed
-		n.Url = helpers.Urlize("index.xml")
-		n.Title = "Recent Content"
-		n.Permalink = permalink(s, "index.xml")
-		err := s.render(n, ".xml", "rss.xml")
-		if err != nil {
+	if info.RSSLink == "" {
+		return nil
+	}
+	n := newNode()
+	n.Data["Pages"] = s.Sections[section]
+	return s.renderFeedLimit(n, section, info.options.RSSLimit)
+}
+
+// renderPagers writes the list of pages of the nodes made by newNode, split
+// in size pages at a time. The first page is written to first, the
+// following ones below base, see paginate.
+func (s *Site) renderPagers(newNode func() *Node, pages Pages, size int, base, first string, layouts []string) error {
+	for _, pager := range s.paginate(pages, size, base) {
+		n := newNode()
+		out := first
+		if pager.PageNumber > 1 {
+			n.Url = pager.Url
+			n.Permalink = pager.Permalink
+			out = pager.path
+		}
+		n.Data["Pages"] = pager.Pages
+		n.Paginator = pager
+		if err := s.render(n, out, layouts...); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+func (s *Site) RenderHomePage() error {
+
+	n := s.newHomeNode()
+	var err error
+	if s.Config.Paginate > 0 {
+		err = s.renderPagers(s.newHomeNode, s.Pages, s.Config.Paginate, "", "/", homeLayouts())
+	} else {
+		err = s.render(n, "/", homeLayouts()...)
+	}
+	if err != nil {
+		return err
+	}
+
+	n.Title = "Recent Content"
+	if s.Config.RSSLimit > 0 {
+		// not just the content shown on the homepage
+		n.Data["Pages"] = s.Pages
+	}
+	if err = s.renderFeed(n, ""); err != nil {
+		return err
+	}
 
 	if a := s.Tmpl.Lookup("404.html"); a != nil {
 		n.Url = helpers.Urlize("404.html")
@@ -520,7 +1189,8 @@ func (s *Site) RenderHomePage() error {
 
 func (s *Site) Stats() {
 	fmt.Printf("%d pages created \n", len(s.Pages))
-	for _, pl := range s.Config.Indexes {
+	for _, singular := range s.indexSingulars() {
+		pl := s.Config.Indexes[singular]
 		fmt.Printf("%d %s index created\n", len(s.Indexes[pl]), pl)
 	}
 }
@@ -547,7 +1217,6 @@ func (s *Site) NewNode() *Node {
 }
 
 func (s *Site) render(d interface{}, out string, layouts ...string) (err error) {
-
 	layout := s.findFirstLayout(layouts...)
 	if layout == "" {
 		if s.Config.Verbose {
@@ -557,38 +1226,99 @@ func (s *Site) render(d interface{}, out string, layouts ...string) (err error)
 	}
 
 	section := ""
+	source := out
 	if page, ok := d.(*Page); ok {
 		section, _ = page.RelPermalink()
+		source = page.FileName
 	}
 
-	transformer := transform.NewChain(
-		&transform.AbsURL{BaseURL: s.Config.BaseUrl},
-		&transform.NavActive{Section: section},
-	)
+	transformers := []transform.Entry{
+		{Priority: transform.PriorityAbsURL, Transformer: &transform.AbsURL{BaseURL: s.Config.BaseUrl}},
+		{Priority: transform.PriorityNavActive, Transformer: &transform.NavActive{
+			Section:     section,
+			Element:     s.Config.NavElement,
+			AttrName:    s.Config.NavAttrName,
+			MatchPrefix: s.Config.NavMatchPrefix,
+		}},
+	}
+	if len(s.Config.ExternalAssetHosts) > 0 {
+		s.initLocalizer()
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityLocalizeAssets, Transformer: s.localizer})
+	}
+	if page, ok := d.(*Page); ok && page.Draft && s.Config.BuildDrafts && s.Config.MarkDrafts {
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityDraftMark, Transformer: &transform.DraftMark{Banner: s.Config.DraftBanner}})
+	} else if ok && page.NoIndex {
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityNoIndex, Transformer: new(transform.NoIndex)})
+	}
+	if page, ok := d.(*Page); ok && page.IsTranslated() && s.Config.Hreflang {
+		alternates, err := s.hreflang(page)
+		if err != nil {
+			return err
+		}
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityHreflang, Transformer: &transform.Hreflang{Alternates: alternates}})
+	}
+	if s.Config.Typography {
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityTypography, Transformer: new(transform.Typography)})
+	}
+	if s.Config.LintHtml {
+		transformers = append(transformers, transform.Entry{Priority: transform.PriorityLint, Transformer: &transform.Lint{Source: source}})
+	}
+	transformer := transform.NewPriorityChain(append(transformers, s.Transformers...)...)
 
 	renderReader, renderWriter := io.Pipe()
 	go func() {
-		err = s.renderThing(d, layout, renderWriter)
-		if err != nil {
-			panic(err)
-		}
+		// A failing template is reported to the publisher through the pipe.
+		renderWriter.CloseWithError(s.renderThing(d, layout, renderWriter))
 	}()
 
 	trReader, trWriter := io.Pipe()
 	go func() {
-		transformer.Apply(trWriter, renderReader)
-		trWriter.Close()
+		// A failing transformer (e.g. an asset that could not be downloaded)
+		// is reported to the publisher through the pipe.
+		trWriter.CloseWithError(transformer.Apply(trWriter, renderReader))
 	}()
 
-	return s.WritePublic(out, trReader)
+	return s.writePublic(out, source, trReader)
+}
+
+// renderXML writes d with layout to out, leaving out the transformers,
+// which treat their input as html. The links of the html escaped in it are
+// made absolute all the same, as feed readers don't resolve them.
+func (s *Site) renderXML(d interface{}, out, layout string) error {
+	buf := new(bytes.Buffer)
+	if err := s.Tmpl.ExecuteTemplate(buf, layout, d); err != nil {
+		return err
+	}
+	abs := new(bytes.Buffer)
+	if err := (&transform.AbsURLInXML{BaseURL: s.Config.BaseUrl}).Apply(abs, buf); err != nil {
+		return err
+	}
+	return s.WritePublic(out, abs)
+}
+
+// AddTransformer includes tr in the chain applied to every rendered file.
+// The priority orders it relative to the built in transformers (see
+// transform.PriorityAbsURL and transform.PriorityNavActive); lower runs first.
+// The same transformer is used for every file so it must not keep state
+// between calls to Apply.
+func (s *Site) AddTransformer(priority int, tr transform.Transformer) {
+	s.Transformers = append(s.Transformers, transform.Entry{Priority: priority, Transformer: tr})
 }
 
+// findFirstLayout is the first of layouts the site or its themes have, else
+// the first internal one, e.g. "_internal/_default/single.html" for
+// "_default/single.html" (see internalTemplates).
 func (s *Site) findFirstLayout(layouts ...string) (layout string) {
 	for _, layout = range layouts {
 		if s.Tmpl.Lookup(layout) != nil {
 			return
 		}
 	}
+	for _, layout = range layouts {
+		if s.Tmpl.Lookup(internalPrefix+layout) != nil {
+			return internalPrefix + layout
+		}
+	}
 	return ""
 }
 
@@ -597,8 +1327,10 @@ func (s *Site) renderThing(d interface{}, layout string, w io.WriteCloser) error
 	if s.Tmpl.Lookup(layout) == nil {
 		return fmt.Errorf("Layout not found: %s", layout)
 	}
-	defer w.Close()
-	return s.Tmpl.ExecuteTemplate(w, layout, d)
+	if err := s.Tmpl.ExecuteTemplate(w, layout, d); err != nil {
+		return err
+	}
+	return w.Close()
 }
 
 func (s *Site) whyNewXMLBuffer() *bytes.Buffer {
@@ -606,30 +1338,79 @@ func (s *Site) whyNewXMLBuffer() *bytes.Buffer {
 	return bytes.NewBufferString(header)
 }
 
+// initLocalizer creates the transformer downloading the assets of the
+// external asset hosts, shared by every rendered file.
+func (s *Site) initLocalizer() {
+	if s.localizer == nil && len(s.Config.ExternalAssetHosts) > 0 {
+		s.localizer = &transform.LocalizeAssets{
+			Hosts:      s.Config.ExternalAssetHosts,
+			BaseURL:    s.Config.BaseUrl,
+			PublishDir: s.absPublishDir(),
+			Publish:    s.publishFile,
+		}
+	}
+}
+
 func (s *Site) initTarget() {
 	if s.Target == nil {
 		s.Target = &target.Filesystem{
 			PublishDir: s.absPublishDir(),
 			UglyUrls:   s.Config.UglyUrls,
+			Modes:      s.publishModes(),
+		}
+	}
+}
+
+// publishModes parses the configured permissions of published output.
+func (s *Site) publishModes() (modes target.Modes) {
+	parse := func(name, mode string) os.FileMode {
+		if mode == "" {
+			return 0
+		}
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || m > 0777 {
+			fmt.Fprintf(os.Stderr, "Invalid %s %q, expected an octal mode like 0755\n", name, mode)
+			return 0
 		}
+		return os.FileMode(m)
 	}
+	modes.DirMode = parse("publishdirmode", s.Config.PublishDirMode)
+	modes.FileMode = parse("publishfilemode", s.Config.PublishFileMode)
+	return
 }
 
 func (s *Site) WritePublic(path string, reader io.Reader) (err error) {
+	return s.writePublic(path, path, reader)
+}
+
+// writePublic is WritePublic for the page rendered from source.
+func (s *Site) writePublic(path, source string, reader io.Reader) (err error) {
 	s.initTarget()
+	s.recordOutput(path, source)
 
 	if s.Config.Verbose {
 		fmt.Println(path)
 	}
+
+	if len(s.OutputHooks) > 0 {
+		if reader, err = s.applyOutputHooks(path, reader); err != nil {
+			return
+		}
+	}
 	return s.Target.Publish(path, reader)
 }
 
 func (s *Site) WriteAlias(path string, permalink template.HTML) (err error) {
 	if s.Alias == nil {
 		s.initTarget()
-		s.Alias = &target.HTMLRedirectAlias{
+		alias := &target.HTMLRedirectAlias{
 			PublishDir: s.absPublishDir(),
+			Modes:      s.publishModes(),
+		}
+		if s.Tmpl != nil {
+			alias.Templates = aliasTemplates{s}
 		}
+		s.Alias = alias
 	}
 
 	if s.Config.Verbose {
@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/hugo/target"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 const ALIAS_DOC_1 = "---\ntitle: alias doc\naliases:\n  - \"alias1/\"\n  - \"alias-2/\"\n---\naliases\n"
@@ -99,3 +104,94 @@ func TestFileTargetPublishDir(t *testing.T) {
 		"section/somecontent.html (renderer: n/a)\n canonical => ../public/section/somecontent/index.html\n\n"
 	checkShowPlanExpected(t, s, expected)
 }
+
+func TestShowIndexSuggestions(t *testing.T) {
+	s := &Site{Config: Config{Indexes: map[string]string{"tag": "tags"}}}
+	for _, content := range []string{
+		"---\ntitle: a\ntags: [go]\nseries: [intro, go]\nauthor: me\n---\n",
+		"---\ntitle: b\nseries: [Intro]\ntopics: \"web, go\"\n---\n",
+	} {
+		s.Pages = append(s.Pages, pageMust(ReadFrom(strings.NewReader(content), "path/to/page")))
+	}
+
+	out := new(bytes.Buffer)
+	s.ShowIndexSuggestions(out)
+	expected := "Possible indexes not configured:\n series (2 pages, 2 terms)\n topics (1 pages, 2 terms)\n"
+	if out.String() != expected {
+		t.Errorf("ShowIndexSuggestions expected:\n%q\ngot\n%q", expected, out.String())
+	}
+}
+
+func TestShowStaleContent(t *testing.T) {
+	s := &Site{
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\nexpirydate: 2013-05-01\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\nexpirydate: 2013-06-03\n---\n"), Section: "post"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\nexpirydate: 2013-09-01\n---\n"), Section: "post"},
+			{Name: "post/d.md", Content: []byte("---\ntitle: d\ndate: 2013-01-01\ndraft: true\n---\n"), Section: "post"},
+			{Name: "post/e.md", Content: []byte("---\ntitle: e\ndate: 2013-05-30\ndraft: true\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+
+	out := new(bytes.Buffer)
+	s.ShowStaleContent(out, time.Date(2013, 6, 1, 0, 0, 0, 0, time.UTC))
+	expected := "Content to review:\n post/d.md draft since 2013-01-01\n post/a.md expired 2013-05-01\n post/b.md expires 2013-06-03\n"
+	if out.String() != expected {
+		t.Errorf("ShowStaleContent expected:\n%q\ngot\n%q", expected, out.String())
+	}
+}
+
+func TestShowScheduled(t *testing.T) {
+	s := &Site{
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-05-01\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-05-01\npublishdate: 2100-01-02\n---\n"), Section: "post"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2100-01-01\n---\n"), Section: "post"},
+			{Name: "post/d.md", Content: []byte("---\ntitle: d\ndate: 2100-01-03\ndraft: true\n---\n"), Section: "post"},
+		}},
+	}
+	for _, hide := range []bool{false, true} {
+		s.Config.HideFuture = hide
+		s.Pages, s.Unlisted, s.held = nil, nil, nil
+		s.initializeSiteInfo()
+		must(s.CreatePages())
+
+		out := new(bytes.Buffer)
+		s.ShowScheduled(out, time.Date(2013, 6, 1, 0, 0, 0, 0, time.UTC))
+		built := "built now"
+		if hide {
+			built = "held back"
+		}
+		expected := "Scheduled content:\n" +
+			" post/c.md goes live 2100-01-01 00:00 (" + built + ")\n" +
+			" post/b.md goes live 2100-01-02 00:00 (" + built + ")\n" +
+			" post/d.md goes live 2100-01-03 00:00 (held back, draft)\n"
+		if out.String() != expected {
+			t.Errorf("ShowScheduled expected:\n%q\ngot\n%q", expected, out.String())
+		}
+	}
+}
+
+func TestShowTemplateOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	must(os.MkdirAll(filepath.Join(dir, "layouts", "_default"), 0755))
+	must(ioutil.WriteFile(filepath.Join(dir, "layouts", "_default", "single.html"), []byte("{{ .Title }}"), 0644))
+
+	s := &Site{Config: Config{Path: dir, LayoutDir: "layouts"}}
+	must(s.prepTemplates())
+	must(s.addTemplate("_default/single.html", "{{ .Content }}"))
+
+	out := new(bytes.Buffer)
+	s.ShowTemplateOverrides(out)
+	expected := "Template overrides:\n" +
+		" _default/single.html: (added) replaces layouts/_default/single.html\n"
+	if out.String() != expected {
+		t.Errorf("ShowTemplateOverrides expected:\n%q\ngot\n%q", expected, out.String())
+	}
+}
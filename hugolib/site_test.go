@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/hugo/target"
+	"github.com/spf13/hugo/transform"
 	"html/template"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -321,3 +326,571 @@ func TestAbsUrlify(t *testing.T) {
 		}
 	}
 }
+
+type footerTransformer struct{}
+
+func (footerTransformer) Apply(w io.Writer, r io.Reader) (err error) {
+	b := new(bytes.Buffer)
+	if _, err = b.ReadFrom(r); err != nil {
+		return
+	}
+	_, err = io.WriteString(w, strings.Replace(b.String(), "</body>", "<footer>legal</footer></body>", 1))
+	return
+}
+
+func TestCustomTransformer(t *testing.T) {
+	files := make(map[string][]byte)
+	target := &target.InMemoryTarget{Files: files}
+	s := &Site{
+		Target: target,
+	}
+	s.prepTemplates()
+	s.AddTransformer(transform.PriorityNavActive+1, footerTransformer{})
+	must(s.addTemplate("foo", TEMPLATE_TITLE))
+
+	p := pageMust(ReadFrom(strings.NewReader(PAGE_SIMPLE_TITLE), "content/a/file.md"))
+	if err := s.render(p, "out", "foo"); err != nil {
+		t.Fatalf("Unable to render: %s", err)
+	}
+
+	expected := HTML("simple template<footer>legal</footer>")
+	if string(files["out"]) != expected {
+		t.Errorf("Content expected: %q, got: %q", expected, files["out"])
+	}
+}
+
+func TestSanitizeContent(t *testing.T) {
+	sources := []source.ByteSource{
+		{Name: "sect/doc1.md", Content: []byte("---\ntitle: doc1\n---\nsome <script>alert('x')</script>*content* <img src=\"a.png\" onerror=\"alert(1)\">"), Section: "sect"},
+		{Name: "sect/doc2.md", Content: []byte("---\ntitle: doc2\n---\n<script>alert('x')</script>{{% em %}}*a*{{% /em %}} {{< video >}}"), Section: "sect"},
+	}
+	s := &Site{
+		Config: Config{Sanitize: true},
+		Source: &source.InMemorySource{ByteSource: sources},
+	}
+	s.initializeSiteInfo()
+
+	if err := s.CreatePages(); err != nil {
+		t.Fatalf("Unable to create pages: %s", err)
+	}
+
+	expected := "<p>some <em>content</em> <img src=\"a.png\"></p>\n"
+	if string(s.Pages[0].Content) != expected {
+		t.Errorf("Sanitized content expected:\n%q\ngot:\n%q", expected, s.Pages[0].Content)
+	}
+
+	// Pages using shortcodes are rendered again, and cleaned again, but the
+	// html of the templates is trusted.
+	s.prepTemplates()
+	must(s.addTemplate("shortcodes/em.html", "<em>{{ .Inner }}</em>"))
+	must(s.addTemplate("shortcodes/video.html", `<iframe src="/v"></iframe>`))
+	must(s.ProcessShortcodes())
+	expected = "<p><em><em>a</em></em> <iframe src=\"/v\"></iframe></p>\n"
+	if string(s.Pages[1].Content) != expected {
+		t.Errorf("Sanitized content with shortcodes expected:\n%q\ngot:\n%q", expected, s.Pages[1].Content)
+	}
+}
+
+func TestRenderIndexesIndexesLayouts(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Config: Config{Indexes: map[string]string{"tag": "tags", "category": "categories"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("indexes/indexes.html", "generic {{ .Data.Plural }}"))
+	must(s.addTemplate("indexes/tags.terms.html", "cloud {{ .Data.Plural }}"))
+	must(s.BuildSiteMeta())
+
+	if err := s.RenderIndexesIndexes(); err != nil {
+		t.Fatalf("Unable to render indexes indexes: %s", err)
+	}
+
+	for file, expected := range map[string]string{
+		"tags/index.html":       HTML("cloud tags"),
+		"categories/index.html": HTML("generic categories"),
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s expected: %q, got: %q", file, expected, files[file])
+		}
+	}
+}
+
+func TestKindListLayout(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "blog/a.md", Content: []byte("---\ntitle: a\ntags: [go]\n---\n"), Section: "blog"},
+		}},
+		Config: Config{BaseUrl: "http://auth/bub/", Indexes: map[string]string{"tag": "tags"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/list.html", "{{ .Kind }}"))
+	must(s.addTemplate("_default/single.html", "{{ .Kind }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderIndexes())
+	must(s.RenderIndexesIndexes())
+	must(s.RenderLists())
+	must(s.RenderPages())
+	must(s.RenderHomePage())
+
+	for file, expected := range map[string]string{
+		"/":               KindHome,
+		"blog":            KindSection,
+		"tags/go.html":    KindTaxonomy,
+		"tags/index.html": KindTaxonomyTerms,
+		"blog/a.html":     KindPage,
+	} {
+		if string(files[file]) != HTML(expected) {
+			t.Errorf("%s expected: %q, got: %q", file, HTML(expected), files[file])
+		}
+	}
+}
+
+func TestTypeOverridesSectionLayout(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\ntype: /gallery/\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("post/single.html", "post {{ .Title }}"))
+	must(s.addTemplate("gallery/single.html", "gallery {{ .Title }} in {{ .Section }}"))
+	must(s.CreatePages())
+	must(s.RenderPages())
+
+	for file, expected := range map[string]string{
+		"post/a.html": "post a",
+		"post/b.html": "gallery b in post",
+	} {
+		if string(files[file]) != HTML(expected) {
+			t.Errorf("%s expected: %q, got: %q", file, HTML(expected), files[file])
+		}
+	}
+}
+
+func TestHeadlessPages(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+			{Name: "snippets/cta.md", Content: []byte("---\ntitle: cta\nheadless: true\n---\nSign *up*"), Section: "snippets"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", `{{ .Title }} {{ with .Site.GetPage "snippets/cta" }}{{ .Content }}{{ end }}`))
+	must(s.addTemplate("_default/list.html", "{{ range .Data.Pages }}{{ .Title }}{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderLists())
+	must(s.RenderPages())
+
+	if len(s.Pages) != 1 || len(s.Sections) != 1 {
+		t.Errorf("Headless pages should not be listed, got %d pages in %d sections", len(s.Pages), len(s.Sections))
+	}
+	if _, ok := files["snippets/cta.html"]; ok {
+		t.Errorf("Headless pages should not be rendered")
+	}
+	expected := HTML("a <p>Sign <em>up</em></p>\n")
+	if string(files["post/a.html"]) != expected {
+		t.Errorf("post/a.html expected: %q, got: %q", expected, files["post/a.html"])
+	}
+	for _, ref := range []string{"snippets/cta", "/snippets/cta.md"} {
+		if s.Info.GetPage(ref) == nil {
+			t.Errorf("GetPage(%q) should find the headless page", ref)
+		}
+	}
+	if s.Info.GetPage("post/a") == nil || s.Info.GetPage("post/b") != nil {
+		t.Errorf("GetPage should find regular pages by path")
+	}
+}
+
+func TestBuildOptions(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/listed.md", Content: []byte("---\ntitle: listed\n_build:\n  render: false\n---\n"), Section: "post"},
+			{Name: "post/hidden.md", Content: []byte("+++\ntitle = \"hidden\"\n[_build]\nlist = false\n+++\n"), Section: "post"},
+			{Name: "post/trip/index.md", Content: []byte("---\ntitle: trip\n_build:\n  publishResources: false\n---\n"), Section: "trip"},
+			{Name: "post/trip/photo.jpg", Content: []byte("jpg"), Section: "trip"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	if len(s.Pages) != 2 || len(s.Unlisted) != 1 || s.Unlisted[0].Title != "hidden" {
+		t.Errorf("Expected hidden to be left out of the lists, got: %v", s.Unlisted)
+	}
+	for file, rendered := range map[string]bool{
+		"post/listed.html":    false,
+		"post/hidden.html":    true,
+		"post/trip.html":      true,
+		"post/trip/photo.jpg": false,
+	} {
+		if _, ok := files[file]; ok != rendered {
+			t.Errorf("%s expected to be rendered: %t", file, rendered)
+		}
+	}
+}
+
+func TestInvalidBuildOptions(t *testing.T) {
+	for _, content := range []string{
+		"---\n_build: yes\n---\n",
+		"---\n_build:\n  render: sometimes\n---\n",
+		"---\n_build:\n  publish: false\n---\n",
+	} {
+		if _, err := ReadFrom(strings.NewReader(content), "post/a.md"); err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+func TestFeedLinks(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "blog/a.md", Content: []byte("---\ntitle: a\ntags: [go]\n---\n"), Section: "blog"},
+		}},
+		Config: Config{BaseUrl: "http://auth/bub/", Indexes: map[string]string{"tag": "tags"}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("rss.xml", "{{ .Permalink }}"))
+	must(s.addTemplate("_default/list.html", "{{ .RSSLink }}{{ range .Alternates }} {{ .Type }}{{ end }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderIndexes())
+	must(s.RenderIndexesIndexes())
+	must(s.RenderLists())
+	must(s.RenderHomePage())
+
+	fs := &target.Filesystem{}
+	for out, list := range map[string]string{
+		".xml":        "/",
+		"blog.xml":    "blog",
+		"tags/go.xml": "tags/go.html",
+		"tags.xml":    "tags/index.html",
+	} {
+		dest, err := fs.Translate(out)
+		if err != nil {
+			t.Fatalf("Unable to translate %s: %s", out, err)
+		}
+		feed := "http://auth/bub/" + dest
+		if got := string(files[out]); got != feed {
+			t.Errorf("%s expected: %q, got: %q", out, feed, got)
+		}
+		if expected := HTML(feed + " application/rss+xml"); string(files[list]) != expected {
+			t.Errorf("%s expected: %q, got: %q", list, expected, files[list])
+		}
+	}
+}
+
+func TestRenderPagesCollectsErrors(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\nlayout: broken\n---\n"), Section: "post"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\n---\n"), Section: "post"},
+			{Name: "post/d.html", Content: []byte("<p>{{ .Title</p>"), Section: "post"},
+		}},
+		Config: Config{RenderWorkers: 2},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}"))
+	must(s.addTemplate("post/broken.html", "{{ template \"missing\" . }}"))
+	must(s.CreatePages())
+
+	err := s.RenderPages()
+	errs, ok := err.(RenderErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Expected the errors of b and d, got: %v", err)
+	}
+	for _, name := range []string{"post/b.md", "post/d.html"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected an error for %s, got: %s", name, err)
+		}
+	}
+	for _, file := range []string{"post/a.html", "post/c.html"} {
+		if string(files[file]) == "" {
+			t.Errorf("Expected %s to be rendered", file)
+		}
+	}
+}
+
+func TestFeaturedPages(t *testing.T) {
+	s := &Site{
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-04\nfeatured: true\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-03\nfeatured: true\nweight: 2\n---\n"), Section: "post"},
+			{Name: "post/c.md", Content: []byte("---\ntitle: c\ndate: 2013-01-02\n---\n"), Section: "post"},
+			{Name: "post/d.md", Content: []byte("---\ntitle: d\ndate: 2013-01-01\nfeatured: true\nweight: 1\n---\n"), Section: "post"},
+			{Name: "post/e.md", Content: []byte("---\ntitle: e\ndate: 2013-01-05\nfeatured: true\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	var titles []string
+	for _, p := range s.Info.Featured {
+		titles = append(titles, p.Title)
+	}
+	if expected := []string{"d", "b", "e", "a"}; !compareStringSlice(titles, expected) {
+		t.Errorf("Expected featured pages %v, got: %v", expected, titles)
+	}
+}
+
+func TestMarkDrafts(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\ndraft: true\n---\n"), Section: "post"},
+		}},
+		Config: Config{BuildDrafts: true, MarkDrafts: true, DraftBanner: "<p>preview</p>"},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "{{ .Title }}"))
+	must(s.CreatePages())
+	must(s.RenderPages())
+
+	for file, expected := range map[string]string{
+		"post/a.html": HTML("a"),
+		"post/b.html": `<html><head><meta name="robots" content="noindex"></head><body><p>preview</p>b</body></html>`,
+	} {
+		if string(files[file]) != expected {
+			t.Errorf("%s expected: %q, got: %q", file, expected, files[file])
+		}
+	}
+}
+
+func TestTmplFuncs(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target:    &target.InMemoryTarget{Files: files},
+		Source:    &source.InMemorySource{ByteSource: []source.ByteSource{{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"}}},
+		TmplFuncs: template.FuncMap{"shout": strings.ToUpper},
+	}
+	s.initializeSiteInfo()
+	if err := s.prepTemplates(); err != nil {
+		t.Fatalf("Unable to prepare templates: %s", err)
+	}
+	must(s.addTemplate("_default/single.html", "{{ shout .Title }}"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+	if string(files["post/a.html"]) != HTML("A") {
+		t.Errorf("Expected the title shouted, got: %q", files["post/a.html"])
+	}
+}
+
+func TestBuildDate(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n{{% built %}}"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	s.Info.BuildDate = time.Date(2013, 11, 1, 12, 0, 0, 0, time.UTC)
+	must(s.addTemplate("_default/single.html", "{{ .Content }}{{ .Site.Now.Year }}/{{ .Site.BuildDate.Month }}"))
+	must(s.addTemplate("shortcodes/built.html", "{{ .Page.Site.Now.Day }} "))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.ProcessShortcodes())
+	must(s.RenderPages())
+
+	for name, expected := range map[string]string{"post/a.html": "<p>1</p>\n2013/November", "post/b.html": "2013/November"} {
+		if got := string(files[name]); !strings.Contains(got, expected) {
+			t.Errorf("%s expected to contain %q, got: %q", name, expected, got)
+		}
+	}
+}
+
+func TestHideFutureAtBuildDate(t *testing.T) {
+	s := &Site{
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\npublishdate: 2013-10-31\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\npublishdate: 2013-11-02\n---\n"), Section: "post"},
+		}},
+		Config: Config{HideFuture: true},
+	}
+	s.initializeSiteInfo()
+	s.Info.BuildDate = time.Date(2013, 11, 1, 12, 0, 0, 0, time.UTC)
+	must(s.CreatePages())
+
+	if len(s.Pages) != 1 || s.Pages[0].Title != "a" || len(s.held) != 1 {
+		t.Errorf("Expected only b to be held back at the build date, got pages %v and held %v", s.Pages, s.held)
+	}
+}
+
+func TestDeterministicBuild(t *testing.T) {
+	var sources []source.ByteSource
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		sources = append(sources, source.ByteSource{
+			Name:    "post/" + name + ".md",
+			Content: []byte("---\ntitle: " + name + "\ndate: 2013-01-01\ntags: [" + name + ", x" + name + "]\n---\n"),
+			Section: "post",
+		})
+	}
+	build := func() map[string][]byte {
+		files := make(map[string][]byte)
+		s := &Site{
+			Target: &target.InMemoryTarget{Files: files},
+			Source: &source.InMemorySource{ByteSource: sources},
+			Config: Config{BaseUrl: "http://auth/", Deterministic: true, Indexes: map[string]string{"tag": "tags"}},
+		}
+		s.initializeSiteInfo()
+		s.prepTemplates()
+		must(s.addTemplate("_default/single.html", "{{ .Site.Now.Unix }}{{ range .Site.Indexes.tags }} {{ .Name }}{{ end }}"))
+		must(s.addTemplate("_default/list.html", "{{ range .Site.Pages }}{{ .Title }} {{ end }}{{ range .Data.Pages }} {{ .Title }}{{ end }}"))
+		must(s.CreatePages())
+		must(s.BuildSiteMeta())
+		must(s.Render())
+		return files
+	}
+
+	first := build()
+	if got := string(first["post/a.html"]); !strings.Contains(got, fmt.Sprint(time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC).Unix())) {
+		t.Errorf("Expected the date of the last change as build date, got: %q", got)
+	}
+	for i := 0; i < 5; i++ {
+		for name, content := range build() {
+			if string(content) != string(first[name]) {
+				t.Fatalf("%s differs between builds:\n%q\n%q", name, first[name], content)
+			}
+		}
+	}
+}
+
+func TestContentEncoding(t *testing.T) {
+	sources := []source.ByteSource{
+		{Name: "bom.md", Content: []byte("\xef\xbb\xbf---\ntitle: bom\n---\ncafé")},
+		{Name: "utf16.md", Content: []byte("\xff\xfe-\x00-\x00-\x00\n\x00t\x00i\x00t\x00l\x00e\x00:\x00 \x00u\x00\n\x00-\x00-\x00-\x00\n\x00\xe9\x00")},
+		{Name: "latin1.md", Content: []byte("---\ntitle: latin1\n---\ncaf\xe9")},
+	}
+
+	s := &Site{Source: &source.InMemorySource{ByteSource: sources}, Config: Config{ContentEncoding: "latin1"}}
+	s.initializeSiteInfo()
+	must(s.CreatePages())
+	if len(s.Pages) != 3 {
+		t.Fatalf("Expected 3 pages, got: %d", len(s.Pages))
+	}
+	for _, p := range s.Pages {
+		if !strings.Contains(string(p.Content), "é") || p.Title == "" {
+			t.Errorf("Expected %s to be read as UTF-8 with its frontmatter, got title %q and %q", p.FileName, p.Title, p.Content)
+		}
+	}
+
+	s = &Site{Source: &source.InMemorySource{ByteSource: sources}}
+	s.initializeSiteInfo()
+	if err := s.CreatePages(); err == nil || !strings.Contains(err.Error(), "latin1.md") {
+		t.Errorf("Expected an error naming the file that isn't UTF-8, got: %v", err)
+	}
+}
+
+func TestThemeLayouts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"themes/plain/layouts/_default/single.html": "theme single",
+		"themes/plain/layouts/index.html":           "theme index",
+		"layouts/_default/single.html":              "site single",
+		"content/a.md":                              "---\ntitle: a\n---\n",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		must(os.MkdirAll(filepath.Dir(name), 0755))
+		must(ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	s := &Site{Config: Config{Path: dir, ContentDir: "content", LayoutDir: "layouts", ThemesDir: "themes", Theme: "plain"}}
+	must(s.checkDirectories())
+	must(s.prepTemplates())
+	for name, expected := range map[string]string{"_default/single.html": "site single", "index.html": "theme index"} {
+		out := new(bytes.Buffer)
+		must(s.Tmpl.ExecuteTemplate(out, name, nil))
+		if out.String() != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, out.String())
+		}
+	}
+
+	must(os.RemoveAll(filepath.Join(dir, "layouts")))
+	if err := s.checkDirectories(); err != nil {
+		t.Errorf("Expected the layouts of the theme to do without the site's, got: %s", err)
+	}
+	s.Config.Theme = ""
+	if err := s.checkDirectories(); err == nil {
+		t.Errorf("Expected an error without layouts")
+	}
+}
+
+func TestThemeComponents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-themes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"themes/blog/layouts/index.html":           "blog index",
+		"themes/shared/layouts/chrome/header.html": "shared header",
+		"themes/base/layouts/chrome/header.html":   "base header",
+		"themes/base/layouts/index.html":           "base index",
+		"themes/base/layouts/shortcodes/note.html": "base note",
+		"layouts/_default/single.html":             "site single",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		must(os.MkdirAll(filepath.Dir(name), 0755))
+		must(ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	s := &Site{Config: Config{Path: dir, LayoutDir: "layouts", ThemesDir: "themes", Theme: "blog", Themes: []string{"shared", "base"}}}
+	if dirs := s.Config.GetThemeDirs(); len(dirs) != 3 || filepath.Base(dirs[0]) != "blog" || filepath.Base(dirs[2]) != "base" {
+		t.Errorf("Unexpected theme directories: %v", dirs)
+	}
+	must(s.prepTemplates())
+	for name, expected := range map[string]string{
+		"index.html":           "blog index",
+		"chrome/header.html":   "shared header",
+		"shortcodes/note.html": "base note",
+		"_default/single.html": "site single",
+	} {
+		out := new(bytes.Buffer)
+		must(s.Tmpl.ExecuteTemplate(out, name, nil))
+		if out.String() != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, out.String())
+		}
+	}
+
+	s.Config.Theme = ""
+	if dirs := s.Config.GetThemeDirs(); len(dirs) != 2 || filepath.Base(dirs[0]) != "shared" {
+		t.Errorf("Expected the components without a theme, got: %v", dirs)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/spf13/hugo/source"
 	"github.com/spf13/hugo/target"
 	"html/template"
+	"strings"
 	"testing"
 )
 
@@ -37,7 +38,10 @@ type InMemoryAliasTarget struct {
 }
 
 func (t *InMemoryAliasTarget) Publish(label string, permalink template.HTML) (err error) {
-	f, _ := t.Translate(label)
+	f, err := t.Translate(label)
+	if err != nil {
+		return
+	}
 	t.files[f] = []byte("--dummy text--")
 	return
 }
@@ -97,3 +101,33 @@ func TestPageCount(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidAliases(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Alias:  &InMemoryAliasTarget{files: files},
+		Source: &source.InMemorySource{ByteSource: []source.ByteSource{
+			{Name: "post/a.md", Content: []byte("---\ntitle: a\naliases: [\"old/a/\", \"../../a\"]\n---\n"), Section: "post"},
+			{Name: "post/b.md", Content: []byte("---\ntitle: b\naliases: ['C:\\old\\b.html']\n---\n"), Section: "post"},
+		}},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+
+	err := s.RenderAliases()
+	errs, ok := err.(RenderErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Expected an error for each page with an invalid alias, got: %v", err)
+	}
+	for i, prefix := range []string{"post/a.md: alias ../../a:", "post/b.md: alias C:\\old\\b.html:"} {
+		if !strings.HasPrefix(errs[i].Error(), prefix) {
+			t.Errorf("Expected the error to name the page and the alias, %q, got: %q", prefix, errs[i])
+		}
+	}
+	if _, ok := files["old/a/index.html"]; !ok {
+		t.Errorf("Expected the valid alias to be written, got: %v", renderedFiles(files))
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/hugo/target"
+)
+
+// Collision is a path below the publish dir that a page is rendered to and
+// a static file is copied to.  Which one ends up there depends on StaticWins.
+type Collision struct {
+	Path   string // below the publish dir, with slashes
+	Static string // the static file
+	Source string // the content file, or the output path, of the page
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("%s is rendered from %s and copied from %s", c.Path, c.Source, c.Static)
+}
+
+// outputs records where the pages of a build were published and what they
+// were rendered from.
+type outputs struct {
+	mu      sync.Mutex
+	sources map[string]string // path below the publish dir, source
+}
+
+func (o *outputs) add(path, source string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.sources == nil {
+		o.sources = make(map[string]string)
+	}
+	o.sources[path] = source
+}
+
+func (o *outputs) reset() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sources = nil
+}
+
+// initOutputs creates the record of the outputs, before the render workers
+// share it.
+func (s *Site) initOutputs() {
+	if s.outputs == nil {
+		s.outputs = new(outputs)
+	}
+}
+
+// recordOutput notes that the page rendered from source is published to
+// path, translated by the Target to where it lands below the publish dir.
+func (s *Site) recordOutput(path, source string) {
+	s.initOutputs()
+	if tr, ok := s.Target.(target.Translator); ok {
+		if dest, err := tr.Translate(path); err == nil {
+			path = dest
+		}
+	}
+	if rel, err := filepath.Rel(s.absPublishDir(), filepath.FromSlash(path)); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	s.outputs.add(strings.TrimPrefix(filepath.ToSlash(path), "/"), source)
+}
+
+// StaticCopied tells whether the static file rel, its path below a static
+// dir with slashes, is copied to the publish dir: unless it matches one of
+// StaticExclude, such as Sass sources, or when it matches one of
+// StaticPassthrough too.
+func (c *Config) StaticCopied(rel string) (bool, error) {
+	excluded, err := matchStatic(c.StaticExclude, rel)
+	if err != nil || !excluded {
+		return err == nil, err
+	}
+	return matchStatic(c.StaticPassthrough, rel)
+}
+
+// matchStatic tells whether rel matches one of patterns.  A pattern without
+// a slash matches the name of the file or of one of its dirs, e.g. "*.psd"
+// or "sass", one with a slash the path of the file or of one of its dirs,
+// e.g. "css/src".
+func matchStatic(patterns []string, rel string) (bool, error) {
+	names := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		for i := range names {
+			name := names[i]
+			if strings.Contains(pattern, "/") {
+				name = strings.Join(names[:i+1], "/")
+			}
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return false, fmt.Errorf("Invalid static file pattern %q: %s", pattern, err)
+			}
+			if ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// staticFiles are the files copied from the static dirs, by their path below
+// the publish dir.  A file of the site replaces the one of a theme.
+func (s *Site) staticFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range s.Config.GetStaticDirs() {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, name)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if copied, err := s.Config.StaticCopied(rel); !copied {
+				return err
+			}
+			files[rel] = name
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// StaticCollisions are the pages of the last build published where a static
+// file is copied too, sorted by path.
+func (s *Site) StaticCollisions() ([]Collision, error) {
+	if s.outputs == nil {
+		return nil, nil
+	}
+	files, err := s.staticFiles()
+	if err != nil {
+		return nil, err
+	}
+	s.outputs.mu.Lock()
+	defer s.outputs.mu.Unlock()
+	var collisions []Collision
+	for p, source := range s.outputs.sources {
+		if static, ok := files[p]; ok {
+			collisions = append(collisions, Collision{Path: p, Static: static, Source: source})
+		}
+	}
+	sort.Sort(collisionsByPath(collisions))
+	return collisions, nil
+}
+
+type collisionsByPath []Collision
+
+func (c collisionsByPath) Len() int           { return len(c) }
+func (c collisionsByPath) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c collisionsByPath) Less(i, j int) bool { return c[i].Path < c[j].Path }
+
+// RenderStatic warns about every page colliding with a static file.  With
+// StaticWins set the static file is published over the page, otherwise the
+// page, rendered after the static files were copied, is left in place.
+func (s *Site) RenderStatic() error {
+	collisions, err := s.StaticCollisions()
+	if err != nil {
+		return err
+	}
+	for _, c := range collisions {
+		kept := "the page"
+		if s.Config.StaticWins {
+			kept = "the static file"
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %s, keeping %s\n", c, kept)
+		if s.Config.StaticWins {
+			if err = s.copyStatic(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyStatic publishes the static file of c as is.
+func (s *Site) copyStatic(c Collision) error {
+	f, err := os.Open(c.Static)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if fp, ok := s.Target.(filePublisher); ok {
+		return fp.PublishFile(c.Path, f)
+	}
+	return s.Target.Publish(c.Path, f)
+}
+
+// staticSynced keeps the winners of the collisions in place after
+// StaticSync copied the static files again.  The pages are rendered again
+// when they win, as the copy replaced them.
+func (s *Site) staticSynced() error {
+	if s.Config.StaticWins {
+		return s.RenderStatic()
+	}
+	collisions, err := s.StaticCollisions()
+	if err != nil || len(collisions) == 0 {
+		return err
+	}
+	return s.Render()
+}
@@ -0,0 +1,84 @@
+package hugolib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/hugo/target"
+)
+
+func TestStaticCollisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"static/about/index.html": "static about",
+		"static/css/site.css":     "body {}",
+		"public/about/index.html": "static about",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		must(os.MkdirAll(filepath.Dir(name), 0755))
+		must(ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	for _, staticWins := range []bool{false, true} {
+		s := &Site{Config: Config{Path: dir, StaticDir: "static", PublishDir: "public", StaticWins: staticWins}}
+		s.Target = &target.Filesystem{PublishDir: s.absPublishDir()}
+		must(s.writePublic("about/", "about.md", bytes.NewBufferString("rendered about")))
+		must(s.writePublic("css/other.css", "other.md", bytes.NewBufferString("rendered css")))
+
+		collisions, err := s.StaticCollisions()
+		must(err)
+		expected := Collision{Path: "about/index.html", Static: filepath.Join(dir, "static", "about", "index.html"), Source: "about.md"}
+		if len(collisions) != 1 || collisions[0] != expected {
+			t.Errorf("Expected the collision %v, got: %v", expected, collisions)
+		}
+
+		must(s.RenderStatic())
+		content, err := ioutil.ReadFile(filepath.Join(dir, "public", "about", "index.html"))
+		must(err)
+		want := "rendered about"
+		if staticWins {
+			want = "static about"
+		}
+		if string(content) != want {
+			t.Errorf("Expected %q with StaticWins %v, got: %q", want, staticWins, content)
+		}
+	}
+}
+
+func TestStaticCopied(t *testing.T) {
+	c := &Config{
+		StaticExclude:     []string{"*.scss", "*.psd", "src/", "css/drafts"},
+		StaticPassthrough: []string{"vendor"},
+	}
+	for rel, expected := range map[string]bool{
+		"css/site.css":             true,
+		"css/site.scss":            false,
+		"images/logo.psd":          false,
+		"src/app.js":               false,
+		"js/src/app.js":            false,
+		"css/drafts/new.css":       false,
+		"other/css/drafts/new.css": true,
+		"vendor/bootstrap.scss":    true,
+		"srcset.html":              true,
+	} {
+		copied, err := c.StaticCopied(rel)
+		if err != nil {
+			t.Fatalf("Unable to match %s: %s", rel, err)
+		}
+		if copied != expected {
+			t.Errorf("Expected %s to be copied: %v, got: %v", rel, expected, copied)
+		}
+	}
+
+	c.StaticExclude = []string{"[*.scss"}
+	if _, err := c.StaticCopied("a.scss"); err == nil {
+		t.Errorf("Expected an invalid pattern to be reported")
+	}
+}
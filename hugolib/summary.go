@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 var summaryLength = 70
@@ -14,6 +16,31 @@ func TotalWords(s string) int {
 	return len(strings.Fields(s))
 }
 
+// TotalWordsCJK counts words in text mixing CJK and other scripts.  Every
+// Chinese, Japanese or Korean character counts as a word since those
+// languages don't separate words with spaces.
+func TotalWordsCJK(s string) int {
+	n := 0
+	inWord := false
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			n++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case !inWord:
+			n++
+			inWord = true
+		}
+	}
+	return n
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
 func WordCount(s string) map[string]int {
 	m := make(map[string]int)
 	for _, f := range strings.Fields(s) {
@@ -54,6 +81,46 @@ func TruncateWordsToWholeSentence(s string, max int) string {
 	return strings.Join(words[:max], " ")
 }
 
+// TruncateWordsToWholeSentenceCJK is TruncateWordsToWholeSentence counting
+// words the way TotalWordsCJK does and also ending sentences on CJK
+// punctuation.
+func TruncateWordsToWholeSentenceCJK(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	n := 0
+	inWord := false
+	for i, r := range s {
+		switch {
+		case isCJK(r):
+			n++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case !inWord:
+			n++
+			inWord = true
+		}
+
+		if n < max {
+			continue
+		}
+
+		end := i + utf8.RuneLen(r)
+		switch r {
+		case '。', '！', '？':
+			return s[:end]
+		case '.', '?', '!', '"':
+			if end == len(s) || s[end] == ' ' {
+				if r != '"' || strings.HasSuffix(s[:end], ".\"") {
+					return s[:end]
+				}
+			}
+		}
+	}
+
+	return s
+}
+
 func getRstContent(content []byte) string {
 	cleanContent := bytes.Replace(content, summaryDivider, []byte(""), 1)
 
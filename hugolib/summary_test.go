@@ -0,0 +1,65 @@
+package hugolib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTotalWordsCJK(t *testing.T) {
+	tests := []struct {
+		text  string
+		count int
+	}{
+		{"hello world", 2},
+		{"你好世界", 4},
+		{"Hugo是一个静态网站生成器", 11},
+		{"こんにちは、 world!", 7},
+		{"", 0},
+	}
+
+	for _, test := range tests {
+		if count := TotalWordsCJK(test.text); count != test.count {
+			t.Errorf("TotalWordsCJK(%q) expected: %d, got: %d", test.text, test.count, count)
+		}
+	}
+}
+
+func TestTruncateWordsToWholeSentenceCJK(t *testing.T) {
+	tests := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"第一句话。第二句话。", 3, "第一句话。"},
+		{"第一句话。第二句话。", 6, "第一句话。第二句话。"},
+		{"One two. Three four.", 1, "One two."},
+		{"短", 5, "短"},
+	}
+
+	for _, test := range tests {
+		if got := TruncateWordsToWholeSentenceCJK(test.text, test.max); got != test.expected {
+			t.Errorf("TruncateWordsToWholeSentenceCJK(%q, %d) expected: %q, got: %q", test.text, test.max, test.expected, got)
+		}
+	}
+}
+
+func TestPageCJKWordCount(t *testing.T) {
+	content := "---\ntitle: cjk\n---\n" + strings.Repeat("这是一个句子。", 20)
+	p, err := readFrom(strings.NewReader(content), "content/cjk.md", SiteInfo{Config: &Config{HasCJKLanguage: true}})
+	if err != nil {
+		t.Fatalf("Unable to read page: %s", err)
+	}
+
+	if p.WordCount != 140 {
+		t.Errorf("WordCount expected: 140, got: %d", p.WordCount)
+	}
+
+	if p.ReadingTime != 1 {
+		t.Errorf("ReadingTime expected: 1, got: %d", p.ReadingTime)
+	}
+
+	expected := strings.Repeat("这是一个句子。", 10)
+	if string(p.Summary) != expected {
+		t.Errorf("Summary expected: %q, got: %q", expected, p.Summary)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// titleFunc turns the names of sections, indexes and terms into titles:
+// Site.TitleFunc when set, else the one of Config.TitleCase, "title"
+// (strings.Title, the default), "ap" (see apTitle) or "none".
+func (s *Site) titleFunc() func(string) string {
+	if s.TitleFunc != nil {
+		return s.TitleFunc
+	}
+	switch strings.ToLower(s.Config.TitleCase) {
+	case "none":
+		return func(name string) string { return name }
+	case "ap":
+		return apTitle
+	}
+	return strings.Title
+}
+
+func (s *SiteInfo) title(name string) string {
+	if s.titleFunc == nil {
+		return strings.Title(name)
+	}
+	return s.titleFunc(name)
+}
+
+// Short articles, conjunctions and prepositions AP style leaves lower case.
+var apSmallWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "but": true,
+	"by": true, "for": true, "if": true, "in": true, "nor": true, "of": true,
+	"on": true, "or": true, "so": true, "the": true, "to": true, "up": true,
+	"yet": true,
+}
+
+// apTitle capitalizes name in AP style: every word but the small ones,
+// unless they come first or last. Words with capitals of their own, like
+// "macOS" or "NASA", are left as they are.
+func apTitle(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		if strings.IndexFunc(word, unicode.IsUpper) != -1 {
+			continue
+		}
+		if i > 0 && i < len(words)-1 && apSmallWords[word] {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToTitle(r)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
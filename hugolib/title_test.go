@@ -0,0 +1,72 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"strings"
+	"testing"
+)
+
+func TestAPTitle(t *testing.T) {
+	for in, expected := range map[string]string{
+		"war and peace":         "War and Peace",
+		"the lord of the rings": "The Lord of the Rings",
+		"what it is for":        "What It Is For",
+		"macOS tips":            "macOS Tips",
+		"NASA":                  "NASA",
+		"école":                 "École",
+	} {
+		if got := apTitle(in); got != expected {
+			t.Errorf("Expected %q from %q, got: %q", expected, in, got)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	sources := []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\ntags: [go]\n---\n"), Section: "post"},
+	}
+	for _, test := range []struct {
+		titleCase     string
+		titleFunc     func(string) string
+		section, term string
+	}{
+		{"", nil, "Posts", "Go"},
+		{"none", nil, "posts", "go"},
+		{"ap", nil, "Posts", "Go"},
+		{"none", strings.ToUpper, "POSTS", "GO"},
+	} {
+		s := &Site{
+			Source:    &source.InMemorySource{ByteSource: sources},
+			Config:    Config{BaseUrl: "http://auth/", TitleCase: test.titleCase, Indexes: map[string]string{"tag": "tags"}},
+			TitleFunc: test.titleFunc,
+		}
+		s.initializeSiteInfo()
+		must(s.CreatePages())
+		must(s.BuildSiteMeta())
+		if title := s.Info.Sections[0].Title; title != test.section {
+			t.Errorf("Expected section title %q with %q, got: %q", test.section, test.titleCase, title)
+		}
+		if title := s.newIndexNode("tag", "go", s.Indexes["tags"]["go"]).Title; title != test.term {
+			t.Errorf("Expected term title %q with %q, got: %q", test.term, test.titleCase, title)
+		}
+	}
+}
+
+func TestTermTitleKeepsSpelling(t *testing.T) {
+	sources := []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\ndate: 2013-01-02\ntags: [macOS]\n---\n"), Section: "post"},
+		{Name: "post/b.md", Content: []byte("---\ntitle: b\ndate: 2013-01-01\ntags: [macos]\n---\n"), Section: "post"},
+	}
+	for titleCase, expected := range map[string]string{"none": "macOS", "ap": "macOS", "": "MacOS"} {
+		s := &Site{
+			Source: &source.InMemorySource{ByteSource: sources},
+			Config: Config{BaseUrl: "http://auth/", TitleCase: titleCase, Indexes: map[string]string{"tag": "tags"}},
+		}
+		s.initializeSiteInfo()
+		must(s.CreatePages())
+		must(s.BuildSiteMeta())
+		if title := s.newIndexNode("tag", "macos", s.Indexes["tags"]["macos"]).Title; title != expected {
+			t.Errorf("Expected term title %q with %q, got: %q", expected, titleCase, title)
+		}
+	}
+}
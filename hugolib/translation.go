@@ -0,0 +1,105 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"github.com/spf13/hugo/transform"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Translations are the other versions of the page in other languages,
+// ordered by language code.
+func (p *Page) Translations() Pages { return p.translations }
+
+func (p *Page) IsTranslated() bool { return len(p.translations) > 0 }
+
+// translationKey tells which pages are translations of each other: those
+// with the same translationkey in their frontmatter or, by default, with
+// the same content file but for a suffix of their language, like
+// about.md and about.fr.md.
+func (p *Page) translationKey(lang string) string {
+	if p.translationKeyParam != "" {
+		return p.translationKeyParam
+	}
+	key := p.sourcePath()
+	key = strings.TrimSuffix(key, path.Ext(key))
+	if suffix := strings.ToLower(path.Ext(key)); suffix != "" && lang != "" {
+		if suffix == "."+lang || strings.HasPrefix(lang, suffix[1:]+"-") {
+			key = strings.TrimSuffix(key, path.Ext(key))
+		}
+	}
+	return key
+}
+
+// buildTranslations links the rendered pages to their translations.
+func (s *Site) buildTranslations() {
+	lang := func(p *Page) string {
+		if p.languageCode != "" {
+			return strings.ToLower(p.languageCode)
+		}
+		return strings.ToLower(s.Info.LanguageCode)
+	}
+
+	groups := make(map[string]Pages)
+	var keys []string
+	for _, pages := range []Pages{s.Pages, s.Unlisted} {
+		for _, p := range pages {
+			p.translations = nil
+			if !p.Build.Render {
+				continue
+			}
+			key := p.translationKey(lang(p))
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], p)
+		}
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.Stable(pagesByLanguage{group, lang})
+		for _, p := range group {
+			for _, t := range group {
+				if t != p {
+					p.translations = append(p.translations, t)
+				}
+			}
+		}
+	}
+}
+
+type pagesByLanguage struct {
+	Pages
+	lang func(*Page) string
+}
+
+func (p pagesByLanguage) Less(i, j int) bool { return p.lang(p.Pages[i]) < p.lang(p.Pages[j]) }
+
+// hreflang lists page and its translations for the Hreflang transformer.
+func (s *Site) hreflang(page *Page) (alternates []transform.Alternate, err error) {
+	for _, p := range append(Pages{page}, page.translations...) {
+		plink, err := p.Permalink()
+		if err != nil {
+			return nil, err
+		}
+		alternates = append(alternates, transform.Alternate{Lang: p.LanguageCode(), Href: plink})
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"strings"
+	"testing"
+)
+
+var translationSources = []source.ByteSource{
+	{Name: "about.md", Content: []byte("---\ntitle: about\n---\n")},
+	{Name: "about.fr.md", Content: []byte("---\ntitle: a propos\nlanguagecode: fr\n---\n")},
+	{Name: "post/hello.md", Content: []byte("---\ntitle: hello\ntranslationkey: hello\n---\n"), Section: "post"},
+	{Name: "post/hallo.md", Content: []byte("---\ntitle: hallo\nlanguagecode: de\ntranslationkey: hello\n---\n"), Section: "post"},
+	{Name: "post/jquery.min.md", Content: []byte("---\ntitle: jquery\n---\n"), Section: "post"},
+}
+
+func TestTranslations(t *testing.T) {
+	files := make(map[string][]byte)
+	s := &Site{
+		Target: &target.InMemoryTarget{Files: files},
+		Source: &source.InMemorySource{ByteSource: translationSources},
+		Config: Config{BaseUrl: "http://auth/", LanguageCode: "en", Hreflang: true},
+	}
+	s.initializeSiteInfo()
+	s.prepTemplates()
+	must(s.addTemplate("_default/single.html", "<html><head></head><body>{{ .Title }}:{{ range .Translations }} {{ .Title }}{{ end }}</body></html>"))
+	must(s.CreatePages())
+	must(s.BuildSiteMeta())
+	must(s.RenderPages())
+
+	for _, test := range []struct {
+		file, expected string
+	}{
+		{"about.html", "about: a propos"},
+		{"about.fr.html", "a propos: about"},
+		{"post/hello.html", "hello: hallo"},
+		{"post/hallo.html", "hallo: hello"},
+		{"post/jquery.min.html", "jquery:"},
+	} {
+		if !strings.Contains(string(files[test.file]), "<body>"+test.expected+"</body>") {
+			t.Errorf("Expected %q in %s, got: %q", test.expected, test.file, files[test.file])
+		}
+	}
+
+	links := `<link rel="alternate" hreflang="de" href="http://auth/post/hallo"><link rel="alternate" hreflang="en" href="http://auth/post/hello"></head>`
+	if !strings.Contains(string(files["post/hallo.html"]), links) {
+		t.Errorf("Expected hreflang links to the translations, got: %q", files["post/hallo.html"])
+	}
+	if strings.Contains(string(files["post/jquery.min.html"]), "hreflang") {
+		t.Errorf("Expected no hreflang links without translations, got: %q", files["post/jquery.min.html"])
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"github.com/howeyc/fsnotify"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Changes are handled once files stopped changing for watchDelay, so an
+// editor saving several files at once causes a single rebuild.
+var watchDelay = 100 * time.Millisecond
+
+// Watch keeps a built site up to date until stop is closed, watching its
+// content, layout and static directories. Changed content and layouts are
+// rebuilt (see ReBuild) and changed static files are handed to StaticSync.
+// Rebuilt, when set, is called after each round of changes with the error
+// it ended with.
+func (s *Site) Watch(stop chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{s.Config.ContentDir, s.Config.LayoutDir, s.Config.StaticDir, s.Config.DataDir, s.Config.I18nDir} {
+		watchDir(watcher, s.Config.GetAbsPath(dir))
+	}
+	for _, theme := range s.Config.GetThemeDirs() {
+		watchDir(watcher, theme)
+	}
+
+	pending := make(map[string]bool)
+	var quiet <-chan time.Time
+	for {
+		select {
+		case ev := <-watcher.Event:
+			if ignoreChange(ev.Name) {
+				continue
+			}
+			if s.Config.Verbose {
+				fmt.Println(ev)
+			}
+			if ev.IsCreate() {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					watchDir(watcher, ev.Name)
+				}
+			}
+			pending[ev.Name] = true
+			quiet = time.After(watchDelay)
+		case err := <-watcher.Error:
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error watching files:", err)
+			}
+		case <-quiet:
+			var names []string
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pending = make(map[string]bool)
+			quiet = nil
+
+			err := s.changed(names)
+			if s.Rebuilt != nil {
+				s.Rebuilt(err)
+			} else if err != nil {
+				fmt.Fprintln(os.Stderr, "Error rebuilding site:", err)
+			}
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// watchDir watches dir and every directory below it.
+func watchDir(watcher *fsnotify.Watcher, dir string) {
+	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err == nil && fi.IsDir() {
+			if err = watcher.Watch(path); err != nil {
+				fmt.Fprintln(os.Stderr, "Unable to watch", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ignoreChange tells the files editors keep next to the ones being edited.
+func ignoreChange(name string) bool {
+	base := filepath.Base(name)
+	return strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") || strings.HasPrefix(base, "#")
+}
+
+// changed brings the site up to date after the named files changed.
+func (s *Site) changed(names []string) error {
+	var statics []string
+	for _, dir := range s.Config.GetStaticDirs() {
+		statics = append(statics, filepath.Clean(filepath.FromSlash(dir)))
+	}
+	var rebuild []string
+	var syncStatic bool
+	for _, name := range names {
+		if inDirs(filepath.Clean(name), statics) {
+			syncStatic = true
+		} else {
+			rebuild = append(rebuild, name)
+		}
+	}
+
+	if syncStatic && s.StaticSync != nil {
+		if s.Config.Verbose {
+			fmt.Println("Static file changed, syncing")
+		}
+		if err := s.StaticSync(); err != nil {
+			return err
+		}
+		if err := s.staticSynced(); err != nil {
+			return err
+		}
+	}
+	if len(rebuild) > 0 {
+		if s.Config.Verbose {
+			fmt.Println("Change detected, rebuilding site")
+		}
+		return s.ReBuild(rebuild)
+	}
+	return nil
+}
+
+// inDirs tells whether name is one of dirs or below one of them.
+func inDirs(name string, dirs []string) bool {
+	for _, dir := range dirs {
+		if name == dir || strings.HasPrefix(name, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package hugolib
+
+import (
+	"github.com/spf13/hugo/source"
+	"github.com/spf13/hugo/target"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreChange(t *testing.T) {
+	for name, ignored := range map[string]bool{
+		"content/post/a.md":       false,
+		"content/post/.a.md.swp":  true,
+		"content/post/a.md~":      true,
+		"content/post/#a.md#":     true,
+		"layouts/_default/a.html": false,
+	} {
+		if ignoreChange(filepath.FromSlash(name)) != ignored {
+			t.Errorf("%s expected to be ignored: %t", name, ignored)
+		}
+	}
+}
+
+func TestChangedSyncsStaticAndRebuildsContent(t *testing.T) {
+	files := make(map[string][]byte)
+	s := rebuildSite(files, []source.ByteSource{
+		{Name: "post/a.md", Content: []byte("---\ntitle: a\n---\n"), Section: "post"},
+	})
+	s.Config.Path = filepath.FromSlash("/site")
+	s.Config.ContentDir, s.Config.StaticDir = "content", "static"
+
+	synced := 0
+	s.StaticSync = func() error {
+		synced++
+		return nil
+	}
+	s.Target = &target.InMemoryTarget{Files: files}
+	for name := range files {
+		delete(files, name)
+	}
+
+	must(s.changed([]string{filepath.FromSlash("/site/static/css/a.css")}))
+	if synced != 1 || len(files) != 0 {
+		t.Errorf("Expected a static change to only sync static files, synced %d times and rendered %v", synced, renderedFiles(files))
+	}
+
+	must(s.changed([]string{filepath.FromSlash("/site/content/post/a.md"), filepath.FromSlash("/site/static/a.css")}))
+	if synced != 2 || len(files["post/a.html"]) == 0 {
+		t.Errorf("Expected static files to be synced and a to be rendered, synced %d times and rendered %v", synced, renderedFiles(files))
+	}
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+var ErrInvalidUTF8 = errors.New("not valid UTF-8, set the encoding of the content")
+
+// windows1252 are the characters of windows-1252 differing from latin1,
+// from 0x80 to 0x9f. The bytes it leaves undefined map to themselves.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// ToUTF8 converts content to UTF-8. A byte order mark tells UTF-8 and
+// UTF-16 apart and is dropped. Without one content is read in encoding:
+// "utf-8" (the default), "utf-16le", "utf-16be", "latin1" (or
+// "iso-8859-1") and "windows-1252" (or "cp1252"). UTF-8 content that isn't
+// valid UTF-8 is an error rather than garbage in the output.
+func ToUTF8(content []byte, encoding string) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		content, encoding = content[len(bomUTF8):], "utf-8"
+	case bytes.HasPrefix(content, bomUTF16LE):
+		content, encoding = content[len(bomUTF16LE):], "utf-16le"
+	case bytes.HasPrefix(content, bomUTF16BE):
+		content, encoding = content[len(bomUTF16BE):], "utf-16be"
+	}
+
+	switch strings.ToLower(encoding) {
+	case "", "utf-8", "utf8":
+		if !utf8.Valid(content) {
+			return nil, ErrInvalidUTF8
+		}
+		return content, nil
+	case "utf-16le":
+		return fromUTF16(content, func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+	case "utf-16be":
+		return fromUTF16(content, func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+	case "latin1", "iso-8859-1":
+		return fromSingleByte(content, nil), nil
+	case "windows-1252", "cp1252":
+		return fromSingleByte(content, windows1252[:]), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+func fromUTF16(content []byte, unit func([]byte) uint16) ([]byte, error) {
+	if len(content)%2 != 0 {
+		return nil, errors.New("not valid UTF-16, odd number of bytes")
+	}
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = unit(content[2*i:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// fromSingleByte reads content as latin1, with high, when given, replacing
+// the characters from 0x80 to 0x9f.
+func fromSingleByte(content []byte, high []rune) []byte {
+	out := bytes.NewBuffer(make([]byte, 0, len(content)))
+	for _, b := range content {
+		r := rune(b)
+		if high != nil && b >= 0x80 && b <= 0x9f {
+			r = high[b-0x80]
+		}
+		out.WriteRune(r)
+	}
+	return out.Bytes()
+}
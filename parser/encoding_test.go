@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		content  []byte
+		encoding string
+		expected string
+	}{
+		{[]byte("---\ntitle: é\n"), "", "---\ntitle: é\n"},
+		{[]byte("\xef\xbb\xbf---\n"), "", "---\n"},
+		{[]byte("\xef\xbb\xbf---\n"), "latin1", "---\n"},
+		{[]byte("\xff\xfe-\x00\xe9\x00"), "", "-é"},
+		{[]byte("\xfe\xff\x00-\x00\xe9"), "", "-é"},
+		{[]byte("-\x00\xe9\x00"), "UTF-16LE", "-é"},
+		{[]byte("caf\xe9 \x93a\x94"), "latin1", "café \u0093a\u0094"},
+		{[]byte("caf\xe9 \x93a\x94 \x80"), "windows-1252", "café “a” €"},
+	}
+	for _, test := range tests {
+		out, err := ToUTF8(test.content, test.encoding)
+		if err != nil {
+			t.Errorf("Unexpected error converting %q: %s", test.content, err)
+		}
+		if string(out) != test.expected {
+			t.Errorf("Expected %q from %q in %q, got: %q", test.expected, test.content, test.encoding, out)
+		}
+	}
+
+	if _, err := ToUTF8([]byte("caf\xe9"), ""); err != ErrInvalidUTF8 {
+		t.Errorf("Expected invalid UTF-8 to be an error, got: %v", err)
+	}
+	if _, err := ToUTF8([]byte("a"), "ebcdic"); err == nil {
+		t.Errorf("Expected an unknown encoding to be an error")
+	}
+	if _, err := ToUTF8([]byte("\xff\xfea"), ""); err == nil {
+		t.Errorf("Expected an odd number of UTF-16 bytes to be an error")
+	}
+}
@@ -101,7 +101,7 @@ func chompWhitespace(r io.RuneScanner) (err error) {
 		if err != nil {
 			return err
 		}
-		if !unicode.IsSpace(c) {
+		if !unicode.IsSpace(c) && c != '\ufeff' { // byte order mark
 			r.UnreadRune()
 			return nil
 		}
@@ -169,11 +169,14 @@ func determineDelims(firstLine []byte) (left, right []byte) {
 }
 
 func extractFrontMatterDelims(r *bufio.Reader, left, right []byte) (fm FrontMatter, err error) {
+	if bytes.Equal(left, right) {
+		return extractFrontMatterLines(r, bytes.TrimRight(left, "\r\n"))
+	}
+
 	var (
 		c         byte
 		level     int = 0
 		bytesRead int = 0
-		sameDelim     = bytes.Equal(left, right)
 	)
 
 	wr := new(bytes.Buffer)
@@ -197,15 +200,7 @@ func extractFrontMatterDelims(r *bufio.Reader, left, right []byte) (fm FrontMatt
 			buf = append(buf, remaining...)
 
 			if bytes.Equal(buf, left) {
-				if sameDelim {
-					if level == 0 {
-						level = 1
-					} else {
-						level = 0
-					}
-				} else {
-					level += 1
-				}
+				level += 1
 			}
 
 			if _, err = wr.Write([]byte{c}); err != nil {
@@ -246,6 +241,28 @@ func extractFrontMatterDelims(r *bufio.Reader, left, right []byte) (fm FrontMatt
 	return nil, errors.New("Could not find front matter.")
 }
 
+// extractFrontMatterLines reads the frontmatter between two delim lines.
+// Lines may end in "\n" or "\r\n", whatever the opening line ends in, as
+// editors on Windows don't always keep line endings consistent.
+func extractFrontMatterLines(r *bufio.Reader, delim []byte) (fm FrontMatter, err error) {
+	wr := new(bytes.Buffer)
+	for first := true; ; first = false {
+		line, err := r.ReadBytes('\n')
+		wr.Write(line)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read frontmatter at filepos %d: %s", wr.Len(), err)
+		}
+		if !first && bytes.Equal(bytes.TrimRight(line, "\r\n"), delim) {
+			break
+		}
+	}
+
+	if err = chompWhitespace(r); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return wr.Bytes(), nil
+}
+
 func matches_quick(buf, expected []byte) (ok bool, err error) {
 	return bytes.Equal(expected, buf), nil
 }
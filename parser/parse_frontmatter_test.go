@@ -299,3 +299,24 @@ func TestExtractFrontMatterDelim(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractFrontMatterMixedLineEndings(t *testing.T) {
+	tests := []struct {
+		frontmatter string
+		extracted   string
+	}{
+		{"---\r\ntitle: a\n---\ncontent", "---\r\ntitle: a\n---\n"},
+		{"---\ntitle: a\r\n---\r\ncontent", "---\ntitle: a\r\n---\r\n"},
+		{"+++\r\ntitle = \"a\"\r\n+++\n\r\ncontent", "+++\r\ntitle = \"a\"\r\n+++\n"},
+	}
+	for _, test := range tests {
+		p, err := ReadFrom(strings.NewReader(test.frontmatter))
+		if err != nil {
+			t.Errorf("Unexpected error reading %q: %s", test.frontmatter, err)
+			continue
+		}
+		if string(p.FrontMatter()) != test.extracted || string(p.Content()) != "content" {
+			t.Errorf("Expected %q and the content from %q, got: %q %q", test.extracted, test.frontmatter, p.FrontMatter(), p.Content())
+		}
+	}
+}
@@ -12,6 +12,14 @@ type Input interface {
 	Files() []*File
 }
 
+// FileReader is an Input able to read one of its files again, for instance
+// after it changed. Names are relative to the source. A file which doesn't
+// exist any more gives an error satisfying os.IsNotExist.
+type FileReader interface {
+	Input
+	File(name string) (*File, error)
+}
+
 type File struct {
 	name        string
 	LogicalName string
@@ -41,20 +49,33 @@ func (f *Filesystem) add(name string, reader io.Reader) (err error) {
 		return err
 	}
 
+	f.files = append(f.files, newFile(name, reader))
+	return
+}
+
+// File opens the file at name, relative to Base, afresh.
+func (f *Filesystem) File(name string) (*File, error) {
+	reader, err := os.Open(filepath.Join(f.Base, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	return newFile(path.Clean(filepath.ToSlash(name)), reader), nil
+}
+
+func newFile(name string, reader io.Reader) *File {
 	dir, logical := path.Split(name)
 	_, section := path.Split(path.Dir(name))
 	if section == "." {
 		section = ""
 	}
 
-	f.files = append(f.files, &File{
+	return &File{
 		name:        name,
 		LogicalName: logical,
 		Contents:    reader,
 		Section:     section,
 		Dir:         dir,
-	})
-	return
+	}
 }
 
 func (f *Filesystem) getRelativePath(name string) (final string, err error) {
@@ -102,7 +123,7 @@ func (f *Filesystem) captureFiles() {
 
 func (f *Filesystem) avoid(filePath string) bool {
 	for _, avoid := range f.AvoidPaths {
-		if avoid == filePath {
+		if filepath.Clean(avoid) == filepath.Clean(filePath) {
 			return true
 		}
 	}
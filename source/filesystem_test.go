@@ -2,6 +2,8 @@ package source
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"testing"
@@ -69,3 +71,41 @@ func TestAddFile(t *testing.T) {
 		}
 	}
 }
+
+func TestAvoidCleansPaths(t *testing.T) {
+	src := &Filesystem{AvoidPaths: []string{filepath.Join(platformBase, "static") + string(filepath.Separator)}}
+	if !src.avoid(filepath.Join(platformBase, "static")) {
+		t.Errorf("A path should be avoided regardless of a trailing separator")
+	}
+	if src.avoid(filepath.Join(platformBase, "statics")) {
+		t.Errorf("Only the avoided path should be avoided")
+	}
+}
+
+func TestReadFileAgain(t *testing.T) {
+	base, err := ioutil.TempDir("", "hugo-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	if err = os.MkdirAll(filepath.Join(base, "post"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(base, "post", "a.md"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &Filesystem{Base: base}
+	file, err := src.File("post/a.md")
+	if err != nil {
+		t.Fatalf("Unable to read post/a.md: %s", err)
+	}
+	content, _ := ioutil.ReadAll(file.Contents)
+	if string(content) != "a" || file.LogicalName != "a.md" || file.Section != "post" || file.Dir != "post/" {
+		t.Errorf("Unexpected file: %+v %q", file, content)
+	}
+
+	if _, err = src.File("post/b.md"); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing file to not exist, got: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package source
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"path"
 )
 
@@ -23,12 +24,25 @@ type InMemorySource struct {
 func (i *InMemorySource) Files() (files []*File) {
 	files = make([]*File, len(i.ByteSource))
 	for i, fake := range i.ByteSource {
-		files[i] = &File{
-			LogicalName: fake.Name,
-			Contents:    bytes.NewReader(fake.Content),
-			Section:     fake.Section,
-			Dir:         path.Dir(fake.Name),
-		}
+		files[i] = fake.file()
 	}
 	return
 }
+
+func (i *InMemorySource) File(name string) (*File, error) {
+	for _, fake := range i.ByteSource {
+		if fake.Name == name {
+			return fake.file(), nil
+		}
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+func (b ByteSource) file() *File {
+	return &File{
+		LogicalName: b.Name,
+		Contents:    bytes.NewReader(b.Content),
+		Section:     b.Section,
+		Dir:         path.Dir(b.Name),
+	}
+}
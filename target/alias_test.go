@@ -20,6 +20,11 @@ func TestHTMLRedirectAlias(t *testing.T) {
 		{"alias 3.html", "alias-3.html"},
 		{"alias4.html", "alias4.html"},
 		{"/alias 5.html", "/alias-5.html"},
+		{"old/blog/post", "old/blog/post/index.html"},
+		{"old/blog/post/index.html", "old/blog/post/index.html"},
+		{"2010/old.htm", "2010/old.htm"},
+		{"/feed.xhtml", "/feed.xhtml"},
+		{"2013.01.05", "2013.01.05/index.html"},
 	}
 
 	for _, test := range tests {
@@ -33,3 +38,19 @@ func TestHTMLRedirectAlias(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidAliases(t *testing.T) {
+	o := &HTMLRedirectAlias{PublishDir: "/var/www/public"}
+	for _, alias := range []string{
+		"http://example.com/old/",
+		"//example.com/old/",
+		`C:\sites\old.html`,
+		"/var/www/public/old/",
+		"../../etc/passwd",
+		`old\..\..\up`,
+	} {
+		if path, err := o.Translate(alias); err == nil {
+			t.Errorf("Expected %s to be refused, got: %s", alias, path)
+		}
+	}
+}
@@ -21,10 +21,24 @@ type Output interface {
 	Translator
 }
 
+// Modes is the permissions given to published directories and files.  When
+// a mode is left zero the default is used and the umask applies, otherwise
+// the mode is set exactly.
+type Modes struct {
+	DirMode  os.FileMode
+	FileMode os.FileMode
+}
+
+const (
+	DefaultDirMode  os.FileMode = 0764 // rwx, rw, r
+	DefaultFileMode os.FileMode = 0666
+)
+
 type Filesystem struct {
 	UglyUrls         bool
 	DefaultExtension string
 	PublishDir       string
+	Modes
 }
 
 func (fs *Filesystem) Publish(path string, r io.Reader) (err error) {
@@ -34,31 +48,78 @@ func (fs *Filesystem) Publish(path string, r io.Reader) (err error) {
 		return
 	}
 
-	return writeToDisk(translated, r)
+	return fs.writeToDisk(translated, r)
+}
+
+// PublishFile writes r to path below the publish dir as is, without the
+// translation applied to rendered pages.
+func (fs *Filesystem) PublishFile(path string, r io.Reader) (err error) {
+	dest := filepath.Join(fs.PublishDir, filepath.FromSlash(path))
+	if err = checkPath(dest); err != nil {
+		return
+	}
+	return fs.writeToDisk(dest, r)
 }
 
-func writeToDisk(translated string, r io.Reader) (err error) {
-	path, _ := filepath.Split(translated)
-	ospath := filepath.FromSlash(path)
+func (m Modes) writeToDisk(translated string, r io.Reader) (err error) {
+	translated = filepath.FromSlash(translated)
+	ospath, _ := filepath.Split(translated)
 
 	if ospath != "" {
-		err = os.MkdirAll(ospath, 0764) // rwx, rw, r
+		err = m.mkdirAll(filepath.Clean(ospath))
 		if err != nil {
 			panic(err)
 		}
 	}
 
-	file, err := os.Create(translated)
+	mode := m.FileMode
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+	file, err := os.OpenFile(translated, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
 	if err != nil {
 		return
 	}
 	defer file.Close()
 
+	if m.FileMode != 0 {
+		if err = file.Chmod(m.FileMode); err != nil {
+			return
+		}
+	}
+
 	_, err = io.Copy(file, r)
 	return
 }
 
+// mkdirAll is os.MkdirAll, also setting DirMode on the directories it
+// creates.
+func (m Modes) mkdirAll(dir string) error {
+	if m.DirMode == 0 {
+		return os.MkdirAll(dir, DefaultDirMode)
+	}
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := m.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, m.DirMode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return os.Chmod(dir, m.DirMode)
+}
+
 func (fs *Filesystem) Translate(src string) (dest string, err error) {
+	if dest, err = fs.translate(filepath.ToSlash(src)); err != nil {
+		return
+	}
+	return dest, checkPath(dest)
+}
+
+func (fs *Filesystem) translate(src string) (dest string, err error) {
 	if src == "/" {
 		if fs.PublishDir != "" {
 			return path.Join(fs.PublishDir, "index.html"), nil
@@ -1,6 +1,10 @@
 package target
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -91,3 +95,47 @@ func TestTranslateDefaultExtension(t *testing.T) {
 		t.Errorf("Translate expected return: %s, got %s", "baz/index.foobar", dest)
 	}
 }
+
+func TestPublishFileKeepsName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-target")
+	if err != nil {
+		t.Fatalf("Unable to create a temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{PublishDir: dir}
+	if err = f.PublishFile("post/trip/photo.jpg", strings.NewReader("jpg")); err != nil {
+		t.Fatalf("PublishFile returned an unexpected err: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "post", "trip", "photo.jpg"))
+	if err != nil || string(content) != "jpg" {
+		t.Errorf("Expected photo.jpg to be published as is, got: %q %s", content, err)
+	}
+}
+
+func TestPublishModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-target")
+	if err != nil {
+		t.Fatalf("Unable to create a temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{PublishDir: dir, Modes: Modes{DirMode: 0775, FileMode: 0664}}
+	if err = f.Publish("section/foo.html", strings.NewReader("foo")); err != nil {
+		t.Fatalf("Publish returned an unexpected err: %s", err)
+	}
+
+	for path, mode := range map[string]os.FileMode{
+		filepath.Join(dir, "section"):                      0775,
+		filepath.Join(dir, "section", "foo"):               0775,
+		filepath.Join(dir, "section", "foo", "index.html"): 0664,
+	} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Unable to stat %s: %s", path, err)
+		}
+		if fi.Mode().Perm() != mode {
+			t.Errorf("%s expected mode %o, got: %o", path, mode, fi.Mode().Perm())
+		}
+	}
+}
@@ -2,9 +2,12 @@ package target
 
 import (
 	"bytes"
+	"errors"
 	helpers "github.com/spf13/hugo/template"
 	"html/template"
+	"io"
 	"path"
+	"path/filepath"
 	"strings"
 )
 
@@ -24,22 +27,61 @@ type AliasPublisher interface {
 	Publish(string, template.HTML) error
 }
 
+// AliasTemplates executes the "alias" and "alias-xhtml" templates for the
+// pages written for aliases.
+type AliasTemplates interface {
+	ExecuteTemplate(wr io.Writer, name string, data interface{}) error
+}
+
 type HTMLRedirectAlias struct {
 	PublishDir string
-	Templates  *template.Template
+	Templates  AliasTemplates // DefaultAliasTemplates when nil
+	Modes
 }
 
+// Translate is the file written for alias: the alias itself when it names
+// an html file, e.g. "2010/old.html", else the index.html of the directory
+// it names, e.g. "old/blog/post/index.html" for "old/blog/post".
 func (h *HTMLRedirectAlias) Translate(alias string) (aliasPath string, err error) {
 	if len(alias) <= 0 {
 		return
 	}
+	if err = h.checkAlias(alias); err != nil {
+		return
+	}
+	alias = filepath.ToSlash(alias)
 
 	if strings.HasSuffix(alias, "/") {
 		alias = alias + "index.html"
-	} else if !strings.HasSuffix(alias, ".html") {
+	} else if !aliasExtensions[path.Ext(alias)] {
 		alias = alias + "/index.html"
 	}
-	return path.Join(h.PublishDir, helpers.Urlize(alias)), nil
+	aliasPath = path.Join(h.PublishDir, helpers.Urlize(alias))
+	return aliasPath, checkPath(aliasPath)
+}
+
+// aliasExtensions are those of the aliases naming a file rather than a
+// directory.
+var aliasExtensions = map[string]bool{".html": true, ".htm": true, ".xhtml": true}
+
+// checkAlias refuses aliases which aren't paths of the site: urls, paths
+// of the filesystem and paths leading out of the publish dir.
+func (h *HTMLRedirectAlias) checkAlias(alias string) error {
+	slashed := strings.Replace(alias, `\`, "/", -1)
+	switch {
+	case strings.Contains(alias, "://") || strings.HasPrefix(slashed, "//"):
+		return errors.New("it is a url, aliases are paths of the site")
+	case len(alias) > 1 && alias[1] == ':':
+		return errors.New("it is a path of the filesystem, aliases are paths of the site")
+	case filepath.IsAbs(h.PublishDir) && strings.HasPrefix(slashed, filepath.ToSlash(h.PublishDir)+"/"):
+		return errors.New("it is a path of the filesystem, aliases are paths of the site")
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return errors.New("it leads out of the publish dir")
+		}
+	}
+	return nil
 }
 
 type AliasNode struct {
@@ -56,7 +98,7 @@ func (h *HTMLRedirectAlias) Publish(path string, permalink template.HTML) (err e
 		t = "alias-xhtml"
 	}
 
-	template := DefaultAliasTemplates
+	var template AliasTemplates = DefaultAliasTemplates
 	if h.Templates != nil {
 		template = h.Templates
 	}
@@ -67,5 +109,5 @@ func (h *HTMLRedirectAlias) Publish(path string, permalink template.HTML) (err e
 		return
 	}
 
-	return writeToDisk(path, buffer)
+	return h.writeToDisk(path, buffer)
 }
@@ -3,22 +3,33 @@ package target
 import (
 	"bytes"
 	"io"
+	"sync"
 )
 
 type InMemoryTarget struct {
 	Files map[string][]byte
+	mu    sync.Mutex
 }
 
 func (t *InMemoryTarget) Publish(label string, reader io.Reader) (err error) {
+	bytes := new(bytes.Buffer)
+	if _, err = bytes.ReadFrom(reader); err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	if t.Files == nil {
 		t.Files = make(map[string][]byte)
 	}
-	bytes := new(bytes.Buffer)
-	bytes.ReadFrom(reader)
 	t.Files[label] = bytes.Bytes()
 	return
 }
 
+func (t *InMemoryTarget) PublishFile(label string, reader io.Reader) (err error) {
+	return t.Publish(label, reader)
+}
+
 func (t *InMemoryTarget) Translate(label string) (dest string, err error) {
 	return label, nil
 }
@@ -0,0 +1,48 @@
+package target
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// checkWindowsPaths makes the targets refuse paths Windows can't write.
+var checkWindowsPaths = runtime.GOOS == "windows"
+
+// maxPath is the longest path Windows accepts.
+const maxPath = 259
+
+var reservedNames = []string{
+	"con", "prn", "aux", "nul",
+	"com1", "com2", "com3", "com4", "com5", "com6", "com7", "com8", "com9",
+	"lpt1", "lpt2", "lpt3", "lpt4", "lpt5", "lpt6", "lpt7", "lpt8", "lpt9",
+}
+
+// checkPath returns an error describing why dest can't be written on
+// Windows, if it can't.
+func checkPath(dest string) error {
+	if !checkWindowsPaths {
+		return nil
+	}
+	if len(dest) > maxPath {
+		return fmt.Errorf("%s: the path is %d characters long, Windows allows %d", dest, len(dest), maxPath)
+	}
+	for _, part := range strings.Split(strings.Replace(dest, `\`, "/", -1), "/") {
+		if i := strings.Index(part, ":"); i == 1 && len(part) == 2 {
+			continue // volume
+		}
+		name := strings.ToLower(part)
+		if i := strings.Index(name, "."); i != -1 {
+			name = name[:i]
+		}
+		for _, reserved := range reservedNames {
+			if name == reserved {
+				return fmt.Errorf("%s: %q is a reserved name on Windows", dest, part)
+			}
+		}
+		if i := strings.IndexAny(part, `<>:"|?*`); i != -1 {
+			return fmt.Errorf("%s: %q contains %q which Windows doesn't allow", dest, part, part[i])
+		}
+	}
+	return nil
+}
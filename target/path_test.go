@@ -0,0 +1,49 @@
+package target
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckWindowsPaths(t *testing.T) {
+	defer func(check bool) { checkWindowsPaths = check }(checkWindowsPaths)
+	checkWindowsPaths = true
+
+	for _, p := range []string{
+		"public/tags/go/index.html",
+		`C:\site\public\tags\go\index.html`,
+		"public/icons/index.html",
+	} {
+		if err := checkPath(p); err != nil {
+			t.Errorf("%s should be valid, got: %s", p, err)
+		}
+	}
+
+	for _, p := range []string{
+		"public/tags/con/index.html",
+		"public/tags/Aux.html",
+		"public/lpt1",
+		"public/what?/index.html",
+		"public/" + strings.Repeat("a", 300) + "/index.html",
+	} {
+		if err := checkPath(p); err == nil {
+			t.Errorf("%s should be rejected", p)
+		}
+	}
+
+	if _, err := new(Filesystem).Translate("tags/con.html"); err == nil {
+		t.Errorf("Filesystem should refuse to translate to a reserved name")
+	}
+	if _, err := new(HTMLRedirectAlias).Translate("nul/"); err == nil {
+		t.Errorf("HTMLRedirectAlias should refuse to translate to a reserved name")
+	}
+}
+
+func TestCheckWindowsPathsOff(t *testing.T) {
+	defer func(check bool) { checkWindowsPaths = check }(checkWindowsPaths)
+	checkWindowsPaths = false
+
+	if err := checkPath("public/tags/con/index.html"); err != nil {
+		t.Errorf("Paths should only be checked for Windows, got: %s", err)
+	}
+}
@@ -0,0 +1,195 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// The arithmetic funcs take any numbers, and strings holding one such as the
+// params of the front matter.  Integers give an integer, e.g. 7 / 2 is 3,
+// anything else a float.
+
+// Add is a + b.
+func Add(a, b interface{}) (interface{}, error) {
+	return arithmetic('+', a, b)
+}
+
+// Sub is a - b.
+func Sub(a, b interface{}) (interface{}, error) {
+	return arithmetic('-', a, b)
+}
+
+// Mul is a * b.
+func Mul(a, b interface{}) (interface{}, error) {
+	return arithmetic('*', a, b)
+}
+
+// Div is a / b.
+func Div(a, b interface{}) (interface{}, error) {
+	return arithmetic('/', a, b)
+}
+
+// Mod is the remainder of the integer division of a by b.
+func Mod(a, b interface{}) (int64, error) {
+	ai, aok := integer(a)
+	bi, bok := integer(b)
+	if !aok || !bok {
+		return 0, fmt.Errorf("mod takes integers, got: %v and %v", a, b)
+	}
+	if bi == 0 {
+		return 0, errors.New("can't divide by zero")
+	}
+	return ai % bi, nil
+}
+
+// ModBool tells whether b divides a, e.g. modBool $index 2 for every
+// other row.
+func ModBool(a, b interface{}) (bool, error) {
+	m, err := Mod(a, b)
+	return m == 0, err
+}
+
+func arithmetic(op rune, a, b interface{}) (interface{}, error) {
+	if ai, ok := integer(a); ok {
+		if bi, ok := integer(b); ok {
+			switch op {
+			case '+':
+				return ai + bi, nil
+			case '-':
+				return ai - bi, nil
+			case '*':
+				return ai * bi, nil
+			}
+			if bi == 0 {
+				return nil, errors.New("can't divide by zero")
+			}
+			return ai / bi, nil
+		}
+	}
+	af, aok := float(a)
+	bf, bok := float(b)
+	if !aok || !bok {
+		return nil, fmt.Errorf("can't compute %v %c %v", a, op, b)
+	}
+	switch op {
+	case '+':
+		return af + bf, nil
+	case '-':
+		return af - bf, nil
+	case '*':
+		return af * bf, nil
+	}
+	if bf == 0 {
+		return nil, errors.New("can't divide by zero")
+	}
+	return af / bf, nil
+}
+
+// integer is v as an integer, when it is one or a string holding one.
+func integer(v interface{}) (int64, bool) {
+	rv := indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	case reflect.String:
+		i, err := strconv.ParseInt(rv.String(), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// float is v as a float, when it is a number or a string holding one.
+func float(v interface{}) (float64, bool) {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.String {
+		f, err := strconv.ParseFloat(rv.String(), 64)
+		return f, err == nil
+	}
+	return number(rv)
+}
+
+// The comparison funcs replace the ones of Go templates, which only compare
+// values of the same basic kind.  Numbers of any type compare with each
+// other, as do strings holding a number, though two strings compare as
+// strings.  Eq and Ne otherwise tell values equal as Where does, while Lt,
+// Le, Gt and Ge compare lists and maps by their length and are false for
+// values which can't be ordered.
+
+// Eq tells whether a equals one of bs, like the eq of Go templates, e.g.
+// eq .Type "post" "page".
+func Eq(a interface{}, bs ...interface{}) (bool, error) {
+	if len(bs) == 0 {
+		return false, errors.New("missing argument for comparison")
+	}
+	for _, b := range bs {
+		if equalOperands(a, b) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Ne tells whether a differs from b.
+func Ne(a, b interface{}) bool {
+	return !equalOperands(a, b)
+}
+
+func equalOperands(a, b interface{}) bool {
+	as, bs := indirect(reflect.ValueOf(a)), indirect(reflect.ValueOf(b))
+	if as.Kind() == reflect.String && bs.Kind() == reflect.String {
+		return as.String() == bs.String()
+	}
+	ok, _ := compare(operand(a, false), "=", operand(b, false))
+	return ok
+}
+
+// Lt tells whether a is less than b.
+func Lt(a, b interface{}) bool {
+	ok, _ := compare(operand(a, true), "<", operand(b, true))
+	return ok
+}
+
+// Le tells whether a is less than or equal to b.
+func Le(a, b interface{}) bool {
+	ok, _ := compare(operand(a, true), "<=", operand(b, true))
+	return ok
+}
+
+// Gt tells whether a is greater than b, e.g. gt .Site.Recent 5 when there
+// are more than five pieces of content.
+func Gt(a, b interface{}) bool {
+	ok, _ := compare(operand(a, true), ">", operand(b, true))
+	return ok
+}
+
+// Ge tells whether a is greater than or equal to b.
+func Ge(a, b interface{}) bool {
+	ok, _ := compare(operand(a, true), ">=", operand(b, true))
+	return ok
+}
+
+// operand is v as the comparison funcs compare it: a string holding a
+// number as that number and, when ordering, a list or map as its length and
+// nothing as 0.
+func operand(v interface{}, ordering bool) reflect.Value {
+	rv := indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.String:
+		if f, err := strconv.ParseFloat(rv.String(), 64); err == nil {
+			return reflect.ValueOf(f)
+		}
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice:
+		if ordering {
+			return reflect.ValueOf(rv.Len())
+		}
+	case reflect.Invalid:
+		if ordering {
+			return reflect.ValueOf(0)
+		}
+	}
+	return rv
+}
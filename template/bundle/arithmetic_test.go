@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestArithmetic(t *testing.T) {
+	for _, test := range []struct {
+		fn       func(a, b interface{}) (interface{}, error)
+		a, b     interface{}
+		expected interface{}
+	}{
+		{Add, 1, 2, int64(3)},
+		{Add, 1, 0.5, 1.5},
+		{Add, "2", 3, int64(5)},
+		{Sub, 10, uint8(4), int64(6)},
+		{Sub, 1.5, 1, 0.5},
+		{Mul, 3, 4, int64(12)},
+		{Mul, "1.5", 2, 3.0},
+		{Div, 7, 2, int64(3)},
+		{Div, 7.0, 2, 3.5},
+	} {
+		got, err := test.fn(test.a, test.b)
+		if err != nil {
+			t.Errorf("Unable to compute with %v and %v: %s", test.a, test.b, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("Expected %v and %v to give %#v, got: %#v", test.a, test.b, test.expected, got)
+		}
+	}
+
+	for _, err := range []error{
+		second(Div(1, 0)),
+		second(Div(1.5, 0.0)),
+		second(Add("a", 1)),
+		second(Mod(3, 0)),
+		second(Mod(3.5, 2)),
+	} {
+		if err == nil {
+			t.Errorf("Expected an error")
+		}
+	}
+
+	if m, _ := Mod(7, 3); m != 1 {
+		t.Errorf("Expected 7 mod 3 to be 1, got: %d", m)
+	}
+	if ok, _ := ModBool(6, 3); !ok {
+		t.Errorf("Expected 3 to divide 6")
+	}
+}
+
+func second(_ interface{}, err error) error { return err }
+
+func TestComparison(t *testing.T) {
+	Eq := func(a, b interface{}) bool {
+		ok, _ := Eq(a, b)
+		return ok
+	}
+	for _, test := range []struct {
+		name     string
+		fn       func(a, b interface{}) bool
+		a, b     interface{}
+		expected bool
+	}{
+		{"eq", Eq, 1, 1.0, true},
+		{"eq", Eq, "1", 1, true},
+		{"eq", Eq, "a", "a", true},
+		{"eq", Eq, "a", "b", false},
+		{"eq", Eq, "1.10", "1.1", false},
+		{"ne", Ne, "1.10", "1.1", true},
+		{"eq", Eq, []int{1}, []int{2}, false},
+		{"ne", Ne, "a", "b", true},
+		{"lt", Lt, 1, 2, true},
+		{"lt", Lt, "a", "b", true},
+		{"lt", Lt, "10", "9", false},
+		{"le", Le, 2, 2.0, true},
+		{"gt", Gt, []int{1, 2, 3}, 2, true},
+		{"gt", Gt, "10", 9, true},
+		{"gt", Gt, nil, 0, false},
+		{"ge", Ge, map[string]int{"a": 1}, 1, true},
+		{"gt", Gt, struct{}{}, 1, false},
+	} {
+		if got := test.fn(test.a, test.b); got != test.expected {
+			t.Errorf("Expected %s %v %v to be %v, got: %v", test.name, test.a, test.b, test.expected, got)
+		}
+	}
+}
+
+func TestEqInTemplates(t *testing.T) {
+	tmpl := NewTemplate()
+	if err := tmpl.AddTemplate("a", `{{ if eq . "a" "b" }}y{{ else }}n{{ end }}{{ if eq . 2 }}2{{ end }}`); err != nil {
+		t.Fatalf("Unable to parse: %s", err)
+	}
+	if err := tmpl.AddTemplate("b", `{{ eq 1 }}`); err != nil {
+		t.Fatalf("Unable to parse: %s", err)
+	}
+	for data, expected := range map[interface{}]string{"b": "y", "c": "n", "2": "n2", 2.0: "n2"} {
+		out := new(bytes.Buffer)
+		if err := tmpl.ExecuteTemplate(out, "a", data); err != nil {
+			t.Fatalf("Unable to execute: %s", err)
+		}
+		if out.String() != expected {
+			t.Errorf("Expected %q for %v, got: %q", expected, data, out.String())
+		}
+	}
+
+	if err := tmpl.ExecuteTemplate(new(bytes.Buffer), "b", nil); err == nil {
+		t.Errorf("Expected eq with a single argument to fail")
+	}
+}
+
+func TestArithmeticInTemplates(t *testing.T) {
+	tmpl := NewTemplate()
+	err := tmpl.AddTemplate("a", `{{ range $i, $e := . }}{{ if modBool $i 2 }}even{{ else }}odd{{ end }}{{ if lt (add $i 1) (len $) }},{{ end }}{{ end }} {{ div (mul 12 (len .)) 4 }}`)
+	if err != nil {
+		t.Fatalf("Unable to parse: %s", err)
+	}
+	out := new(bytes.Buffer)
+	if err = tmpl.ExecuteTemplate(out, "a", []string{"x", "y", "z"}); err != nil {
+		t.Fatalf("Unable to execute: %s", err)
+	}
+	if out.String() != "even,odd,even 9" {
+		t.Errorf("Expected the rows alternated and the span computed, got: %q", out.String())
+	}
+}
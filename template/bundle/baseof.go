@@ -0,0 +1,104 @@
+package bundle
+
+import (
+	"html/template"
+	"io"
+	"path"
+	"regexp"
+	"sync"
+)
+
+// baseofName is the template holding the skeleton of the pages of its
+// directory, see baseofTemplates.
+const baseofName = "baseof.html"
+
+// A layout made only of {{ define }} blocks fills in the {{ block }}s of
+// the nearest baseof.html: the one of its directory, else
+// _default/baseof.html.
+var defineOnly = regexp.MustCompile(`^\s*{{-?\s*define\s(?s:.*){{-?\s*end\s*-?}}\s*$`)
+
+// baseofTemplates executes the layouts built on a baseof.html, each in a
+// set of templates of its own so their definitions of the same blocks
+// don't clash.
+type baseofTemplates struct {
+	sync.Mutex
+	texts map[string]string // name, text of baseof templates and layouts using them
+	sets  map[string]*template.Template
+	dirty bool
+}
+
+func (b *baseofTemplates) added(name, text string) {
+	if path.Base(name) != baseofName && !defineOnly.MatchString(text) {
+		if _, ok := b.texts[name]; !ok {
+			return
+		}
+		// A layout using a baseof replaced by one that doesn't.
+		delete(b.texts, name)
+	} else {
+		if b.texts == nil {
+			b.texts = make(map[string]string)
+		}
+		b.texts[name] = text
+	}
+	b.dirty = true
+}
+
+// base is the text of the baseof.html used by the layout name.
+func (b *baseofTemplates) base(name string) (string, bool) {
+	for _, base := range []string{path.Join(path.Dir(name), baseofName), path.Join("_default", baseofName)} {
+		if text, ok := b.texts[base]; ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// build makes the sets of the layouts using a baseof from clones of all
+// the templates, so they can use partials like any other layout. It has to
+// happen before any template is executed, see html/template.Template.Clone.
+func (t *GoHtmlTemplate) buildBaseof() error {
+	b := &t.baseof
+	b.sets = make(map[string]*template.Template)
+	for name, text := range b.texts {
+		if path.Base(name) == baseofName {
+			continue
+		}
+		base, ok := b.base(name)
+		if !ok {
+			continue
+		}
+		set, err := t.Template.Clone()
+		if err != nil {
+			return err
+		}
+		if _, err = set.New(name).Parse(base); err != nil {
+			return err
+		}
+		// Only the blocks are replaced, the body being empty.
+		if _, err = set.Lookup(name).Parse(text); err != nil {
+			return err
+		}
+		b.sets[name] = set
+	}
+	b.dirty = false
+	return nil
+}
+
+// ExecuteTemplate executes the layouts filling in the blocks of a
+// baseof.html within it.
+func (t *GoHtmlTemplate) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	t.baseof.Lock()
+	if t.baseof.dirty {
+		if err := t.buildBaseof(); err != nil {
+			t.baseof.Unlock()
+			return err
+		}
+	}
+	set := t.baseof.sets[name]
+	t.baseof.Unlock()
+
+	if set != nil {
+		return set.ExecuteTemplate(w, name, data)
+	}
+	return t.Template.ExecuteTemplate(w, name, data)
+}
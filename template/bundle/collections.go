@@ -0,0 +1,326 @@
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The collection funcs work on any slice or array, or a pointer to one, such
+// as .Site.Recent or .Data.Pages. They return a slice of the same type, so
+// methods like .ByWeight still apply to what they return, e.g.
+//
+//	{{ range first 5 (where .Site.Recent "Section" "blog") }}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// seqValue is seq as a slice.
+func seqValue(seq interface{}) (reflect.Value, error) {
+	v := indirect(reflect.ValueOf(seq))
+	switch v.Kind() {
+	case reflect.Slice:
+		return v, nil
+	case reflect.Array:
+		s := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		return s, nil
+	}
+	return reflect.Value{}, fmt.Errorf("can't iterate over %v", seq)
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// First is the first limit elements of seq, all of them when it has fewer.
+func First(limit int, seq interface{}) (interface{}, error) {
+	v, err := seqValue(seq)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("can't return a negative number of elements: %d", limit)
+	}
+	if limit > v.Len() {
+		limit = v.Len()
+	}
+	return v.Slice(0, limit).Interface(), nil
+}
+
+// Last is the last limit elements of seq, all of them when it has fewer.
+func Last(limit int, seq interface{}) (interface{}, error) {
+	v, err := seqValue(seq)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("can't return a negative number of elements: %d", limit)
+	}
+	if limit > v.Len() {
+		limit = v.Len()
+	}
+	return v.Slice(v.Len()-limit, v.Len()).Interface(), nil
+}
+
+// After is the elements of seq after the first index ones.
+func After(index int, seq interface{}) (interface{}, error) {
+	v, err := seqValue(seq)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("can't skip a negative number of elements: %d", index)
+	}
+	if index > v.Len() {
+		index = v.Len()
+	}
+	return v.Slice(index, v.Len()).Interface(), nil
+}
+
+// Where is the elements of seq whose key matches, either equal to the one
+// argument, e.g. where .Data.Pages "Section" "blog", or compared with the
+// second one by the first, e.g. where .Data.Pages "Weight" ">" 3.  The
+// operators are =, !=, <, <=, >, >= and "in", which matches when the key is
+// one of the elements of the slice compared with.  The key may be a field,
+// a method without arguments or a map key, and a path of them such as
+// "Params.author".
+func Where(seq interface{}, key string, args ...interface{}) (interface{}, error) {
+	v, err := seqValue(seq)
+	if err != nil {
+		return nil, err
+	}
+	var op string
+	var match interface{}
+	switch len(args) {
+	case 1:
+		op, match = "=", args[0]
+	case 2:
+		var ok bool
+		if op, ok = args[0].(string); !ok {
+			return nil, fmt.Errorf("the operator of where must be a string, got: %v", args[0])
+		}
+		match = args[1]
+	default:
+		return nil, fmt.Errorf("where takes a value, or an operator and a value, got: %v", args)
+	}
+
+	out := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		kv, err := evaluateKey(elem, key)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := compare(kv, op, reflect.ValueOf(match))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = reflect.Append(out, elem)
+		}
+	}
+	return out.Interface(), nil
+}
+
+// Sort is seq sorted by key, see Where, or by its elements themselves when
+// key is left out or "value". The order is "asc", the default, or "desc".
+func Sort(seq interface{}, args ...string) (interface{}, error) {
+	v, err := seqValue(seq)
+	if err != nil {
+		return nil, err
+	}
+	key, order := "value", "asc"
+	if len(args) > 0 && args[0] != "" {
+		key = args[0]
+	}
+	if len(args) > 1 {
+		order = strings.ToLower(args[1])
+	}
+	if len(args) > 2 || (order != "asc" && order != "desc") {
+		return nil, fmt.Errorf("sort takes a key and an order, \"asc\" or \"desc\", got: %v", args)
+	}
+
+	s := &sorter{elems: make([]reflect.Value, v.Len()), keys: make([]reflect.Value, v.Len())}
+	for i := range s.elems {
+		s.elems[i] = v.Index(i)
+		if key == "value" {
+			s.keys[i] = indirect(s.elems[i])
+		} else if s.keys[i], err = evaluateKey(s.elems[i], key); err != nil {
+			return nil, err
+		}
+	}
+	if order == "desc" {
+		sort.Stable(sort.Reverse(s))
+	} else {
+		sort.Stable(s)
+	}
+
+	out := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for _, elem := range s.elems {
+		out = reflect.Append(out, elem)
+	}
+	return out.Interface(), nil
+}
+
+type sorter struct {
+	elems, keys []reflect.Value
+}
+
+func (s *sorter) Len() int { return len(s.elems) }
+func (s *sorter) Swap(i, j int) {
+	s.elems[i], s.elems[j] = s.elems[j], s.elems[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// Less puts the elements without the key first.
+func (s *sorter) Less(i, j int) bool {
+	if !s.keys[i].IsValid() || !s.keys[j].IsValid() {
+		return !s.keys[i].IsValid() && s.keys[j].IsValid()
+	}
+	less, _ := compare(s.keys[i], "<", s.keys[j])
+	return less
+}
+
+// evaluateKey follows the dotted path key from elem through fields, methods
+// without arguments and map keys.
+func evaluateKey(elem reflect.Value, key string) (reflect.Value, error) {
+	v := elem
+	for _, name := range strings.Split(key, ".") {
+		if !v.IsValid() {
+			return v, nil
+		}
+		if m := v.MethodByName(name); m.IsValid() && m.Type().NumIn() == 0 {
+			if out := m.Call(nil); len(out) > 0 {
+				v = out[0]
+				continue
+			}
+		}
+		v = indirect(v)
+		if !v.IsValid() {
+			return v, nil
+		}
+		if m := v.MethodByName(name); m.IsValid() && m.Type().NumIn() == 0 {
+			if out := m.Call(nil); len(out) > 0 {
+				v = out[0]
+				continue
+			}
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			f := v.FieldByName(name)
+			if !f.IsValid() {
+				return f, fmt.Errorf("%s has no field or method %s", v.Type(), name)
+			}
+			v = f
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, fmt.Errorf("can't look up %s in %s", name, v.Type())
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		default:
+			return reflect.Value{}, fmt.Errorf("can't look up %s in %s", name, v.Type())
+		}
+	}
+	return indirect(v), nil
+}
+
+// compare tells whether a op b holds. Numbers are compared whatever their
+// type, as are strings and times; other values can only be told equal.
+func compare(a reflect.Value, op string, b reflect.Value) (bool, error) {
+	a, b = indirect(a), indirect(b)
+	switch op {
+	case "=", "==", "eq":
+		return equal(a, b), nil
+	case "!=", "<>", "ne":
+		return !equal(a, b), nil
+	case "in":
+		if !b.IsValid() || (b.Kind() != reflect.Slice && b.Kind() != reflect.Array) {
+			return false, fmt.Errorf("\"in\" compares with a slice, got: %v", b)
+		}
+		for i := 0; i < b.Len(); i++ {
+			if equal(a, indirect(b.Index(i))) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "<", "lt", "<=", "le", ">", "gt", ">=", "ge":
+	default:
+		return false, fmt.Errorf("unknown operator: %s", op)
+	}
+
+	c, ok := order(a, b)
+	if !ok {
+		return false, nil
+	}
+	switch op {
+	case "<", "lt":
+		return c < 0, nil
+	case "<=", "le":
+		return c <= 0, nil
+	case ">", "gt":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func equal(a, b reflect.Value) bool {
+	if c, ok := order(a, b); ok {
+		return c == 0
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// order is -1, 0 or 1 as a is less than, equal to or greater than b, and
+// false when they can't be ordered.
+func order(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			return cmp(af < bf, af > bf), true
+		}
+		return 0, false
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return cmp(a.String() < b.String(), a.String() > b.String()), true
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		return cmp(at.Before(bt), at.After(bt)), true
+	}
+	return 0, false
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func cmp(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
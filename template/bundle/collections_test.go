@@ -0,0 +1,154 @@
+package bundle
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type item struct {
+	Title   string
+	Section string
+	Weight  int
+	Date    time.Time
+	Params  map[string]interface{}
+}
+
+func (i *item) Upper() string { return "UP " + i.Title }
+
+type items []*item
+
+func (i items) Titles() (titles []string) {
+	for _, it := range i {
+		titles = append(titles, it.Title)
+	}
+	return
+}
+
+func testItems() items {
+	day := func(d int) time.Time { return time.Date(2013, 1, d, 0, 0, 0, 0, time.UTC) }
+	return items{
+		{Title: "a", Section: "blog", Weight: 3, Date: day(3), Params: map[string]interface{}{"author": "steve"}},
+		{Title: "b", Section: "doc", Weight: 1, Date: day(1), Params: map[string]interface{}{}},
+		{Title: "c", Section: "blog", Weight: 2, Date: day(2), Params: map[string]interface{}{"author": "bep"}},
+		{Title: "d", Section: "blog", Weight: 5, Date: day(4), Params: map[string]interface{}{"author": "steve"}},
+	}
+}
+
+// titles are the titles of the items returned by a collection func, or the
+// error it returned.
+func titles(v interface{}, err error) []string {
+	if err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	return v.(items).Titles()
+}
+
+func TestFirstLastAfter(t *testing.T) {
+	seq := testItems()
+	for _, test := range []struct {
+		fn       func(int, interface{}) (interface{}, error)
+		n        int
+		expected []string
+	}{
+		{First, 2, []string{"a", "b"}},
+		{First, 10, []string{"a", "b", "c", "d"}},
+		{First, 0, nil},
+		{Last, 2, []string{"c", "d"}},
+		{After, 3, []string{"d"}},
+		{After, 10, nil},
+	} {
+		got := titles(test.fn(test.n, &seq))
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected %v, got: %v", test.expected, got)
+		}
+	}
+
+	if _, err := First(-1, seq); err == nil {
+		t.Errorf("Expected an error for a negative limit")
+	}
+	if _, err := First(1, 3); err == nil {
+		t.Errorf("Expected an error for something that isn't a slice")
+	}
+	if got, _ := First(2, [3]int{1, 2, 3}); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Expected arrays to be sliced, got: %v", got)
+	}
+}
+
+func TestWhere(t *testing.T) {
+	seq := testItems()
+	for _, test := range []struct {
+		key      string
+		args     []interface{}
+		expected []string
+	}{
+		{"Section", []interface{}{"blog"}, []string{"a", "c", "d"}},
+		{"Section", []interface{}{"!=", "blog"}, []string{"b"}},
+		{"Weight", []interface{}{">", 2}, []string{"a", "d"}},
+		{"Weight", []interface{}{"<=", 2}, []string{"b", "c"}},
+		{"Date", []interface{}{">=", time.Date(2013, 1, 3, 0, 0, 0, 0, time.UTC)}, []string{"a", "d"}},
+		{"Params.author", []interface{}{"steve"}, []string{"a", "d"}},
+		{"Title", []interface{}{"in", []string{"b", "d", "x"}}, []string{"b", "d"}},
+		{"Upper", []interface{}{"UP c"}, []string{"c"}},
+	} {
+		got := titles(Where(seq, test.key, test.args...))
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("where %s %v: expected %v, got: %v", test.key, test.args, test.expected, got)
+		}
+	}
+
+	for _, args := range [][]interface{}{{}, {"~", 1}, {1, 1}, {"in", 1}} {
+		if _, err := Where(seq, "Weight", args...); err == nil {
+			t.Errorf("Expected an error for where Weight %v", args)
+		}
+	}
+	if _, err := Where(seq, "Missing", "x"); err == nil {
+		t.Errorf("Expected an error for a missing field")
+	}
+}
+
+func TestSort(t *testing.T) {
+	seq := testItems()
+	for _, test := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"Weight"}, []string{"b", "c", "a", "d"}},
+		{[]string{"Date", "desc"}, []string{"d", "a", "c", "b"}},
+		{[]string{"Params.author"}, []string{"b", "c", "a", "d"}},
+		{[]string{"Title", "desc"}, []string{"d", "c", "b", "a"}},
+	} {
+		got := titles(Sort(seq, test.args...))
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("sort %v: expected %v, got: %v", test.args, test.expected, got)
+		}
+	}
+	if seq[0].Title != "a" {
+		t.Errorf("Expected sort to leave the sequence alone")
+	}
+
+	if got, _ := Sort([]string{"b", "c", "a"}); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("Expected the values to be sorted, got: %v", got)
+	}
+	if got, _ := Sort([]int{2, 3, 1}, "value", "desc"); !reflect.DeepEqual(got, []int{3, 2, 1}) {
+		t.Errorf("Expected the values to be sorted descending, got: %v", got)
+	}
+	if _, err := Sort(seq, "Weight", "up"); err == nil {
+		t.Errorf("Expected an error for an unknown order")
+	}
+}
+
+func TestCollectionFuncs(t *testing.T) {
+	tmpl := NewTemplate()
+	if err := tmpl.AddTemplate("list", `{{ range first 2 (sort (where . "Section" "blog") "Weight" "desc") }}{{ .Title }}{{ end }}|{{ range last 1 . }}{{ .Title }}{{ end }}|{{ range after 3 . }}{{ .Title }}{{ end }}`); err != nil {
+		t.Fatalf("Unable to parse a template using the collection funcs: %s", err)
+	}
+	out := new(bytes.Buffer)
+	if err := tmpl.ExecuteTemplate(out, "list", testItems()); err != nil {
+		t.Fatalf("Unable to execute: %s", err)
+	}
+	if out.String() != "da|d|d" {
+		t.Errorf("Expected the collection funcs to chain, got: %q", out.String())
+	}
+}
@@ -1,6 +1,7 @@
 package bundle
 
 import (
+	"fmt"
 	"github.com/eknkc/amber"
 	helpers "github.com/spf13/hugo/template"
 	"html/template"
@@ -9,37 +10,9 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
-func Gt(a interface{}, b interface{}) bool {
-	var left, right int64
-	av := reflect.ValueOf(a)
-
-	switch av.Kind() {
-	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice:
-		left = int64(av.Len())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		left = av.Int()
-	case reflect.String:
-		left, _ = strconv.ParseInt(av.String(), 10, 64)
-	}
-
-	bv := reflect.ValueOf(b)
-
-	switch bv.Kind() {
-	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice:
-		right = int64(bv.Len())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		right = bv.Int()
-	case reflect.String:
-		right, _ = strconv.ParseInt(bv.String(), 10, 64)
-	}
-
-	return left > right
-}
-
 func IsSet(a interface{}, key interface{}) bool {
 	av := reflect.ValueOf(a)
 	kv := reflect.ValueOf(key)
@@ -78,10 +51,32 @@ func ReturnWhenSet(a interface{}, index int) interface{} {
 	return ""
 }
 
+// The safe funcs mark text from a trusted source, such as the config or a
+// data file, as safe where it is used, so html/template leaves it alone
+// instead of escaping it, e.g. {{ .Site.Copyright | safeHTML }}.  Text
+// from anywhere else must not go through them.
+
+// SafeHtml marks text as html, e.g. a copyright with entities.
 func SafeHtml(text string) template.HTML {
 	return template.HTML(text)
 }
 
+// SafeCss marks text as css, e.g. a style block or attribute.
+func SafeCss(text string) template.CSS {
+	return template.CSS(text)
+}
+
+// SafeUrl marks text as a url, e.g. one with a scheme html/template
+// doesn't allow, like irc:.
+func SafeUrl(text string) template.URL {
+	return template.URL(text)
+}
+
+// SafeJs marks text as a javascript expression.
+func SafeJs(text string) template.JS {
+	return template.JS(text)
+}
+
 type Template interface {
 	ExecuteTemplate(wr io.Writer, name string, data interface{}) error
 	Lookup(name string) *template.Template
@@ -89,6 +84,8 @@ type Template interface {
 	New(name string) *template.Template
 	LoadTemplates(absPath string)
 	AddTemplate(name, tpl string) error
+	AddFuncs(funcMap template.FuncMap) error
+	Overrides() []Override
 }
 
 type templateErr struct {
@@ -96,9 +93,23 @@ type templateErr struct {
 	err  error
 }
 
+// Override is a template defined more than once, e.g. by layouts loaded
+// from two directories. The last definition is the one used.
+type Override struct {
+	Name     string
+	Used     string // the file the definition in use comes from
+	Replaced string // the file of the definition it replaced
+}
+
+// addedSource stands for templates added with AddTemplate in overrides.
+const addedSource = "(added)"
+
 type GoHtmlTemplate struct {
 	template.Template
-	errors []*templateErr
+	errors    []*templateErr
+	sources   map[string]string // name, file
+	overrides []Override
+	baseof    baseofTemplates
 }
 
 func NewTemplate() Template {
@@ -109,22 +120,81 @@ func NewTemplate() Template {
 
 	funcMap := template.FuncMap{
 		"urlize":    helpers.Urlize,
+		"eq":        Eq,
+		"ne":        Ne,
+		"lt":        Lt,
+		"le":        Le,
 		"gt":        Gt,
+		"ge":        Ge,
+		"add":       Add,
+		"sub":       Sub,
+		"mul":       Mul,
+		"div":       Div,
+		"mod":       Mod,
+		"modBool":   ModBool,
 		"isset":     IsSet,
 		"echoParam": ReturnWhenSet,
 		"safeHtml":  SafeHtml,
+		"safeHTML":  SafeHtml,
+		"safeCSS":   SafeCss,
+		"safeURL":   SafeUrl,
+		"safeJS":    SafeJs,
+		"where":     Where,
+		"first":     First,
+		"last":      Last,
+		"after":     After,
+		"sort":      Sort,
 	}
 
 	templates.Funcs(funcMap)
 	return templates
 }
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// AddFuncs makes the funcs in funcMap available to templates, replacing
+// built-in ones of the same name. Templates are checked for the funcs they
+// use as they are parsed, so AddFuncs has to be called before LoadTemplates.
+func (t *GoHtmlTemplate) AddFuncs(funcMap template.FuncMap) error {
+	for name, fn := range funcMap {
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func {
+			return fmt.Errorf("template func %s is not a function", name)
+		}
+		if out := v.Type().NumOut(); out == 0 || out > 2 || out == 2 && v.Type().Out(1) != errorType {
+			return fmt.Errorf("template func %s must return a value, and optionally an error", name)
+		}
+	}
+	t.Funcs(funcMap)
+	return nil
+}
+
 func (t *GoHtmlTemplate) AddTemplate(name, tpl string) error {
 	_, err := t.New(name).Parse(tpl)
 	if err != nil {
 		t.errors = append(t.errors, &templateErr{name: name, err: err})
+		return err
+	}
+	t.defined(name, addedSource)
+	t.baseof.added(name, tpl)
+	return nil
+}
+
+// defined records that name now comes from source.
+func (t *GoHtmlTemplate) defined(name, source string) {
+	if t.sources == nil {
+		t.sources = make(map[string]string)
 	}
-	return err
+	if old, ok := t.sources[name]; ok {
+		t.overrides = append(t.overrides, Override{Name: name, Used: source, Replaced: old})
+	}
+	t.sources[name] = source
+}
+
+// Overrides lists the templates defined more than once, in the order they
+// were replaced.
+func (t *GoHtmlTemplate) Overrides() []Override {
+	return t.overrides
 }
 
 func (t *GoHtmlTemplate) AddTemplateFile(name, path string) error {
@@ -136,8 +206,11 @@ func (t *GoHtmlTemplate) AddTemplateFile(name, path string) error {
 	_, err = t.New(name).Parse(s)
 	if err != nil {
 		t.errors = append(t.errors, &templateErr{name: name, err: err})
+		return err
 	}
-	return err
+	t.defined(name, path)
+	t.baseof.added(name, s)
+	return nil
 }
 
 func (t *GoHtmlTemplate) generateTemplateNameFrom(base, path string) string {
@@ -172,6 +245,7 @@ func (t *GoHtmlTemplate) LoadTemplates(absPath string) {
 				if _, err := compiler.CompileWithTemplate(t.New(tplName)); err != nil {
 					return err
 				}
+				t.defined(tplName, path)
 
 			} else {
 				t.AddTemplateFile(tplName, path)
@@ -0,0 +1,129 @@
+package bundle
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestAddFuncs(t *testing.T) {
+	tmpl := NewTemplate()
+	err := tmpl.AddFuncs(template.FuncMap{
+		"shout":  strings.ToUpper,
+		"urlize": func(s string) string { return "custom-" + s },
+	})
+	if err != nil {
+		t.Fatalf("Unable to add funcs: %s", err)
+	}
+	if err = tmpl.AddTemplate("a", `{{ shout "hi" }} {{ urlize "x" }}`); err != nil {
+		t.Fatalf("Unable to parse a template using the funcs: %s", err)
+	}
+	out := new(bytes.Buffer)
+	if err = tmpl.ExecuteTemplate(out, "a", nil); err != nil {
+		t.Fatalf("Unable to execute: %s", err)
+	}
+	if out.String() != "HI custom-x" {
+		t.Errorf("Expected the added funcs to be used, got: %q", out.String())
+	}
+}
+
+func TestAddInvalidFuncs(t *testing.T) {
+	for _, funcMap := range []template.FuncMap{
+		{"notafunc": "x"},
+		{"noresult": func() {}},
+		{"noerror": func() (string, string) { return "", "" }},
+	} {
+		if err := NewTemplate().AddFuncs(funcMap); err == nil {
+			t.Errorf("Expected an error adding %v", funcMap)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	tmpl := NewTemplate()
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(tmpl.AddTemplate("a", "first"))
+	must(tmpl.AddTemplate("b", "only"))
+	must(tmpl.AddTemplate("a", "second"))
+
+	overrides := tmpl.Overrides()
+	if len(overrides) != 1 || overrides[0] != (Override{Name: "a", Used: addedSource, Replaced: addedSource}) {
+		t.Errorf("Expected a to be overridden once, got: %v", overrides)
+	}
+	out := new(bytes.Buffer)
+	must(tmpl.ExecuteTemplate(out, "a", nil))
+	if out.String() != "second" {
+		t.Errorf("Expected the last definition to be used, got: %q", out.String())
+	}
+}
+
+func TestBaseof(t *testing.T) {
+	tmpl := NewTemplate()
+	for _, tpl := range []struct{ name, text string }{
+		{"_default/baseof.html", `<html>{{ template "chrome/header.html" . }}{{ block "main" . }}default{{ end }}|{{ block "aside" . }}aside{{ end }}</html>`},
+		{"post/baseof.html", `<article>{{ block "main" . }}{{ end }}</article>`},
+		{"chrome/header.html", `<h1>{{ . }}</h1>`},
+		{"_default/single.html", `{{ define "main" }}single {{ . }}{{ end }}`},
+		{"_default/list.html", "\n{{ define \"main\" }}{{ if . }}list{{ end }}{{ end }}\n{{ define \"aside\" }}more{{ end }}\n"},
+		{"post/single.html", `{{ define "main" }}post {{ . }}{{ end }}`},
+		{"index.html", `<html>home</html>`},
+	} {
+		if err := tmpl.AddTemplate(tpl.name, tpl.text); err != nil {
+			t.Fatalf("Unable to add %s: %s", tpl.name, err)
+		}
+	}
+
+	for name, expected := range map[string]string{
+		"_default/single.html": "<html><h1>a</h1>single a|aside</html>",
+		"_default/list.html":   "<html><h1>a</h1>list|more</html>",
+		"post/single.html":     "<article>post a</article>",
+		"index.html":           "<html>home</html>",
+	} {
+		out := new(bytes.Buffer)
+		if err := tmpl.ExecuteTemplate(out, name, "a"); err != nil {
+			t.Fatalf("Unable to execute %s: %s", name, err)
+		}
+		if out.String() != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, out.String())
+		}
+	}
+}
+
+func TestSafeFuncs(t *testing.T) {
+	tmpl := NewTemplate()
+	for name, src := range map[string]string{
+		"html": `{{ .html | safeHTML }}|{{ .html | safeHtml }}|{{ .html }}`,
+		"css":  `<p style="{{ .css | safeCSS }}">`,
+		"url":  `<a href="{{ .url | safeURL }}">`,
+		"js":   `<script>var x = {{ .js | safeJS }};</script>`,
+	} {
+		if err := tmpl.AddTemplate(name, src); err != nil {
+			t.Fatalf("Unable to parse %s: %s", name, err)
+		}
+	}
+	data := map[string]string{
+		"html": "&copy; 2013 <b>spf13</b>",
+		"css":  "color: red; background: url(x.png)",
+		"url":  "irc://irc.freenode.net/#hugo",
+		"js":   "{a: 1}",
+	}
+	for name, expected := range map[string]string{
+		"html": "&copy; 2013 <b>spf13</b>|&copy; 2013 <b>spf13</b>|&amp;copy; 2013 &lt;b&gt;spf13&lt;/b&gt;",
+		"css":  `<p style="color: red; background: url(x.png)">`,
+		"url":  `<a href="irc://irc.freenode.net/#hugo">`,
+		"js":   `<script>var x = {a: 1};</script>`,
+	} {
+		out := new(bytes.Buffer)
+		if err := tmpl.ExecuteTemplate(out, name, data); err != nil {
+			t.Fatalf("Unable to execute %s: %s", name, err)
+		}
+		if out.String() != expected {
+			t.Errorf("Expected %s to be %q, got: %q", name, expected, out.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"bytes"
 	htmltran "code.google.com/p/go-html-transform/html/transform"
 	"io"
 	"net/url"
@@ -57,3 +58,76 @@ func (t *AbsURL) absUrlify(tr *htmltran.Transformer, selectors ...elattr) (err e
 func fragmentOnly(u *url.URL) bool {
 	return u.Fragment != "" && u.Scheme == "" && u.Opaque == "" && u.User == nil && u.Host == "" && u.Path == "" && u.Path == "" && u.RawQuery == ""
 }
+
+// AbsURLInXML is AbsURL for feeds, whose items hold their html escaped as
+// text or in CDATA sections.  The src and href attributes of that html
+// are resolved against BaseURL, the rest of the document is left as is.
+type AbsURLInXML struct {
+	BaseURL string
+}
+
+// xmlAttrQuotes are the quotes of the attributes of html escaped once, as
+// the text of an element, or not at all, in a CDATA section.
+var xmlAttrQuotes = []string{"&#34;", "&quot;", "&#39;", "&apos;", `"`, `'`}
+
+func (t *AbsURLInXML) Apply(w io.Writer, r io.Reader) (err error) {
+	var baseURL *url.URL
+	if baseURL, err = url.Parse(t.BaseURL); err != nil {
+		return
+	}
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	_, err = w.Write(absURLInXML(in.Bytes(), baseURL))
+	return
+}
+
+func absURLInXML(in []byte, baseURL *url.URL) []byte {
+	out := new(bytes.Buffer)
+	for i := 0; i < len(in); i++ {
+		start, quote := xmlURLAttr(in[i:])
+		end := -1
+		if start > 0 {
+			end = bytes.Index(in[i+start:], []byte(quote))
+		}
+		if end == -1 {
+			out.WriteByte(in[i])
+			continue
+		}
+		out.Write(in[i : i+start])
+		out.WriteString(resolveURL(baseURL, string(in[i+start:i+start+end])))
+		i += start + end - 1
+	}
+	return out.Bytes()
+}
+
+// xmlURLAttr is the length of the src or href attribute starting in, up to
+// its value, and its quote, or 0 when in doesn't start with one.
+func xmlURLAttr(in []byte) (int, string) {
+	if len(in) == 0 || bytes.IndexByte([]byte(" \t\r\n"), in[0]) == -1 {
+		return 0, ""
+	}
+	for _, attr := range []string{"src=", "href="} {
+		if !bytes.HasPrefix(in[1:], []byte(attr)) {
+			continue
+		}
+		for _, quote := range xmlAttrQuotes {
+			if bytes.HasPrefix(in[1+len(attr):], []byte(quote)) {
+				return 1 + len(attr) + len(quote), quote
+			}
+		}
+	}
+	return 0, ""
+}
+
+// resolveURL is in resolved against baseURL, unless it is absolute already
+// or only a fragment.
+func resolveURL(baseURL *url.URL, in string) string {
+	u, err := url.Parse(in)
+	if err != nil || u.IsAbs() || u.Host != "" || fragmentOnly(u) {
+		return in
+	}
+	return baseURL.ResolveReference(u).String()
+}
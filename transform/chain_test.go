@@ -2,6 +2,7 @@ package transform
 
 import (
 	"bytes"
+	"io"
 	"testing"
 )
 
@@ -34,3 +35,23 @@ func TestChainTwoTransformer(t *testing.T) {
 	)
 	apply(t, tr, two_chain_tests)
 }
+
+type appendTransformer string
+
+func (a appendTransformer) Apply(w io.Writer, r io.Reader) (err error) {
+	if _, err = io.Copy(w, r); err != nil {
+		return
+	}
+	_, err = io.WriteString(w, string(a))
+	return
+}
+
+func TestPriorityChainOrder(t *testing.T) {
+	tr := NewPriorityChain(
+		Entry{300, appendTransformer("c")},
+		Entry{100, appendTransformer("a")},
+		Entry{200, appendTransformer("b1")},
+		Entry{200, appendTransformer("b2")},
+	)
+	apply(t, tr, []test{{"", "ab1b2c"}})
+}
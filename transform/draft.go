@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"bytes"
+	"io"
+)
+
+// DefaultDraftBanner is shown at the top of pages built from drafts.
+const DefaultDraftBanner = `<div class="hugo-draft" style="position:fixed;top:0;left:0;right:0;z-index:9999;padding:4px;background:#c00;color:#fff;font:bold 14px sans-serif;text-align:center">DRAFT</div>`
+
+const draftNoIndex = `<meta name="robots" content="noindex">`
+
+// DraftMark flags a page built from a draft: a robots noindex meta tag goes
+// at the start of the head and Banner, DefaultDraftBanner when empty, at
+// the start of the body.  Documents missing either element get them at the
+// very start.
+type DraftMark struct {
+	Banner string
+}
+
+func (d *DraftMark) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	banner := d.Banner
+	if banner == "" {
+		banner = DefaultDraftBanner
+	}
+
+	_, err = w.Write(draftMark(in.Bytes(), banner))
+	return
+}
+
+// NoIndex asks search engines to leave a page alone with a robots noindex
+// meta tag at the start of the head, or of the document without one.
+type NoIndex struct{}
+
+func (n *NoIndex) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	_, err = w.Write(draftMark(in.Bytes(), ""))
+	return
+}
+
+// draftMark puts the noindex meta tag in the head of in and banner, unless
+// empty, in its body.
+func draftMark(in []byte, banner string) []byte {
+	var (
+		out  = new(bytes.Buffer)
+		head = -1
+		body = -1
+	)
+
+	for i := 0; i < len(in) && (head == -1 || body == -1); i++ {
+		if in[i] != '<' {
+			continue
+		}
+		end := tagEnd(in[i:])
+		if end == -1 {
+			break
+		}
+		switch name, closing := tagName(in[i : i+end+1]); {
+		case closing:
+		case name == "head" && head == -1:
+			head = i + end + 1
+		case name == "body" && body == -1:
+			body = i + end + 1
+		}
+		i += end
+	}
+
+	// Marks for missing elements go at the start, the others after the
+	// start tags, in document order.
+	type mark struct {
+		at   int
+		text string
+	}
+	var marks []mark
+	for _, m := range []mark{{head, draftNoIndex}, {body, banner}} {
+		if m.text == "" {
+			continue
+		}
+		if m.at == -1 {
+			m.at = 0
+		}
+		if len(marks) > 0 && m.at < marks[0].at {
+			marks = append([]mark{m}, marks...)
+		} else {
+			marks = append(marks, m)
+		}
+	}
+
+	last := 0
+	for _, m := range marks {
+		out.Write(in[last:m.at])
+		out.WriteString(m.text)
+		last = m.at
+	}
+	out.Write(in[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,25 @@
+package transform
+
+import (
+	"testing"
+)
+
+var draft_tests = []test{
+	{`<html><head><title>a</title></head><body class="x"><p>a</p></body></html>`,
+		`<html><head>` + draftNoIndex + `<title>a</title></head><body class="x">` + DefaultDraftBanner + `<p>a</p></body></html>`},
+	{`<!-- <body> --><html><head></head><body></body></html>`,
+		`<!-- <body> --><html><head>` + draftNoIndex + `</head><body>` + DefaultDraftBanner + `</body></html>`},
+	{`<p>a</p>`, draftNoIndex + DefaultDraftBanner + `<p>a</p>`},
+}
+
+func TestDraftMark(t *testing.T) {
+	apply(t, new(DraftMark), draft_tests)
+}
+
+func TestNoIndex(t *testing.T) {
+	apply(t, new(NoIndex), []test{
+		{`<html><head><title>a</title></head><body><p>a</p></body></html>`,
+			`<html><head>` + draftNoIndex + `<title>a</title></head><body><p>a</p></body></html>`},
+		{`<p>a</p>`, draftNoIndex + `<p>a</p>`},
+	})
+}
@@ -0,0 +1,45 @@
+package transform
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+)
+
+// Alternate is a version of a page in another language.
+type Alternate struct {
+	Lang, Href string
+}
+
+// Hreflang adds a <link rel="alternate" hreflang> tag for every one of
+// Alternates before the closing head tag, or at the start of documents
+// without one.
+type Hreflang struct {
+	Alternates []Alternate
+}
+
+func (h *Hreflang) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	doc := in.Bytes()
+	at := bytes.Index(bytes.ToLower(doc), []byte("</head>"))
+	if at == -1 {
+		at = 0
+	}
+
+	out := new(bytes.Buffer)
+	out.Write(doc[:at])
+	for _, alt := range h.Alternates {
+		out.WriteString(`<link rel="alternate" hreflang="`)
+		out.WriteString(template.HTMLEscapeString(alt.Lang))
+		out.WriteString(`" href="`)
+		out.WriteString(template.HTMLEscapeString(alt.Href))
+		out.WriteString(`">`)
+	}
+	out.Write(doc[at:])
+	_, err = w.Write(out.Bytes())
+	return
+}
@@ -0,0 +1,22 @@
+package transform
+
+import (
+	"testing"
+)
+
+const hreflang_links = `<link rel="alternate" hreflang="en" href="http://auth/about/">` +
+	`<link rel="alternate" hreflang="fr" href="http://auth/fr/a-propos/?a=1&amp;b=2">`
+
+var hreflang_tests = []test{
+	{`<html><head><title>a</title></head><body></body></html>`,
+		`<html><head><title>a</title>` + hreflang_links + `</head><body></body></html>`},
+	{`<HTML><HEAD></HEAD></HTML>`, `<HTML><HEAD>` + hreflang_links + `</HEAD></HTML>`},
+	{`<p>a</p>`, hreflang_links + `<p>a</p>`},
+}
+
+func TestHreflang(t *testing.T) {
+	apply(t, &Hreflang{Alternates: []Alternate{
+		{"en", "http://auth/about/"},
+		{"fr", "http://auth/fr/a-propos/?a=1&b=2"},
+	}}, hreflang_tests)
+}
@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+)
+
+// tagEnd returns the index of the '>' closing the tag starting at in[0],
+// skipping over quoted attribute values, or -1 if the tag is not closed.
+// Comments are treated as a single tag ending at "-->".
+func tagEnd(in []byte) int {
+	if bytes.HasPrefix(in, []byte("<!--")) {
+		end := bytes.Index(in[4:], []byte("-->"))
+		if end == -1 {
+			return -1
+		}
+		return end + 4 + 2
+	}
+
+	var quote byte
+	for i := 1; i < len(in); i++ {
+		c := in[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+func tagName(tag []byte) (name string, closing bool) {
+	tag = bytes.TrimPrefix(tag, []byte("<"))
+	if bytes.HasPrefix(tag, []byte("/")) {
+		closing = true
+		tag = tag[1:]
+	}
+	end := bytes.IndexAny(tag, " \t\r\n/>")
+	if end == -1 {
+		end = len(tag)
+	}
+	return strings.ToLower(string(tag[:end])), closing
+}
+
+type attr struct {
+	key, val string
+}
+
+// tagAttrs returns the attributes of a start tag in the order they appear.
+// Attributes without a value have an empty val.
+func tagAttrs(tag []byte) (attrs []attr) {
+	tag = bytes.TrimSuffix(bytes.TrimSuffix(tag, []byte(">")), []byte("/"))
+	start := bytes.IndexAny(tag, " \t\r\n")
+	if start == -1 {
+		return
+	}
+	in := tag[start:]
+
+	for len(in) > 0 {
+		in = bytes.TrimLeft(in, " \t\r\n/")
+		if len(in) == 0 {
+			break
+		}
+
+		end := bytes.IndexAny(in, " \t\r\n=")
+		if end == -1 {
+			end = len(in)
+		}
+		key := strings.ToLower(string(in[:end]))
+		in = bytes.TrimLeft(in[end:], " \t\r\n")
+
+		if !bytes.HasPrefix(in, []byte("=")) {
+			attrs = append(attrs, attr{key, ""})
+			continue
+		}
+		in = bytes.TrimLeft(in[1:], " \t\r\n")
+
+		var val []byte
+		if len(in) > 0 && (in[0] == '"' || in[0] == '\'') {
+			close := bytes.IndexByte(in[1:], in[0])
+			if close == -1 {
+				val, in = in[1:], nil
+			} else {
+				val, in = in[1:close+1], in[close+2:]
+			}
+		} else {
+			end := bytes.IndexAny(in, " \t\r\n")
+			if end == -1 {
+				end = len(in)
+			}
+			val, in = in[:end], in[end:]
+		}
+		attrs = append(attrs, attr{key, string(val)})
+	}
+	return
+}
+
+func attrValue(attrs []attr, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.key == key {
+			return a.val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Lint passes html through unchanged, reporting likely problems in it as
+// warnings: elements that are never closed, closing tags without a matching
+// element, duplicate ids and images without alt text.  Source names the file
+// the html was generated from in the warnings.
+type Lint struct {
+	Source   string
+	Warnings io.Writer
+}
+
+// Elements that never have a closing tag.
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "keygen", "link", "meta", "param", "source", "track", "wbr", "!doctype"}
+
+// Elements whose closing tag may legitimately be left out.
+var optionalEndElements = []string{"html", "head", "body", "p", "li", "dt", "dd", "option", "optgroup", "thead", "tbody", "tfoot", "tr", "td", "th", "colgroup", "caption", "rt", "rp"}
+
+// Elements whose contents are not markup.
+var rawTextElements = []string{"script", "style"}
+
+func (l *Lint) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	out := l.Warnings
+	if out == nil {
+		out = os.Stderr
+	}
+	for _, warning := range lint(in.Bytes()) {
+		fmt.Fprintf(out, "WARNING: %s: %s\n", l.Source, warning)
+	}
+
+	_, err = w.Write(in.Bytes())
+	return
+}
+
+func lint(in []byte) (warnings []string) {
+	var (
+		open []string
+		ids  = make(map[string]bool)
+	)
+
+	for i := 0; i < len(in); i++ {
+		if in[i] != '<' {
+			continue
+		}
+
+		end := tagEnd(in[i:])
+		if end == -1 {
+			warnings = append(warnings, "unterminated tag at end of document")
+			break
+		}
+		tag := in[i : i+end+1]
+		i += end
+
+		if bytes.HasPrefix(tag, []byte("<!--")) {
+			continue
+		}
+
+		name, closing := tagName(tag)
+		if name == "" {
+			continue
+		}
+
+		if closing {
+			var ok bool
+			if open, ok = closeElement(open, name); !ok {
+				warnings = append(warnings, fmt.Sprintf("closing tag </%s> without matching open tag", name))
+			}
+			continue
+		}
+
+		attrs := tagAttrs(tag)
+		if id, ok := attrValue(attrs, "id"); ok {
+			if ids[id] {
+				warnings = append(warnings, fmt.Sprintf("duplicate id %q", id))
+			}
+			ids[id] = true
+		}
+
+		if name == "img" {
+			if _, ok := attrValue(attrs, "alt"); !ok {
+				src, _ := attrValue(attrs, "src")
+				warnings = append(warnings, fmt.Sprintf("<img> without alt attribute (src %q)", src))
+			}
+		}
+
+		if inList(voidElements, name) || bytes.HasSuffix(tag, []byte("/>")) {
+			continue
+		}
+
+		if inList(rawTextElements, name) {
+			closeTag := []byte("</" + name)
+			skip := bytes.Index(bytes.ToLower(in[i:]), closeTag)
+			if skip == -1 {
+				warnings = append(warnings, fmt.Sprintf("<%s> is never closed", name))
+				break
+			}
+			i += skip - 1
+		}
+
+		open = append(open, name)
+	}
+
+	for _, name := range open {
+		if !inList(optionalEndElements, name) {
+			warnings = append(warnings, fmt.Sprintf("<%s> is never closed", name))
+		}
+	}
+	return
+}
+
+// closeElement pops the innermost open element named name.  Elements opened
+// after it are implicitly closed, which is only valid when their closing tag
+// is optional; anything else is left open and reported at the end.
+func closeElement(open []string, name string) ([]string, bool) {
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == name {
+			var remaining []string
+			for _, unclosed := range open[i+1:] {
+				if !inList(optionalEndElements, unclosed) {
+					remaining = append(remaining, unclosed)
+				}
+			}
+			return append(open[:i], remaining...), true
+		}
+	}
+	return open, false
+}
+
+func inList(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		content  string
+		warnings []string
+	}{
+		{"<!DOCTYPE html><html><head><meta charset=\"utf-8\"></head><body><p>one<p>two<br><img src=\"a.png\" alt=\"\"></body></html>", nil},
+		{"<html><body><div><span>x</div></body></html>", []string{"<span> is never closed"}},
+		{"<html><body><div>x</div></div></body></html>", []string{"closing tag </div> without matching open tag"}},
+		{"<html><body><div id=\"a\"></div><div id='a'></div></body></html>", []string{"duplicate id \"a\""}},
+		{"<html><body><img src=\"a.png\"></body></html>", []string{"<img> without alt attribute (src \"a.png\")"}},
+		{"<html><body><a title=\"a > b\">x</a><script>if (a < b) { document.write(\"<div>\") }</script></body></html>", nil},
+	}
+
+	for _, test := range tests {
+		warnings := lint([]byte(test.content))
+		if strings.Join(warnings, "\n") != strings.Join(test.warnings, "\n") {
+			t.Errorf("Lint of %q expected warnings:\n%q\ngot:\n%q", test.content, test.warnings, warnings)
+		}
+	}
+}
+
+func TestLintPassesContentThrough(t *testing.T) {
+	warnings := new(bytes.Buffer)
+	tr := &Lint{Source: "post/foo.md", Warnings: warnings}
+	apply(t, tr, []test{{"<img src=\"a.png\">", "<img src=\"a.png\">"}})
+
+	expected := "WARNING: post/foo.md: <img> without alt attribute (src \"a.png\")\n"
+	if warnings.String() != expected {
+		t.Errorf("Lint warnings expected: %q, got: %q", expected, warnings.String())
+	}
+}
+
+func TestLintInChain(t *testing.T) {
+	warnings := new(bytes.Buffer)
+	tr := NewPriorityChain(
+		Entry{Priority: PriorityAbsURL, Transformer: &AbsURL{BaseURL: "http://base/"}},
+		Entry{Priority: PriorityTypography, Transformer: new(Typography)},
+		Entry{Priority: PriorityLint, Transformer: &Lint{Source: "post/foo.md", Warnings: warnings}},
+	)
+	out := new(bytes.Buffer)
+	if err := tr.Apply(out, strings.NewReader("<html><body><div><span>x</div></div></body></html>")); err != nil {
+		t.Fatalf("Unable to apply the chain: %s", err)
+	}
+
+	expected := "WARNING: post/foo.md: closing tag </div> without matching open tag\nWARNING: post/foo.md: <span> is never closed\n"
+	if warnings.String() != expected {
+		t.Errorf("Lint warnings expected: %q, got: %q", expected, warnings.String())
+	}
+}
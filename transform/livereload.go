@@ -0,0 +1,42 @@
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// LiveReloadPath is where the server accepts the websockets of the pages
+// to reload.
+const LiveReloadPath = "/__livereload"
+
+// LiveReloadInject adds a script before the closing body tag (or at the end
+// of documents without one) reloading the page whenever the server it was
+// served from sends "reload" on its LiveReloadPath websocket.
+type LiveReloadInject struct{}
+
+var liveReloadScript = fmt.Sprintf(`<script>(function() {
+	if (!window.WebSocket) return;
+	var ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "%s");
+	ws.onmessage = function(e) { if (e.data == "reload") location.reload(); };
+})();</script>`, LiveReloadPath)
+
+func (l *LiveReloadInject) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	doc := in.Bytes()
+	at := bytes.LastIndex(bytes.ToLower(doc), []byte("</body>"))
+	if at == -1 {
+		at = len(doc)
+	}
+
+	out := new(bytes.Buffer)
+	out.Write(doc[:at])
+	out.WriteString(liveReloadScript)
+	out.Write(doc[at:])
+	_, err = w.Write(out.Bytes())
+	return
+}
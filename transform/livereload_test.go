@@ -0,0 +1,22 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+)
+
+var livereload_tests = []test{
+	{`<html><body><p>a</p></body></html>`, `<html><body><p>a</p>` + liveReloadScript + `</body></html>`},
+	{`<HTML><BODY>a</BODY></HTML>`, `<HTML><BODY>a` + liveReloadScript + `</BODY></HTML>`},
+	{`<p>a</p>`, `<p>a</p>` + liveReloadScript},
+}
+
+func TestLiveReloadInject(t *testing.T) {
+	apply(t, new(LiveReloadInject), livereload_tests)
+}
+
+func TestLiveReloadScheme(t *testing.T) {
+	if !strings.Contains(liveReloadScript, `(location.protocol === "https:" ? "wss://" : "ws://")`) {
+		t.Errorf("Expected the websocket to follow the scheme of the page, got: %s", liveReloadScript)
+	}
+}
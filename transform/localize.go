@@ -0,0 +1,201 @@
+package transform
+
+import (
+	htmltran "code.google.com/p/go-html-transform/html/transform"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LocalizeAssets downloads stylesheets, scripts and images referenced from
+// one of Hosts into Dir under PublishDir and rewrites the references to point
+// at the local copy.  Each asset is fetched only once per LocalizeAssets,
+// pages referencing one being fetched waiting for it.
+type LocalizeAssets struct {
+	Hosts      []string
+	BaseURL    string
+	PublishDir string
+	Dir        string
+
+	// Fetch retrieves a remote asset.  It defaults to an http GET.
+	Fetch func(u string) (io.ReadCloser, error)
+
+	// Publish writes an asset to its path relative to the publish dir.  It
+	// defaults to writing the file below PublishDir.
+	Publish func(path string, r io.Reader) error
+
+	mu    sync.Mutex
+	local map[string]*localAsset
+}
+
+// localAsset is an asset being fetched, or fetched already once done is
+// closed.
+type localAsset struct {
+	done chan struct{}
+	out  string
+	err  error
+}
+
+func (t *LocalizeAssets) Apply(w io.Writer, r io.Reader) (err error) {
+	var tr *htmltran.Transformer
+
+	if tr, err = htmltran.NewFromReader(r); err != nil {
+		return
+	}
+
+	var fetchErr error
+	replace := func(in string) string {
+		out, err := t.localize(in)
+		if err != nil {
+			if fetchErr == nil {
+				fetchErr = err
+			}
+			return in
+		}
+		return out
+	}
+
+	for _, el := range []elattr{{"link", "href"}, {"script", "src"}, {"img", "src"}} {
+		if err = tr.Apply(htmltran.TransformAttrib(el.attr, replace), el.tag); err != nil {
+			return
+		}
+	}
+
+	if fetchErr != nil {
+		return fetchErr
+	}
+
+	return tr.Render(w)
+}
+
+func (t *LocalizeAssets) localize(in string) (string, error) {
+	u, err := url.Parse(in)
+	if err != nil || !t.isExternal(u) {
+		return in, nil
+	}
+
+	t.mu.Lock()
+	if t.local == nil {
+		t.local = make(map[string]*localAsset)
+	}
+	a, fetching := t.local[in]
+	if !fetching {
+		a = &localAsset{done: make(chan struct{})}
+		t.local[in] = a
+	}
+	t.mu.Unlock()
+
+	if fetching {
+		<-a.done
+		return a.out, a.err
+	}
+
+	a.out, a.err = t.fetch(u)
+	if a.err != nil {
+		// The next page referencing the asset tries again.
+		t.mu.Lock()
+		delete(t.local, in)
+		t.mu.Unlock()
+	}
+	close(a.done)
+	return a.out, a.err
+}
+
+// fetch downloads the asset at u and returns its local url.
+func (t *LocalizeAssets) fetch(u *url.URL) (string, error) {
+	rel := t.localPath(u)
+	if err := t.download(u, rel); err != nil {
+		return "", err
+	}
+
+	base, err := url.Parse(t.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(&url.URL{Path: "/" + rel}).String(), nil
+}
+
+func (t *LocalizeAssets) isExternal(u *url.URL) bool {
+	if u.Host == "" {
+		return false
+	}
+	for _, h := range t.Hosts {
+		if strings.EqualFold(u.Host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// localPath maps a remote asset to a path relative to PublishDir.  Query
+// strings are kept as part of the file name since font and widget services
+// commonly use them to select the asset.
+func (t *LocalizeAssets) localPath(u *url.URL) string {
+	dir := t.Dir
+	if dir == "" {
+		dir = "external"
+	}
+
+	p := u.Path
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index"
+	}
+	if u.RawQuery != "" {
+		p += "_" + strings.NewReplacer("&", "_", "=", "-", "/", "_").Replace(u.RawQuery)
+	}
+	return path.Join(dir, strings.ToLower(u.Host), path.Clean("/"+p))
+}
+
+func (t *LocalizeAssets) download(u *url.URL, rel string) (err error) {
+	fetch := t.Fetch
+	if fetch == nil {
+		fetch = httpFetch
+	}
+
+	remote := *u
+	if remote.Scheme == "" {
+		remote.Scheme = "http"
+	}
+
+	body, err := fetch(remote.String())
+	if err != nil {
+		return fmt.Errorf("Unable to download %s: %s", remote.String(), err)
+	}
+	defer body.Close()
+
+	if t.Publish != nil {
+		return t.Publish(rel, body)
+	}
+
+	dest := filepath.Join(t.PublishDir, filepath.FromSlash(rel))
+	if err = os.MkdirAll(filepath.Dir(dest), 0764); err != nil {
+		return
+	}
+
+	file, err := os.Create(dest)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, body)
+	return
+}
+
+func httpFetch(u string) (io.ReadCloser, error) {
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const H5_WITH_EXTERNAL_ASSETS = "<!DOCTYPE html><html><head><link rel=\"stylesheet\" href=\"//fonts.example.com/css?family=Open+Sans\"/><script src=\"http://widgets.example.com/w.js\"></script><script src=\"http://other.com/o.js\"></script></head><body><img src=\"https://fonts.example.com/img/logo.png\"/></body></html>"
+
+const CORRECT_OUTPUT_EXTERNAL_ASSETS = "<!DOCTYPE html><html><head><link rel=\"stylesheet\" href=\"http://base/external/fonts.example.com/css_family-Open+Sans\"/><script src=\"http://base/external/widgets.example.com/w.js\"></script><script src=\"http://other.com/o.js\"></script></head><body><img src=\"http://base/external/fonts.example.com/img/logo.png\"/></body></html>"
+
+func TestLocalizeAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo-localize")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var fetched []string
+	tr := &LocalizeAssets{
+		Hosts:      []string{"fonts.example.com", "widgets.example.com"},
+		BaseURL:    "http://base/",
+		PublishDir: dir,
+		Fetch: func(u string) (io.ReadCloser, error) {
+			fetched = append(fetched, u)
+			return ioutil.NopCloser(strings.NewReader("asset " + u)), nil
+		},
+	}
+
+	apply(t, tr, []test{
+		{H5_WITH_EXTERNAL_ASSETS, CORRECT_OUTPUT_EXTERNAL_ASSETS},
+		{H5_WITH_EXTERNAL_ASSETS, CORRECT_OUTPUT_EXTERNAL_ASSETS},
+	})
+
+	if len(fetched) != 3 {
+		t.Errorf("Expected every asset to be fetched once, got: %v", fetched)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "external", "widgets.example.com", "w.js"))
+	if err != nil {
+		t.Fatalf("Asset was not written to the publish dir: %s", err)
+	}
+	if string(content) != "asset http://widgets.example.com/w.js" {
+		t.Errorf("Unexpected asset content: %q", content)
+	}
+}
+
+func TestLocalizeAssetsFetchError(t *testing.T) {
+	tr := &LocalizeAssets{
+		Hosts: []string{"widgets.example.com"},
+		Fetch: func(u string) (io.ReadCloser, error) {
+			return nil, errors.New("offline")
+		},
+	}
+
+	out := new(bytes.Buffer)
+	if err := tr.Apply(out, strings.NewReader(H5_WITH_EXTERNAL_ASSETS)); err == nil {
+		t.Errorf("Expected a download failure to be reported")
+	}
+}
+
+func TestLocalizeAssetsPublish(t *testing.T) {
+	published := make(map[string]string)
+	var mu sync.Mutex
+	fetching := make(chan bool)
+	tr := &LocalizeAssets{
+		Hosts:   []string{"fonts.example.com", "widgets.example.com"},
+		BaseURL: "http://base/",
+		Fetch: func(u string) (io.ReadCloser, error) {
+			if strings.Contains(u, "slow") {
+				fetching <- true
+				<-fetching
+			}
+			return ioutil.NopCloser(strings.NewReader("asset " + u)), nil
+		},
+		Publish: func(path string, r io.Reader) error {
+			content, err := ioutil.ReadAll(r)
+			mu.Lock()
+			published[path] = string(content)
+			mu.Unlock()
+			return err
+		},
+	}
+
+	done := make(chan string)
+	go func() {
+		out, _ := tr.localize("http://widgets.example.com/slow.js")
+		done <- out
+	}()
+	<-fetching
+	// Other assets are fetched while the slow one still is.
+	if out, err := tr.localize("http://fonts.example.com/a.css"); err != nil || out != "http://base/external/fonts.example.com/a.css" {
+		t.Errorf("Expected a.css to be localized meanwhile, got %q, %v", out, err)
+	}
+	fetching <- false
+	if out := <-done; out != "http://base/external/widgets.example.com/slow.js" {
+		t.Errorf("Unexpected url of slow.js: %q", out)
+	}
+
+	if published["external/widgets.example.com/slow.js"] != "asset http://widgets.example.com/slow.js" {
+		t.Errorf("Expected the assets to be published through Publish, got: %v", published)
+	}
+}
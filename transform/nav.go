@@ -4,11 +4,20 @@ import (
 	htmltran "code.google.com/p/go-html-transform/html/transform"
 	"fmt"
 	"io"
+	"strings"
 )
 
+// NavActive adds class="active" to the navigation elements matching Section.
+// By default an element is matched when it is an li whose hugo-nav attribute
+// equals Section.  Element and AttrName change the markup that is looked for.
+// With MatchPrefix set, elements whose attribute names one of the parent
+// paths of Section are matched as well, so a nav item for "/blog/" stays
+// active on "/blog/2013/some-post/".
 type NavActive struct {
-	Section  string
-	AttrName string
+	Section     string
+	AttrName    string
+	Element     string
+	MatchPrefix bool
 }
 
 func (n *NavActive) Apply(w io.Writer, r io.Reader) (err error) {
@@ -27,10 +36,42 @@ func (n *NavActive) Apply(w io.Writer, r io.Reader) (err error) {
 		n.AttrName = "hugo-nav"
 	}
 
-	err = tr.Apply(htmltran.ModifyAttrib("class", "active"), fmt.Sprintf("li[%s=%s]", n.AttrName, n.Section))
-	if err != nil {
-		return
+	element := n.Element
+	if element == "" {
+		element = "li"
+	}
+
+	for _, value := range n.values() {
+		err = tr.Apply(htmltran.ModifyAttrib("class", "active"), fmt.Sprintf("%s[%s=%s]", element, n.AttrName, value))
+		if err != nil {
+			return
+		}
 	}
 
 	return tr.Render(w)
 }
+
+// values returns the attribute values that mark an element as active.
+func (n *NavActive) values() (values []string) {
+	if !n.MatchPrefix {
+		return []string{n.Section}
+	}
+
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+
+	for i, c := range n.Section {
+		if c == '/' && i > 0 {
+			add(n.Section[:i])
+			add(n.Section[:i+1])
+		}
+	}
+	add(strings.TrimSuffix(n.Section, "/"))
+	add(n.Section)
+	return
+}
@@ -57,4 +57,35 @@ func TestSetNav(t *testing.T) {
 	if out.String() != expected {
 		t.Errorf("NavActive.Apply output expected and got:\n%q\n%q", expected, out.String())
 	}
+	if tr.Element != "" {
+		t.Errorf("NavActive.Apply should leave Element unset, got %q", tr.Element)
+	}
+}
+
+const HTML_WITH_NAV_ANCHORS = `<!DOCTYPE html><html><head></head><body><nav><a data-section="/blog/">Blog</a><a data-section="/blog/2013/">2013</a><a data-section="/about/">About</a></nav></body></html>`
+
+func TestSetNavElementAndAttr(t *testing.T) {
+	tr := &NavActive{Section: "/about/", Element: "a", AttrName: "data-section"}
+	out := new(bytes.Buffer)
+	if err := tr.Apply(out, strings.NewReader(HTML_WITH_NAV_ANCHORS)); err != nil {
+		t.Errorf("Unexpected error in Apply() for NavActive: %s", err)
+	}
+
+	expected := `<!DOCTYPE html><html><head></head><body><nav><a data-section="/blog/">Blog</a><a data-section="/blog/2013/">2013</a><a data-section="/about/" class="active">About</a></nav></body></html>`
+	if out.String() != expected {
+		t.Errorf("NavActive.Apply output expected and got:\n%q\n%q", expected, out.String())
+	}
+}
+
+func TestSetNavMatchPrefix(t *testing.T) {
+	tr := &NavActive{Section: "/blog/2013/some-post/", Element: "a", AttrName: "data-section", MatchPrefix: true}
+	out := new(bytes.Buffer)
+	if err := tr.Apply(out, strings.NewReader(HTML_WITH_NAV_ANCHORS)); err != nil {
+		t.Errorf("Unexpected error in Apply() for NavActive: %s", err)
+	}
+
+	expected := `<!DOCTYPE html><html><head></head><body><nav><a data-section="/blog/" class="active">Blog</a><a data-section="/blog/2013/" class="active">2013</a><a data-section="/about/">About</a></nav></body></html>`
+	if out.String() != expected {
+		t.Errorf("NavActive.Apply output expected and got:\n%q\n%q", expected, out.String())
+	}
 }
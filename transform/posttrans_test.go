@@ -47,3 +47,16 @@ func apply(t *testing.T, tr Transformer, tests []test) {
 		}
 	}
 }
+
+func TestAbsURLInXML(t *testing.T) {
+	tr := &AbsURLInXML{BaseURL: "http://base/blog/"}
+	apply(t, tr, []test{
+		{`<description>&lt;a href=&#34;/foo&#34;&gt;a&lt;/a&gt; &lt;img src=&#34;b.png&#34;&gt;</description>`,
+			`<description>&lt;a href=&#34;http://base/foo&#34;&gt;a&lt;/a&gt; &lt;img src=&#34;http://base/blog/b.png&#34;&gt;</description>`},
+		{`<description><![CDATA[<a href="/foo">a</a> <a href='#top'>b</a>]]></description>`,
+			`<description><![CDATA[<a href="http://base/foo">a</a> <a href='#top'>b</a>]]></description>`},
+		{`<atom:link href="http://auth/index.xml" rel="self"/><a href=&#34;//host/x&#34;>`,
+			`<atom:link href="http://auth/index.xml" rel="self"/><a href=&#34;//host/x&#34;>`},
+		{`<p>src=&#34;/not-an-attribute&#34;</p>`, `<p>src=&#34;/not-an-attribute&#34;</p>`},
+	})
+}
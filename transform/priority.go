@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"sort"
+)
+
+// Priorities of the transformers Site includes in its chain.  Custom
+// transformers are ordered relative to these; lower priorities run first.
+// Lint runs first, on the html as the templates wrote it, before AbsURL
+// parses it and writes it out again well formed.
+const (
+	PriorityLint           = 0
+	PriorityAbsURL         = 100
+	PriorityLocalizeAssets = 150
+	PriorityNavActive      = 200
+	PriorityDraftMark      = 250
+	PriorityNoIndex        = 250
+	PriorityHreflang       = 260
+	PriorityTypography     = 300
+	PriorityLiveReload     = 900
+)
+
+// Entry pairs a Transformer with its position in a chain.
+type Entry struct {
+	Priority    int
+	Transformer Transformer
+}
+
+type entries []Entry
+
+func (e entries) Len() int           { return len(e) }
+func (e entries) Less(i, j int) bool { return e[i].Priority < e[j].Priority }
+func (e entries) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// NewPriorityChain builds a chain running the transformers in ascending
+// priority.  Entries sharing a priority run in the order they were given.
+func NewPriorityChain(es ...Entry) Transformer {
+	sorted := make(entries, len(es))
+	copy(sorted, es)
+	sort.Stable(sorted)
+
+	trs := make([]Transformer, len(sorted))
+	for i, e := range sorted {
+		trs[i] = e.Transformer
+	}
+	return NewChain(trs...)
+}
@@ -0,0 +1,165 @@
+package transform
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Elements removed, along with their contents, by a Sanitize without
+// Elements set.
+var DefaultSanitizeElements = []string{"script", "style", "iframe", "frame", "frameset", "object", "embed", "applet", "form", "base", "link", "meta"}
+
+// Attributes removed by a Sanitize without Attributes set.  A trailing *
+// matches any attribute starting with the rest of the name.
+var DefaultSanitizeAttributes = []string{"on*", "formaction", "srcdoc"}
+
+// Attributes holding urls.  Urls with a scheme able to run code are removed
+// from them whatever the policy.
+var urlAttributes = []string{"href", "src", "action", "background", "cite", "data", "poster", "xlink:href"}
+
+var unsafeSchemes = []string{"javascript:", "vbscript:", "data:text/html"}
+
+// Sanitize strips markup able to run scripts from html that cannot be
+// trusted, such as user submitted content.
+type Sanitize struct {
+	Elements   []string
+	Attributes []string
+}
+
+func (s *Sanitize) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	_, err = w.Write(s.Clean(in.Bytes()))
+	return
+}
+
+// Clean returns in with the elements and attributes of the policy removed.
+func (s *Sanitize) Clean(in []byte) []byte {
+	elements := s.Elements
+	if len(elements) == 0 {
+		elements = DefaultSanitizeElements
+	}
+
+	out := new(bytes.Buffer)
+	for i := 0; i < len(in); i++ {
+		if in[i] != '<' {
+			out.WriteByte(in[i])
+			continue
+		}
+
+		end := tagEnd(in[i:])
+		if end == -1 {
+			// An unterminated tag could be completed by the markup around
+			// the content, so escape it.
+			out.WriteString("&lt;")
+			continue
+		}
+		tag := in[i : i+end+1]
+		i += end
+
+		// Comments are dropped too, conditional comments being able to
+		// hold markup old browsers run.
+		name, closing := tagName(tag)
+		if name == "" || strings.HasPrefix(name, "!") || strings.HasPrefix(name, "?") {
+			if name == "" && !bytes.HasPrefix(tag, []byte("<!--")) {
+				out.Write(tag)
+			}
+			continue
+		}
+
+		if inList(elements, name) {
+			if !closing && !bytes.HasSuffix(tag, []byte("/>")) && !inList(voidElements, name) {
+				i += skipElement(in[i+1:], name)
+			}
+			continue
+		}
+
+		if closing {
+			out.Write(tag)
+			continue
+		}
+
+		out.WriteString("<" + name)
+		for _, a := range tagAttrs(tag) {
+			if s.removeAttr(a) {
+				continue
+			}
+			out.WriteString(" " + a.key + "=\"" + strings.Replace(a.val, "\"", "&#34;", -1) + "\"")
+		}
+		if bytes.HasSuffix(tag, []byte("/>")) {
+			out.WriteString(" /")
+		}
+		out.WriteString(">")
+	}
+	return out.Bytes()
+}
+
+func (s *Sanitize) removeAttr(a attr) bool {
+	attributes := s.Attributes
+	if len(attributes) == 0 {
+		attributes = DefaultSanitizeAttributes
+	}
+
+	for _, pattern := range attributes {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(a.key, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if a.key == pattern {
+			return true
+		}
+	}
+
+	// Browsers decode the entities of the value and ignore the whitespace
+	// and control characters in the scheme, so "jav&#x61;script:" and
+	// "java\tscript:" run too.
+	if inList(urlAttributes, a.key) {
+		val := strings.ToLower(strings.Map(func(r rune) rune {
+			if r <= ' ' || unicode.IsSpace(r) || unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, html.UnescapeString(a.val)))
+		for _, scheme := range unsafeSchemes {
+			if strings.HasPrefix(val, scheme) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipElement returns the number of bytes up to and including the closing
+// tag of the element named name, allowing for nested elements of the same
+// name.  The rest of the document is skipped if it is never closed.
+func skipElement(in []byte, name string) int {
+	depth := 1
+	for i := 0; i < len(in); i++ {
+		if in[i] != '<' {
+			continue
+		}
+		end := tagEnd(in[i:])
+		if end == -1 {
+			break
+		}
+		tag := in[i : i+end+1]
+		if n, closing := tagName(tag); n == name {
+			if closing {
+				depth--
+			} else if !bytes.HasSuffix(tag, []byte("/>")) {
+				depth++
+			}
+			if depth == 0 {
+				return i + end + 1
+			}
+		}
+		i += end
+	}
+	return len(in)
+}
@@ -0,0 +1,34 @@
+package transform
+
+import (
+	"testing"
+)
+
+var sanitize_tests = []test{
+	{`<p>hello <b>world</b></p>`, `<p>hello <b>world</b></p>`},
+	{`<p>a</p><script>alert("x")</script><p>b</p>`, `<p>a</p><p>b</p>`},
+	{`<iframe src="http://evil"><iframe></iframe></iframe>after`, `after`},
+	{`<img src="a.png" onerror='alert(1)' alt="a">`, `<img src="a.png" alt="a">`},
+	{`<a href=" JavaScript:alert(1)" title="t">x</a>`, `<a title="t">x</a>`},
+	{`<a href="/ok" title='say "hi"'>x</a>`, `<a href="/ok" title="say &#34;hi&#34;">x</a>`},
+	{`<br/><embed src="x.swf">`, `<br />`},
+	{`<!-- comment --><p onclick="x">y</p>`, `<p>y</p>`},
+	{`<!--[if IE]><script>alert(1)</script><![endif]-->x`, `x`},
+	{`<a href="jav&#x61;script:alert(1)">x</a>`, `<a>x</a>`},
+	{`<a href="&#106;avascript&colon;alert(1)">x</a>`, `<a>x</a>`},
+	{"<a href=\"java\tscr\nipt:alert(1)\">x</a>", `<a>x</a>`},
+	{"<a href=\"\x01javascript:alert(1)\">x</a>", `<a>x</a>`},
+	{`<img src="data&#58;text/html,x">`, `<img>`},
+	{`a < b`, `a &lt; b`},
+}
+
+func TestSanitize(t *testing.T) {
+	apply(t, new(Sanitize), sanitize_tests)
+}
+
+func TestSanitizePolicy(t *testing.T) {
+	tr := &Sanitize{Elements: []string{"marquee"}, Attributes: []string{"style", "data-*"}}
+	apply(t, tr, []test{
+		{`<marquee>x</marquee><p style="color: red" data-x="1" class="c">y</p>`, `<p class="c">y</p>`},
+	})
+}
@@ -0,0 +1,150 @@
+package transform
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Typography replaces straight quotes, dashes and ellipses in the text of an
+// html document with their typographic equivalents:
+//
+//	"quoted"  => &ldquo;quoted&rdquo;
+//	it's      => it&rsquo;s
+//	a -- b    => a &ndash; b
+//	a --- b   => a &mdash; b
+//	...       => &hellip;
+//
+// Markup, attribute values and the contents of code, pre, kbd, samp, tt,
+// script, style and textarea elements are left untouched.
+type Typography struct{}
+
+var typographySkipElements = []string{"code", "pre", "kbd", "samp", "tt", "script", "style", "textarea"}
+
+// Elements starting or ending a block of text, after which a quote opens.
+var typographyBlockElements = []string{"p", "div", "br", "hr", "li", "dt", "dd", "td", "th", "blockquote", "figcaption",
+	"h1", "h2", "h3", "h4", "h5", "h6", "section", "article", "aside", "header", "footer", "body"}
+
+var typographyQuotes = []struct {
+	from  string
+	quote byte
+}{
+	{"&#34;", '"'},
+	{"&quot;", '"'},
+	{"&#39;", '\''},
+}
+
+func (t *Typography) Apply(w io.Writer, r io.Reader) (err error) {
+	in := new(bytes.Buffer)
+	if _, err = in.ReadFrom(r); err != nil {
+		return
+	}
+
+	_, err = w.Write(typography(in.Bytes()))
+	return
+}
+
+func typography(in []byte) []byte {
+	var (
+		out  = new(bytes.Buffer)
+		skip = 0
+		prev byte
+	)
+
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+
+		if c == '<' {
+			end := tagEnd(in[i:])
+			if end == -1 {
+				out.Write(in[i:])
+				break
+			}
+			tag := in[i : i+end+1]
+			name, closing := tagName(tag)
+			if inList(typographyBlockElements, name) {
+				prev = 0
+			}
+			if isTypographySkip(name) {
+				if closing {
+					if skip > 0 {
+						skip--
+					}
+				} else if !bytes.HasSuffix(tag, []byte("/>")) {
+					skip++
+				}
+			}
+			out.Write(tag)
+			i += end
+			continue
+		}
+
+		if skip > 0 {
+			out.WriteByte(c)
+			continue
+		}
+
+		rest := in[i:]
+		switch {
+		case c == '&':
+			quote, n := escapedQuote(rest)
+			if n == 0 {
+				out.WriteByte(c)
+				break
+			}
+			out.WriteString(smartQuote(quote, prev))
+			i += n - 1
+			c = quote
+		case c == '"' || c == '\'':
+			out.WriteString(smartQuote(c, prev))
+		case bytes.HasPrefix(rest, []byte("...")):
+			out.WriteString("&hellip;")
+			i += 2
+			c = '.'
+		case bytes.HasPrefix(rest, []byte("---")):
+			out.WriteString("&mdash;")
+			i += 2
+		case bytes.HasPrefix(rest, []byte("--")):
+			out.WriteString("&ndash;")
+			i++
+		default:
+			out.WriteByte(c)
+		}
+		prev = c
+	}
+
+	return out.Bytes()
+}
+
+// smartQuote picks the opening or closing form of quote based on the
+// character preceding it.
+func smartQuote(quote, prev byte) string {
+	opening := prev == 0 || strings.IndexByte(" \t\r\n([{-", prev) != -1
+	switch {
+	case quote == '"' && opening:
+		return "&ldquo;"
+	case quote == '"':
+		return "&rdquo;"
+	case opening:
+		return "&lsquo;"
+	}
+	return "&rsquo;"
+}
+
+func escapedQuote(in []byte) (quote byte, n int) {
+	for _, q := range typographyQuotes {
+		if bytes.HasPrefix(in, []byte(q.from)) {
+			return q.quote, len(q.from)
+		}
+	}
+	return 0, 0
+}
+
+func isTypographySkip(name string) bool {
+	for _, el := range typographySkipElements {
+		if el == name {
+			return true
+		}
+	}
+	return false
+}
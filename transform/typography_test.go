@@ -0,0 +1,22 @@
+package transform
+
+import (
+	"testing"
+)
+
+var typography_tests = []test{
+	{`<p>"Hello" -- it's a test...</p>`, `<p>&ldquo;Hello&rdquo; &ndash; it&rsquo;s a test&hellip;</p>`},
+	{`<p>one --- two</p>`, `<p>one &mdash; two</p>`},
+	{`<p>'single' and &#34;escaped&#34;</p>`, `<p>&lsquo;single&rsquo; and &ldquo;escaped&rdquo;</p>`},
+	{`<a href="/it's--here">x</a>`, `<a href="/it's--here">x</a>`},
+	{`<pre><code>x := "a" -- 'b'...</code></pre><p>"c"</p>`, `<pre><code>x := "a" -- 'b'...</code></pre><p>&ldquo;c&rdquo;</p>`},
+	{`<script>var a = "b";</script>`, `<script>var a = "b";</script>`},
+	{`<!-- a -- comment -->`, `<!-- a -- comment -->`},
+	{`<p>one.</p><p>"two"</p>`, `<p>one.</p><p>&ldquo;two&rdquo;</p>`},
+	{`<li>a</li><li>'b'</li>`, `<li>a</li><li>&lsquo;b&rsquo;</li>`},
+	{`<p>"a <em>b</em>" c</p>`, `<p>&ldquo;a <em>b</em>&rdquo; c</p>`},
+}
+
+func TestTypography(t *testing.T) {
+	apply(t, new(Typography), typography_tests)
+}